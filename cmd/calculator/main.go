@@ -3,15 +3,15 @@ package main
 import (
 	"context"
 	"log"
-	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
+	"factorial-cal-services/pkg/app"
 	"factorial-cal-services/pkg/config"
 	"factorial-cal-services/pkg/db"
-	"factorial-cal-services/pkg/repository"
 	"factorial-cal-services/pkg/service"
+
+	"github.com/redis/go-redis/v9"
 )
 
 func main() {
@@ -28,26 +28,30 @@ func main() {
 	}
 	log.Println("Connected to database")
 
-	ctx := context.Background()
-	factorialService := service.NewFactorialService(
-		repository.NewFactorialRepository(database),
-		repository.NewCurrentCalculatedRepository(database),
-		repository.NewMaxRequestRepository(database),
-		service.NewS3Service(ctx, cfg),
-	)
-
-	factorialService.StartContinuelyCalculateFactorial()
-
-	log.Println("Calculate started, waiting for messages...")
-
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	// Wait for interrupt signal
-	<-quit
-	log.Println("Received shutdown signal, starting graceful shutdown...")
+	// Initialize Redis
+	redisClient := redis.NewClient(&redis.Options{
+		Addr:     cfg.RedisAddr(),
+		Password: cfg.REDIS_PASSWORD,
+	})
+	defer redisClient.Close()
+
+	// Cancelled on SIGINT/SIGTERM to start the graceful shutdown, a second signal stops at once
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	context.AfterFunc(ctx, stop)
+
+	if err := redisClient.Ping(ctx).Err(); err != nil {
+		log.Printf("Warning: Redis connection failed, waiting clients will not be notified: %v", err)
+	} else {
+		log.Println("Connected to Redis successfully")
+	}
 
-	// Wait for graceful shutdown or timeout
-	<-time.After(5 * time.Second)
+	storage, err := service.NewStorageService(ctx, cfg)
+	if err != nil {
+		log.Fatalf("Failed to create %s storage: %v", cfg.STORAGE_TYPE, err)
+	}
 
-	log.Println("Calculator stopped")
+	if err := app.RunCalculator(ctx, cfg, database, redisClient, storage); err != nil {
+		log.Fatalf("Calculator failed: %v", err)
+	}
 }
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"factorial-cal-services/pkg/config"
+	"factorial-cal-services/pkg/db"
+	"factorial-cal-services/pkg/repository"
+	"factorial-cal-services/pkg/service"
+)
+
+func main() {
+	cfg := config.LoadConfig()
+
+	// Validate configuration
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Configuration validation failed: %v", err)
+	}
+
+	database, err := db.NewGormDB(cfg.DSN())
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	log.Println("Connected to database")
+
+	// Cancelled on SIGINT/SIGTERM to start the graceful shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	storageService, err := service.NewStorageService(ctx, cfg)
+	if err != nil {
+		log.Fatalf("Failed to create %s storage: %v", cfg.STORAGE_TYPE, err)
+	}
+
+	// Corrupted factorials are marked failed and recalculated by the calculator
+	scrubService := service.NewScrubService(
+		repository.NewFactorialRepository(database),
+		repository.NewScrubRepository(database),
+		storageService,
+		service.WithScrubInterval(time.Duration(cfg.SCRUB_INTERVAL_SECONDS)*time.Second),
+		service.WithScrubBytesPerSecond(int64(cfg.SCRUB_BYTES_PER_SECOND)),
+		service.WithScrubSampleRate(cfg.SCRUB_SAMPLE_RATE),
+	)
+	stopped := scrubService.StartScrubbing(ctx)
+
+	log.Println("Scrubber started")
+
+	// Wait for interrupt signal
+	<-ctx.Done()
+	stop()
+	log.Println("Received shutdown signal, starting graceful shutdown...")
+
+	// The pass in progress is recorded as aborted
+	shutdownTimeout := time.Duration(cfg.SHUTDOWN_TIMEOUT_SECONDS) * time.Second
+	select {
+	case <-stopped:
+	case <-time.After(shutdownTimeout):
+		log.Printf("Scrubber forced to stop after %v", shutdownTimeout)
+		return
+	}
+
+	log.Println("Scrubber stopped")
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"factorial-cal-services/migrations"
+	"factorial-cal-services/pkg/app"
+	"factorial-cal-services/pkg/config"
+	"factorial-cal-services/pkg/db"
+	"factorial-cal-services/pkg/queue"
+	"factorial-cal-services/pkg/service"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// The standalone binary runs the API, worker and calculator in one process. They share the
+// queue backend and storage, so QUEUE_TYPE=memory works here for local runs without a broker.
+func main() {
+	cfg := config.LoadConfig()
+
+	// Validate configuration
+	if err := cfg.ValidateStandalone(); err != nil {
+		log.Fatalf("Configuration validation failed: %v", err)
+	}
+
+	dsn := cfg.DSN()
+	// Run migrations
+	if err := migrations.RunMigrations(dsn); err != nil {
+		log.Printf("Migration failed: %v", err)
+	}
+
+	// Initialize database
+	database, err := db.NewGormDB(dsn)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	// Initialize Redis
+	redisClient := redis.NewClient(&redis.Options{
+		Addr:     cfg.RedisAddr(),
+		Password: cfg.REDIS_PASSWORD,
+	})
+	defer redisClient.Close()
+
+	// Cancelled on SIGINT/SIGTERM to start the graceful shutdown, a second signal stops at once
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	context.AfterFunc(ctx, stop)
+
+	// Test Redis connection
+	if err := redisClient.Ping(ctx).Err(); err != nil {
+		log.Printf("Warning: Redis connection failed: %v", err)
+	} else {
+		log.Println("Connected to Redis successfully")
+	}
+
+	// Initialize the queue backend selected by QUEUE_TYPE
+	queueBackend, err := queue.NewBackend(cfg, database)
+	if err != nil {
+		log.Fatalf("Failed to connect to %s queue: %v", cfg.QUEUE_TYPE, err)
+	}
+	defer queueBackend.Close()
+
+	storageService, err := service.NewStorageService(ctx, cfg)
+	if err != nil {
+		log.Fatalf("Failed to create %s storage: %v", cfg.STORAGE_TYPE, err)
+	}
+
+	// A service that fails to start shuts the others down with it
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	services := map[string]func() error{
+		"API server": func() error {
+			return app.RunAPI(runCtx, cfg, database, redisClient, queueBackend, storageService)
+		},
+		"Worker": func() error {
+			return app.RunWorker(runCtx, cfg, database, redisClient, queueBackend, storageService)
+		},
+		"Calculator": func() error {
+			return app.RunCalculator(runCtx, cfg, database, redisClient, storageService)
+		},
+	}
+
+	var (
+		wg     sync.WaitGroup
+		failed bool
+		mu     sync.Mutex
+	)
+	for name, run := range services {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := run(); err != nil {
+				log.Printf("%s failed: %v", name, err)
+				mu.Lock()
+				failed = true
+				mu.Unlock()
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if failed {
+		log.Fatal("Standalone stopped after a service failed")
+	}
+	log.Println("Standalone stopped")
+}
@@ -3,15 +3,13 @@ package main
 import (
 	"context"
 	"log"
-	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
+	"factorial-cal-services/pkg/app"
 	"factorial-cal-services/pkg/config"
-	"factorial-cal-services/pkg/consumer"
 	"factorial-cal-services/pkg/db"
-	"factorial-cal-services/pkg/repository"
+	"factorial-cal-services/pkg/queue"
 	"factorial-cal-services/pkg/service"
 
 	"github.com/redis/go-redis/v9"
@@ -38,8 +36,12 @@ func main() {
 		Password: cfg.REDIS_PASSWORD,
 	})
 
+	// Cancelled on SIGINT/SIGTERM to start the graceful shutdown, a second signal stops at once
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	context.AfterFunc(ctx, stop)
+
 	// Test Redis connection
-	ctx := context.Background()
 	if err := redisClient.Ping(ctx).Err(); err != nil {
 		log.Printf("Warning: Redis connection failed: %v", err)
 	} else {
@@ -47,73 +49,20 @@ func main() {
 	}
 	defer redisClient.Close()
 
-	redisService := service.NewRedisService(redisClient, 24*time.Hour, int64(cfg.REDIS_THRESHOLD))
 	// Initialize services
-	s3Service := service.NewS3Service(ctx, cfg)
-	factorialService := service.NewFactorialService(
-		repository.NewFactorialRepository(database),
-		repository.NewCurrentCalculatedRepository(database),
-		repository.NewMaxRequestRepository(database),
-		s3Service,
-	)
-
-	// Initialize repositories
-	factorialRepo := repository.NewFactorialRepository(database)
-	maxRequestRepo := repository.NewMaxRequestRepository(database)
-	currentCalculatedRepo := repository.NewCurrentCalculatedRepository(database)
-
-	// Initialize RabbitMQ consumer
-	mqConsumer, err := consumer.NewRabbitMQConsumer(cfg.RabbitMQURL())
+	storageService, err := service.NewStorageService(ctx, cfg)
 	if err != nil {
-		log.Fatalf("Failed to create RabbitMQ consumer: %v", err)
-	}
-	defer mqConsumer.Close()
-
-	// Create batch handler
-	factorialMessageHandler := consumer.NewFactorialMessageHandler(
-		factorialService,
-		redisService,
-		s3Service,
-		factorialRepo,
-		maxRequestRepo,
-		currentCalculatedRepo,
-	)
-
-	batchSize := cfg.WORKER_BATCH_SIZE
-	maxBatches := cfg.WORKER_MAX_BATCHES
-	if maxBatches <= 0 {
-		maxBatches = 16 // Default
+		log.Fatalf("Failed to create %s storage: %v", cfg.STORAGE_TYPE, err)
 	}
-	if batchSize <= 0 {
-		batchSize = 100 // Default
-	}
-
-	// Setup signal handling
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
-	// Worker pool with WaitGroup
-	workerCount := maxBatches
-	if workerCount <= 0 {
-		workerCount = 1
-	}
-
-	log.Printf("Starting %d batch consumers with batch size %d", workerCount, batchSize)
-
-	// Start consumer in a goroutine
-	err = mqConsumer.Consume(ctx, cfg.FACTORIAL_CAL_SERVICES_QUEUE_NAME, factorialMessageHandler)
+	// Initialize the queue backend selected by QUEUE_TYPE
+	queueBackend, err := queue.NewBackend(cfg, database)
 	if err != nil {
-		log.Fatalf("Consumer error: %v", err)
+		log.Fatalf("Failed to connect to %s queue: %v", cfg.QUEUE_TYPE, err)
 	}
+	defer queueBackend.Close()
 
-	log.Println("Worker started, waiting for messages...")
-
-	// Wait for interrupt signal
-	<-quit
-	log.Println("Received shutdown signal, starting graceful shutdown...")
-
-	// Wait for graceful shutdown or timeout
-	<-time.After(5 * time.Second)
-
-	log.Println("Worker stopped")
+	if err := app.RunWorker(ctx, cfg, database, redisClient, queueBackend, storageService); err != nil {
+		log.Fatalf("Worker failed: %v", err)
+	}
 }
@@ -25,7 +25,7 @@ const docTemplate = `{
     "paths": {
         "/factorial": {
             "post": {
-                "description": "Submit a number for factorial calculation (async processing)",
+                "description": "Submit a number for factorial calculation (async processing). The submission is committed with its queue message and published by the outbox relay; GET /factorial/submissions/{submission_id} reports whether it was published. When callback_url is set, an HMAC-signed POST with the result metadata is sent to it once the factorial is done.",
                 "consumes": [
                     "application/json"
                 ],
@@ -45,6 +45,30 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/dto.CalculateRequest"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Request ID recorded in the queue message; generated when missing or invalid",
+                        "name": "X-Request-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client ID recorded in the queue message",
+                        "name": "X-Client-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "W3C trace context continued by the queue message",
+                        "name": "traceparent",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Makes the submission safe to retry: a repeat within the idempotency window returns the original response with Idempotent-Replayed: true and publishes nothing",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -67,7 +91,166 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Invalid request - number missing or invalid format",
+                        "description": "Invalid request - number missing or invalid format, or invalid Idempotency-Key",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.ErrorResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "422": {
+                        "description": "Idempotency-Key already used for a different request",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.ErrorResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error - database failure",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.ErrorResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/factorial/batch-get": {
+            "post": {
+                "description": "Look up the factorial result of many numbers in one call. Each number is resolved via cache, database and storage and reported as done, calculating or not_found, or as error with the reason when its lookup failed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "factorial"
+                ],
+                "summary": "Get many factorial results",
+                "parameters": [
+                    {
+                        "description": "Batch Lookup Request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.BatchGetRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Results retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.BatchGetResponseData"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request - empty, too large or invalid number",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.ErrorResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/factorial/callbacks/{callback_id}": {
+            "get": {
+                "description": "Get the state of a webhook callback and every delivery attempt made so far",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "factorial"
+                ],
+                "summary": "Get webhook callback delivery log",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Callback ID",
+                        "name": "callback_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Callback retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.CallbackResponseData"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Callback not found",
                         "schema": {
                             "allOf": [
                                 {
@@ -85,7 +268,7 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "Internal server error - database or queue failure",
+                        "description": "Internal server error - database failure",
                         "schema": {
                             "allOf": [
                                 {
@@ -107,7 +290,7 @@ const docTemplate = `{
         },
         "/factorial/metadata/{number}": {
             "get": {
-                "description": "Get the metadata of a factorial calculation (status, S3 key, checksum, etc.). Returns metadata if calculation exists, or status if not found.",
+                "description": "Get the metadata of a factorial calculation (status, S3 key, checksum, etc.). For numbers not done yet, progress reports the calculator position, the queue target, the throughput over a sliding window and an estimated completion time.",
                 "produces": [
                     "application/json"
                 ],
@@ -126,7 +309,362 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Calculation not found or in progress",
+                        "description": "Calculation not found - queued or needs to be submitted",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.ProgressData"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid number format",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.ErrorResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error - database failure",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.ErrorResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/factorial/range": {
+            "post": {
+                "description": "Submit every number in [from, to] for factorial calculation as a single batch (async processing)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "factorial"
+                ],
+                "summary": "Submit a range of factorial calculations",
+                "parameters": [
+                    {
+                        "description": "Range Request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.RangeRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Request ID recorded in the queue message; generated when missing or invalid",
+                        "name": "X-Request-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client ID recorded in the queue message",
+                        "name": "X-Client-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "W3C trace context continued by the queue message",
+                        "name": "traceparent",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Range submitted successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.RangeResponseData"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request - bounds missing, invalid or reversed",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.ErrorResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error - database failure",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.ErrorResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/factorial/range/{batch_id}": {
+            "get": {
+                "description": "Get how many factorials of a submitted range are done",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "factorial"
+                ],
+                "summary": "Get range batch progress",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Batch ID",
+                        "name": "batch_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Batch progress retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.RangeStatusResponseData"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Batch not found",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.ErrorResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error - database failure",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.ErrorResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/factorial/submissions/{submission_id}": {
+            "get": {
+                "description": "Get whether a submitted calculation or range was published to the queue. Submissions are committed before they are published, so a queue outage shows here as pending with the last publish error, and as failed once the outbox relay gave up.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "factorial"
+                ],
+                "summary": "Get submission status",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Submission ID",
+                        "name": "submission_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Submission retrieved successfully",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.SubmissionResponseData"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Submission not found",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.ErrorResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error - database failure",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.ErrorResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/factorial/{number}": {
+            "get": {
+                "description": "Get the factorial calculation result for a number. Returns result if calculation is complete, or status if in progress or not found. With wait, a calculation in progress blocks until it is done or the duration elapses.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "factorial"
+                ],
+                "summary": "Get factorial result",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Number",
+                        "name": "number",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Maximum duration to wait for a calculation in progress, e.g. 30s (capped at 60s)",
+                        "name": "wait",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Calculation in progress or not found",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.CalculateResponseData"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid number format or wait duration",
                         "schema": {
                             "allOf": [
                                 {
@@ -136,15 +674,15 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/dto.CalculateResponseData"
+                                            "$ref": "#/definitions/dto.ErrorResponse"
                                         }
                                     }
                                 }
                             ]
                         }
                     },
-                    "400": {
-                        "description": "Invalid number format",
+                    "500": {
+                        "description": "Internal server error - database or storage failure",
                         "schema": {
                             "allOf": [
                                 {
@@ -160,9 +698,38 @@ const docTemplate = `{
                                 }
                             ]
                         }
+                    }
+                }
+            }
+        },
+        "/factorial/{number}/events": {
+            "get": {
+                "description": "Push Server-Sent Events while the calculator advances: \"progress\" events carry the calculator position and the target number, a final \"done\" event carries the calculation metadata.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "factorial"
+                ],
+                "summary": "Stream factorial calculation progress",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Number",
+                        "name": "number",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "progress event payload (the final done event carries dto.MetadataResponseData)",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProgressEventData"
+                        }
                     },
-                    "500": {
-                        "description": "Internal server error - database failure",
+                    "400": {
+                        "description": "Invalid number format",
                         "schema": {
                             "allOf": [
                                 {
@@ -182,16 +749,16 @@ const docTemplate = `{
                 }
             }
         },
-        "/factorial/{number}": {
+        "/factorial/{number}/raw": {
             "get": {
-                "description": "Get the factorial calculation result for a number. Returns result if calculation is complete, or status if in progress or not found.",
+                "description": "Stream the factorial result as plain text directly from storage. The result is verified against its checksum while it is streamed. Supports Range requests and conditional requests with If-None-Match, using the stored checksum as ETag.",
                 "produces": [
-                    "application/json"
+                    "text/plain"
                 ],
                 "tags": [
                     "factorial"
                 ],
-                "summary": "Get factorial result",
+                "summary": "Stream factorial result",
                 "parameters": [
                     {
                         "type": "string",
@@ -199,11 +766,41 @@ const docTemplate = `{
                         "name": "number",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Byte range, e.g. bytes=0-1023",
+                        "name": "Range",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Checksum returned in a previous ETag",
+                        "name": "If-None-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Calculation in progress or not found",
+                        "description": "Full factorial result",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "206": {
+                        "description": "Requested byte range of the factorial result",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid number format",
                         "schema": {
                             "allOf": [
                                 {
@@ -213,15 +810,15 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/dto.CalculateResponseData"
+                                            "$ref": "#/definitions/dto.ErrorResponse"
                                         }
                                     }
                                 }
                             ]
                         }
                     },
-                    "400": {
-                        "description": "Invalid number format",
+                    "404": {
+                        "description": "Calculation not found or in progress",
                         "schema": {
                             "allOf": [
                                 {
@@ -238,6 +835,12 @@ const docTemplate = `{
                             ]
                         }
                     },
+                    "416": {
+                        "description": "Range not satisfiable",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error - database or storage failure",
                         "schema": {
@@ -261,7 +864,7 @@ const docTemplate = `{
         },
         "/health": {
             "get": {
-                "description": "Check if the service is running",
+                "description": "Check if the service is running and its queue backend is reachable",
                 "produces": [
                     "application/json"
                 ],
@@ -278,6 +881,15 @@ const docTemplate = `{
                                 "type": "string"
                             }
                         }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
                     }
                 }
             }
@@ -299,12 +911,59 @@ const docTemplate = `{
                 }
             }
         },
+        "dto.BatchGetRequest": {
+            "type": "object",
+            "required": [
+                "numbers"
+            ],
+            "properties": {
+                "numbers": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "dto.BatchGetResponseData": {
+            "type": "object",
+            "properties": {
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.BatchGetResult"
+                    }
+                }
+            }
+        },
+        "dto.BatchGetResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "description": "Error is the reason the lookup failed when Status is error",
+                    "type": "string"
+                },
+                "factorial_result": {
+                    "type": "string"
+                },
+                "number": {
+                    "type": "integer"
+                },
+                "status": {
+                    "description": "Status is done, calculating, not_found or error",
+                    "type": "string"
+                }
+            }
+        },
         "dto.CalculateRequest": {
             "type": "object",
             "required": [
                 "number"
             ],
             "properties": {
+                "callback_url": {
+                    "type": "string"
+                },
                 "number": {
                     "type": "integer"
                 }
@@ -313,11 +972,75 @@ const docTemplate = `{
         "dto.CalculateResponseData": {
             "type": "object",
             "properties": {
+                "callback_id": {
+                    "type": "string"
+                },
                 "message": {
                     "type": "string"
                 },
                 "number": {
                     "type": "integer"
+                },
+                "submission_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CallbackDeliveryData": {
+            "type": "object",
+            "properties": {
+                "attempt": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "status_code": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.CallbackResponseData": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "callback_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deliveries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.CallbackDeliveryData"
+                    }
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "next_attempt_at": {
+                    "type": "string"
+                },
+                "number": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
                 }
             }
         },
@@ -338,29 +1061,148 @@ const docTemplate = `{
                 "bucket": {
                     "type": "string"
                 },
+                "checkpoint_number": {
+                    "type": "integer"
+                },
                 "checksum": {
                     "type": "string"
                 },
                 "created_at": {
                     "type": "string"
                 },
+                "derived": {
+                    "type": "boolean"
+                },
+                "encoding": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "integer"
                 },
                 "number": {
                     "type": "integer"
                 },
+                "progress": {
+                    "$ref": "#/definitions/dto.ProgressData"
+                },
                 "s3_key": {
                     "type": "string"
                 },
                 "status": {
                     "type": "string"
                 },
+                "stored_size": {
+                    "type": "integer"
+                },
                 "updated_at": {
                     "type": "string"
                 }
             }
         },
+        "dto.ProgressData": {
+            "type": "object",
+            "properties": {
+                "current": {
+                    "type": "integer"
+                },
+                "estimated_completion_at": {
+                    "type": "string"
+                },
+                "number": {
+                    "type": "integer"
+                },
+                "queued": {
+                    "type": "boolean"
+                },
+                "remaining": {
+                    "type": "integer"
+                },
+                "target": {
+                    "type": "integer"
+                },
+                "throughput_per_second": {
+                    "type": "number"
+                },
+                "window_seconds": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.ProgressEventData": {
+            "type": "object",
+            "properties": {
+                "current": {
+                    "type": "integer"
+                },
+                "number": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "target": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.RangeRequest": {
+            "type": "object",
+            "required": [
+                "from",
+                "to"
+            ],
+            "properties": {
+                "from": {
+                    "type": "integer"
+                },
+                "to": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.RangeResponseData": {
+            "type": "object",
+            "properties": {
+                "batch_id": {
+                    "type": "string"
+                },
+                "from": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "submission_id": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.RangeStatusResponseData": {
+            "type": "object",
+            "properties": {
+                "batch_id": {
+                    "type": "string"
+                },
+                "done": {
+                    "type": "integer"
+                },
+                "from": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
         "dto.ResultResponseData": {
             "type": "object",
             "properties": {
@@ -371,6 +1213,35 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "dto.SubmissionResponseData": {
+            "type": "object",
+            "properties": {
+                "batch_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "number": {
+                    "type": "integer"
+                },
+                "published_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "submission_id": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
         }
     }
 }`
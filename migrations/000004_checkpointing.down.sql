@@ -0,0 +1,5 @@
+-- Migration: 000004_checkpointing (rollback)
+-- Description: Rollback checkpoint tracking columns
+
+ALTER TABLE factorial_calculations DROP COLUMN IF EXISTS checkpoint_number;
+ALTER TABLE factorial_calculations DROP COLUMN IF EXISTS derived;
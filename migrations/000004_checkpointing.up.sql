@@ -0,0 +1,6 @@
+-- Migration: 000004_checkpointing
+-- Description: Track whether a factorial is materialized in storage or derived from a checkpoint
+-- PostgreSQL
+
+ALTER TABLE factorial_calculations ADD COLUMN IF NOT EXISTS derived BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE factorial_calculations ADD COLUMN IF NOT EXISTS checkpoint_number BIGINT;
@@ -0,0 +1,4 @@
+-- Migration: 000005_factorial_batches (rollback)
+-- Description: Rollback factorial batches table
+
+DROP TABLE IF EXISTS factorial_batches;
@@ -0,0 +1,12 @@
+-- Migration: 000005_factorial_batches
+-- Description: Table for range requests submitted as a single batch
+-- PostgreSQL
+
+CREATE TABLE IF NOT EXISTS factorial_batches (
+    id BIGSERIAL PRIMARY KEY,
+    batch_id VARCHAR(64) NOT NULL UNIQUE,
+    from_number BIGINT NOT NULL,
+    to_number BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
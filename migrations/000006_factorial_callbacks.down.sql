@@ -0,0 +1,5 @@
+-- Migration: 000006_factorial_callbacks (rollback)
+-- Description: Rollback webhook callbacks and delivery log tables
+
+DROP TABLE IF EXISTS factorial_callback_deliveries;
+DROP TABLE IF EXISTS factorial_callbacks;
@@ -0,0 +1,31 @@
+-- Migration: 000006_factorial_callbacks
+-- Description: Webhook callbacks fired when a factorial is done, and their delivery log
+-- PostgreSQL
+
+CREATE TABLE IF NOT EXISTS factorial_callbacks (
+    id BIGSERIAL PRIMARY KEY,
+    callback_id VARCHAR(64) NOT NULL UNIQUE,
+    number BIGINT NOT NULL,
+    url VARCHAR(2048) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_factorial_callbacks_number ON factorial_callbacks(number);
+CREATE INDEX IF NOT EXISTS idx_factorial_callbacks_status_next_attempt_at ON factorial_callbacks(status, next_attempt_at);
+
+CREATE TABLE IF NOT EXISTS factorial_callback_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    callback_id VARCHAR(64) NOT NULL REFERENCES factorial_callbacks(callback_id) ON DELETE CASCADE,
+    attempt INTEGER NOT NULL,
+    status_code INTEGER NOT NULL DEFAULT 0,
+    error TEXT,
+    duration_ms BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_factorial_callback_deliveries_callback_id ON factorial_callback_deliveries(callback_id);
@@ -0,0 +1,4 @@
+-- Migration: 000007_calculation_progress (rollback)
+-- Description: Rollback calculator throughput index
+
+DROP INDEX IF EXISTS idx_factorial_calculations_status_updated_at;
@@ -0,0 +1,5 @@
+-- Migration: 000007_calculation_progress
+-- Description: Index used to measure calculator throughput over a sliding window
+-- PostgreSQL
+
+CREATE INDEX IF NOT EXISTS idx_factorial_calculations_status_updated_at ON factorial_calculations(status, updated_at);
@@ -0,0 +1,4 @@
+-- Migration: 000008_queue_messages (rollback)
+-- Description: Rollback Postgres-backed queue table
+
+DROP TABLE IF EXISTS queue_messages;
@@ -0,0 +1,15 @@
+-- Migration: 000008_queue_messages
+-- Description: Messages of the Postgres-backed queue (QUEUE_TYPE=postgres), leased with SKIP LOCKED
+-- PostgreSQL
+
+CREATE TABLE IF NOT EXISTS queue_messages (
+    id BIGSERIAL PRIMARY KEY,
+    queue VARCHAR(255) NOT NULL,
+    payload BYTEA NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    available_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_queue_messages_queue_available_at ON queue_messages(queue, available_at);
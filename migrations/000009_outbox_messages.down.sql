@@ -0,0 +1,4 @@
+-- Migration: 000009_outbox_messages (rollback)
+-- Description: Rollback transactional outbox table
+
+DROP TABLE IF EXISTS outbox_messages;
@@ -0,0 +1,16 @@
+-- Migration: 000009_outbox_messages
+-- Description: Transactional outbox of submitted requests, published to the queue by the outbox relay
+-- PostgreSQL
+
+CREATE TABLE IF NOT EXISTS outbox_messages (
+    id BIGSERIAL PRIMARY KEY,
+    queue VARCHAR(255) NOT NULL,
+    payload BYTEA NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    sent_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- The relay only scans unsent messages
+CREATE INDEX IF NOT EXISTS idx_outbox_messages_pending ON outbox_messages(id) WHERE sent_at IS NULL;
@@ -0,0 +1,4 @@
+-- Migration: 000010_idempotency_keys (rollback)
+-- Description: Rollback idempotency keys table
+
+DROP TABLE IF EXISTS idempotency_keys;
@@ -0,0 +1,17 @@
+-- Migration: 000010_idempotency_keys
+-- Description: Responses of submissions made with an Idempotency-Key header, replayed to retries within the window
+-- PostgreSQL
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    id BIGSERIAL PRIMARY KEY,
+    client_id VARCHAR(128) NOT NULL DEFAULT '',
+    idempotency_key VARCHAR(255) NOT NULL,
+    request_hash VARCHAR(64) NOT NULL,
+    response_status INTEGER NOT NULL,
+    response_body BYTEA NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_idempotency_keys_client_id_key ON idempotency_keys(client_id, idempotency_key);
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
@@ -0,0 +1,6 @@
+-- Migration: 000011_request_priorities (rollback)
+-- Description: Rollback per-priority max requested numbers
+
+DELETE FROM factorial_max_request_numbers WHERE priority <> 0;
+DROP INDEX IF EXISTS idx_factorial_max_request_numbers_priority;
+ALTER TABLE factorial_max_request_numbers DROP COLUMN IF EXISTS priority;
@@ -0,0 +1,10 @@
+-- Migration: 000011_request_priorities
+-- Description: Track the max requested number per priority so high priority requests are calculated first
+-- PostgreSQL
+
+ALTER TABLE factorial_max_request_numbers ADD COLUMN IF NOT EXISTS priority INTEGER NOT NULL DEFAULT 0;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_factorial_max_request_numbers_priority ON factorial_max_request_numbers(priority);
+
+-- High priority requests (priority 1) start with nothing requested
+INSERT INTO factorial_max_request_numbers (max_number, priority) VALUES (0, 1) ON CONFLICT (priority) DO NOTHING;
@@ -0,0 +1,6 @@
+-- Migration: 000012_factorial_encodings (rollback)
+-- Description: Rollback factorial object encoding columns
+-- Note: objects stored with an encoding other than text are not readable after the rollback
+
+ALTER TABLE factorial_calculations DROP COLUMN IF EXISTS stored_size;
+ALTER TABLE factorial_calculations DROP COLUMN IF EXISTS encoding;
@@ -0,0 +1,9 @@
+-- Migration: 000012_factorial_encodings
+-- Description: Record the encoding and the stored (possibly compressed) size of factorial objects
+-- PostgreSQL
+
+ALTER TABLE factorial_calculations ADD COLUMN IF NOT EXISTS encoding VARCHAR(16) NOT NULL DEFAULT 'text';
+ALTER TABLE factorial_calculations ADD COLUMN IF NOT EXISTS stored_size BIGINT NOT NULL DEFAULT 0;
+
+-- Objects written so far are plain decimal text
+UPDATE factorial_calculations SET stored_size = size WHERE s3_key <> '' AND derived = FALSE;
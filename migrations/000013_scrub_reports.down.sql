@@ -0,0 +1,5 @@
+-- Migration: 000013_scrub_reports (rollback)
+-- Description: Rollback scrub report tables
+
+DROP TABLE IF EXISTS scrub_findings;
+DROP TABLE IF EXISTS scrub_reports;
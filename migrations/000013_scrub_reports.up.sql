@@ -0,0 +1,30 @@
+-- Migration: 000013_scrub_reports
+-- Description: Reports of the integrity scrubber passes and the stored factorials failing their checks
+-- PostgreSQL
+
+CREATE TABLE IF NOT EXISTS scrub_reports (
+    id BIGSERIAL PRIMARY KEY,
+    status VARCHAR(20) NOT NULL,
+    checked BIGINT NOT NULL DEFAULT 0,
+    bytes_read BIGINT NOT NULL DEFAULT 0,
+    pairs_checked BIGINT NOT NULL DEFAULT 0,
+    findings BIGINT NOT NULL DEFAULT 0,
+    last_number BIGINT NOT NULL DEFAULT 0,
+    error TEXT,
+    started_at TIMESTAMPTZ NOT NULL,
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_scrub_reports_status ON scrub_reports(status);
+
+CREATE TABLE IF NOT EXISTS scrub_findings (
+    id BIGSERIAL PRIMARY KEY,
+    report_id BIGINT NOT NULL REFERENCES scrub_reports(id) ON DELETE CASCADE,
+    number BIGINT NOT NULL,
+    kind VARCHAR(32) NOT NULL,
+    detail TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_scrub_findings_report_id ON scrub_findings(report_id);
+CREATE INDEX IF NOT EXISTS idx_scrub_findings_number ON scrub_findings(number);
@@ -0,0 +1,5 @@
+-- Migration: 000014_factorial_submissions (rollback)
+-- Description: Rollback factorial submissions table
+
+ALTER TABLE outbox_messages DROP COLUMN IF EXISTS submission_id;
+DROP TABLE IF EXISTS factorial_submissions;
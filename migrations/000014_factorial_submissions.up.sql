@@ -0,0 +1,23 @@
+-- Migration: 000014_factorial_submissions
+-- Description: Requests accepted by the API, committed with their outbox message and moved to published by the outbox relay
+-- PostgreSQL
+
+CREATE TABLE IF NOT EXISTS factorial_submissions (
+    id BIGSERIAL PRIMARY KEY,
+    submission_id VARCHAR(64) NOT NULL,
+    number BIGINT,
+    batch_id VARCHAR(64) NOT NULL DEFAULT '',
+    client_id VARCHAR(128) NOT NULL DEFAULT '',
+    priority INTEGER NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL,
+    last_error TEXT,
+    published_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_factorial_submissions_submission_id ON factorial_submissions(submission_id);
+CREATE INDEX IF NOT EXISTS idx_factorial_submissions_status ON factorial_submissions(status);
+
+-- The relay updates the submission of every message it settles
+ALTER TABLE outbox_messages ADD COLUMN IF NOT EXISTS submission_id VARCHAR(64) NOT NULL DEFAULT '';
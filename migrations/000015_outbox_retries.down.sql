@@ -0,0 +1,8 @@
+-- Migration: 000015_outbox_retries (rollback)
+-- Description: Rollback outbox message retry schedule
+
+DROP INDEX IF EXISTS idx_outbox_messages_sent_at;
+DROP INDEX IF EXISTS idx_outbox_messages_pending;
+ALTER TABLE outbox_messages DROP COLUMN IF EXISTS failed_at;
+ALTER TABLE outbox_messages DROP COLUMN IF EXISTS next_attempt_at;
+CREATE INDEX IF NOT EXISTS idx_outbox_messages_pending ON outbox_messages(id) WHERE sent_at IS NULL;
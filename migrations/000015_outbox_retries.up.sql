@@ -0,0 +1,13 @@
+-- Migration: 000015_outbox_retries
+-- Description: Retry schedule of outbox messages failing to publish, parking them once their attempts are exhausted
+-- PostgreSQL
+
+ALTER TABLE outbox_messages ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE outbox_messages ADD COLUMN IF NOT EXISTS failed_at TIMESTAMPTZ;
+
+-- The relay only scans due messages that are neither sent nor parked
+DROP INDEX IF EXISTS idx_outbox_messages_pending;
+CREATE INDEX IF NOT EXISTS idx_outbox_messages_pending ON outbox_messages(next_attempt_at, id) WHERE sent_at IS NULL AND failed_at IS NULL;
+
+-- Sent messages are purged once they are old enough
+CREATE INDEX IF NOT EXISTS idx_outbox_messages_sent_at ON outbox_messages(sent_at) WHERE sent_at IS NOT NULL;
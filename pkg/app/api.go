@@ -0,0 +1,212 @@
+package app
+
+import (
+	"context"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"factorial-cal-services/pkg/config"
+	"factorial-cal-services/pkg/handler"
+	"factorial-cal-services/pkg/queue"
+	"factorial-cal-services/pkg/repository"
+	"factorial-cal-services/pkg/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+	"github.com/swaggo/swag/example/override/docs"
+	"gorm.io/gorm"
+)
+
+// RunAPI serves the API and relays submitted requests from the outbox to the queue until ctx
+// is done, then shuts the server down gracefully
+func RunAPI(ctx context.Context, cfg *config.Config, database *gorm.DB, redisClient *redis.Client, queueBackend queue.Backend, storageService service.StorageService) error {
+	mqProducer, err := queueBackend.NewProducer()
+	if err != nil {
+		return err
+	}
+	defer mqProducer.Close()
+
+	redisService := service.NewRedisService(redisClient, 24*time.Hour, int64(cfg.REDIS_THRESHOLD))
+
+	// Initialize repository
+	factorialRepo := repository.NewFactorialRepository(database)
+	idempotencyRepo := repository.NewIdempotencyRepository(database)
+	// Initialize services
+	factorialService := service.NewFactorialService(
+		repository.NewFactorialRepository(database),
+		repository.NewCurrentCalculatedRepository(database),
+		repository.NewMaxRequestRepository(database),
+		storageService,
+	)
+	// Initialize handler
+	factorialHandler := handler.NewFactorialHandler(
+		factorialService,
+		redisService,
+		storageService,
+		factorialRepo,
+		repository.NewCurrentCalculatedRepository(database),
+		repository.NewBatchRepository(database),
+		repository.NewCallbackRepository(database),
+		service.NewRedisCompletionNotifier(redisClient),
+		service.NewProgressService(
+			factorialRepo,
+			repository.NewCurrentCalculatedRepository(database),
+			repository.NewMaxRequestRepository(database),
+			time.Duration(cfg.PROGRESS_WINDOW_SECONDS)*time.Second,
+		),
+		service.NewPriorityPolicy(int64(cfg.PRIORITY_NUMBER_THRESHOLD)),
+		repository.NewOutboxRepository(database),
+		repository.NewSubmissionRepository(database),
+		idempotencyRepo,
+		time.Duration(cfg.IDEMPOTENCY_WINDOW_SECONDS)*time.Second,
+		cfg.FACTORIAL_CAL_SERVICES_QUEUE_NAME,
+	)
+
+	// Publish submitted requests from the outbox until the server has shut down
+	relayCtx, stopRelay := context.WithCancel(context.Background())
+	defer stopRelay()
+	relayStopped := service.NewOutboxRelay(
+		repository.NewOutboxRepository(database),
+		mqProducer,
+		time.Duration(cfg.OUTBOX_RELAY_INTERVAL_MS)*time.Millisecond,
+		cfg.OUTBOX_MAX_ATTEMPTS,
+	).StartRelaying(relayCtx)
+	go purgeExpiredIdempotencyKeys(relayCtx, idempotencyRepo)
+	go purgeSentOutboxMessages(relayCtx, repository.NewOutboxRepository(database))
+
+	// Setup routes
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.Default()
+	r.Use(handler.RequestIDMiddleware())
+
+	// Swagger
+	if cfg.SWAGGER_HOST != "" {
+		docs.SwaggerInfo.Host = cfg.SWAGGER_HOST
+	}
+	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+
+	// Health check
+	r.GET("/health", healthCheck(queueBackend))
+
+	// API routes
+	v1 := r.Group("/api/v1")
+	{
+		v1.POST("/factorial", factorialHandler.SubmitCalculation)
+		v1.POST("/factorial/range", factorialHandler.SubmitRange)
+		v1.GET("/factorial/range/:batch_id", factorialHandler.GetRangeStatus)
+		v1.GET("/factorial/callbacks/:callback_id", factorialHandler.GetCallback)
+		v1.GET("/factorial/submissions/:submission_id", factorialHandler.GetSubmission)
+		v1.POST("/factorial/batch-get", factorialHandler.BatchGetResults)
+		v1.GET("/factorial/:number", factorialHandler.GetResult)
+		v1.GET("/factorial/:number/raw", factorialHandler.GetRawResult)
+		v1.GET("/factorial/:number/events", factorialHandler.StreamEvents)
+		v1.GET("/factorial/metadata/:number", factorialHandler.GetMetadata)
+	}
+
+	// Request contexts derive from serverCtx, which is cancelled once shutdown starts, so
+	// event streams and long-polls end instead of holding the shutdown until its timeout
+	serverCtx, stopServer := context.WithCancel(context.Background())
+	defer stopServer()
+	srv := &http.Server{
+		Addr:        cfg.SERVER_PORT,
+		Handler:     r,
+		BaseContext: func(net.Listener) context.Context { return serverCtx },
+	}
+	srv.RegisterOnShutdown(stopServer)
+
+	// Run server in goroutine
+	go func() {
+		log.Printf("API server listening on %s", srv.Addr)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Server error: %v", err)
+		}
+	}()
+
+	// Wait for the shutdown signal
+	<-ctx.Done()
+	log.Println("Shutting down API server...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.SHUTDOWN_TIMEOUT_SECONDS)*time.Second)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Server forced to shutdown: %v", err)
+	}
+
+	// Messages the relay does not get to are published after the next start
+	stopRelay()
+	select {
+	case <-relayStopped:
+	case <-shutdownCtx.Done():
+		log.Println("Shutdown timeout reached, outbox relay still publishing")
+	}
+
+	log.Println("API server stopped")
+	return nil
+}
+
+// purgeInterval is how often expired idempotency keys and old sent outbox messages are deleted
+const purgeInterval = 10 * time.Minute
+
+// purgeExpiredIdempotencyKeys deletes expired idempotency keys until ctx is done
+func purgeExpiredIdempotencyKeys(ctx context.Context, idempotencyRepo repository.IdempotencyRepository) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(purgeInterval):
+		}
+		deleted, err := idempotencyRepo.DeleteExpired(time.Now())
+		if err != nil {
+			log.Printf("Failed to purge expired idempotency keys: %v", err)
+			continue
+		}
+		if deleted > 0 {
+			log.Printf("Purged %d expired idempotency keys", deleted)
+		}
+	}
+}
+
+// outboxRetention is how long sent outbox messages are kept before they are deleted
+const outboxRetention = 24 * time.Hour
+
+// purgeSentOutboxMessages deletes outbox messages sent more than outboxRetention ago until ctx is done
+func purgeSentOutboxMessages(ctx context.Context, outboxRepo repository.OutboxRepository) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(purgeInterval):
+		}
+		deleted, err := outboxRepo.DeleteSentBefore(time.Now().Add(-outboxRetention))
+		if err != nil {
+			log.Printf("Failed to purge sent outbox messages: %v", err)
+			continue
+		}
+		if deleted > 0 {
+			log.Printf("Purged %d sent outbox messages", deleted)
+		}
+	}
+}
+
+// healthCheck godoc
+// @Summary      Health check
+// @Description  Check if the service is running and its queue backend is reachable
+// @Tags         health
+// @Produce      json
+// @Success      200  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Router       /health [get]
+func healthCheck(queueBackend queue.Backend) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := queueBackend.Healthy(); err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "degraded", "queue": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "ok", "queue": "connected"})
+	}
+}
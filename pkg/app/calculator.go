@@ -0,0 +1,84 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"factorial-cal-services/pkg/config"
+	"factorial-cal-services/pkg/repository"
+	"factorial-cal-services/pkg/service"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// RunCalculator calculates the requested factorials and delivers webhook callbacks until ctx
+// is done, then finishes the current number and the deliveries in flight
+func RunCalculator(ctx context.Context, cfg *config.Config, database *gorm.DB, redisClient *redis.Client, storage service.StorageService) error {
+	algorithm, err := service.NewFactorialAlgorithm(cfg.FACTORIAL_ALGORITHM, cfg.CALCULATION_PARALLELISM)
+	if err != nil {
+		return fmt.Errorf("invalid factorial algorithm: %w", err)
+	}
+
+	checkpointPolicy, err := service.NewCheckpointPolicy(cfg.CHECKPOINT_POLICY, int64(cfg.CHECKPOINT_INTERVAL), cfg.CHECKPOINT_RATIO)
+	if err != nil {
+		return fmt.Errorf("invalid checkpoint policy: %w", err)
+	}
+
+	factorialService := service.NewFactorialService(
+		repository.NewFactorialRepository(database),
+		repository.NewCurrentCalculatedRepository(database),
+		repository.NewMaxRequestRepository(database),
+		storage,
+		service.WithAlgorithm(algorithm),
+		service.WithDirectThreshold(int64(cfg.DIRECT_CALCULATION_THRESHOLD)),
+		service.WithCheckpointPolicy(checkpointPolicy),
+		service.WithCompletionNotifier(service.NewRedisCompletionNotifier(redisClient)),
+	)
+
+	// Every background loop closes its channel once it has stopped
+	stopped := []<-chan struct{}{factorialService.StartContinuelyCalculateFactorial(ctx)}
+
+	if cfg.WEBHOOK_SECRET == "" {
+		log.Println("Warning: WEBHOOK_SECRET is not set, webhook callbacks will not be delivered")
+	} else {
+		webhookService := service.NewWebhookService(
+			repository.NewCallbackRepository(database),
+			repository.NewFactorialRepository(database),
+			cfg.WEBHOOK_SECRET,
+			service.WithWebhookMaxAttempts(cfg.WEBHOOK_MAX_ATTEMPTS),
+			service.WithWebhookBackoff(
+				time.Duration(cfg.WEBHOOK_BACKOFF_SECONDS)*time.Second,
+				time.Duration(cfg.WEBHOOK_MAX_BACKOFF_SECONDS)*time.Second,
+			),
+			service.WithWebhookHTTPClient(service.NewWebhookHTTPClient(
+				time.Duration(cfg.WEBHOOK_TIMEOUT_SECONDS)*time.Second,
+				cfg.WEBHOOK_ALLOW_PRIVATE_NETWORKS,
+			)),
+		)
+		stopped = append(stopped, webhookService.StartDispatching(ctx))
+	}
+
+	log.Println("Calculate started, waiting for messages...")
+
+	// Wait for the shutdown signal
+	<-ctx.Done()
+	log.Println("Received shutdown signal, starting graceful shutdown...")
+
+	// The calculator finishes its current number and the dispatcher its deliveries in flight
+	shutdownTimeout := time.Duration(cfg.SHUTDOWN_TIMEOUT_SECONDS) * time.Second
+	deadline := time.After(shutdownTimeout)
+	for _, done := range stopped {
+		select {
+		case <-done:
+		case <-deadline:
+			log.Printf("Calculator forced to stop after %v, the current number is calculated again on restart", shutdownTimeout)
+			return nil
+		}
+	}
+
+	log.Println("Calculator stopped")
+	return nil
+}
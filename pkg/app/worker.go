@@ -0,0 +1,121 @@
+package app
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"factorial-cal-services/pkg/config"
+	"factorial-cal-services/pkg/consumer"
+	"factorial-cal-services/pkg/domain"
+	"factorial-cal-services/pkg/dto"
+	"factorial-cal-services/pkg/queue"
+	"factorial-cal-services/pkg/repository"
+	"factorial-cal-services/pkg/service"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// RunWorker consumes requested numbers from the queue until ctx is done, then finishes the
+// batches in flight
+func RunWorker(ctx context.Context, cfg *config.Config, database *gorm.DB, redisClient *redis.Client, queueBackend queue.Backend, storageService service.StorageService) error {
+	redisService := service.NewRedisService(redisClient, 24*time.Hour, int64(cfg.REDIS_THRESHOLD))
+	factorialService := service.NewFactorialService(
+		repository.NewFactorialRepository(database),
+		repository.NewCurrentCalculatedRepository(database),
+		repository.NewMaxRequestRepository(database),
+		storageService,
+	)
+
+	// Initialize repositories
+	factorialRepo := repository.NewFactorialRepository(database)
+	maxRequestRepo := repository.NewMaxRequestRepository(database)
+	currentCalculatedRepo := repository.NewCurrentCalculatedRepository(database)
+
+	// Failed messages are retried with backoff, then dead-lettered
+	retryPolicy := consumer.NewRetryPolicy(
+		cfg.CONSUMER_MAX_ATTEMPTS,
+		time.Duration(cfg.CONSUMER_BACKOFF_SECONDS)*time.Second,
+		time.Duration(cfg.CONSUMER_MAX_BACKOFF_SECONDS)*time.Second,
+	)
+	mqConsumer, err := queueBackend.NewConsumer(
+		retryPolicy,
+		time.Duration(cfg.WORKER_BATCH_WINDOW_MS)*time.Millisecond,
+	)
+	if err != nil {
+		return err
+	}
+	defer mqConsumer.Close()
+
+	// Create batch handler
+	factorialBatchHandler := consumer.NewFactorialBatchMessageHandler(
+		factorialService,
+		redisService,
+		storageService,
+		factorialRepo,
+		maxRequestRepo,
+		currentCalculatedRepo,
+	)
+
+	batchSize := cfg.WORKER_BATCH_SIZE
+	maxBatches := cfg.WORKER_MAX_BATCHES
+	if maxBatches <= 0 {
+		maxBatches = 16 // Default
+	}
+	if batchSize <= 0 {
+		batchSize = 100 // Default
+	}
+
+	log.Printf("Starting %d batch consumers with batch size %d", maxBatches, batchSize)
+
+	// Every batch consumer owns a channel, so up to maxBatches batches are handled concurrently
+	var consumers sync.WaitGroup
+	for i := 0; i < maxBatches; i++ {
+		consumers.Add(1)
+		go func() {
+			defer consumers.Done()
+			err := mqConsumer.ConsumeBatch(ctx, cfg.FACTORIAL_CAL_SERVICES_QUEUE_NAME, batchSize, factorialBatchHandler)
+			if err != nil {
+				log.Fatalf("Consumer error: %v", err)
+			}
+		}()
+	}
+
+	// The priority lane has consumers of its own so it is never stuck behind the normal queue
+	priorityQueueName := dto.PriorityQueueName(cfg.FACTORIAL_CAL_SERVICES_QUEUE_NAME, domain.PriorityHigh)
+	log.Printf("Starting %d batch consumers of %s", cfg.WORKER_PRIORITY_BATCHES, priorityQueueName)
+	for i := 0; i < cfg.WORKER_PRIORITY_BATCHES; i++ {
+		consumers.Add(1)
+		go func() {
+			defer consumers.Done()
+			err := mqConsumer.ConsumeBatch(ctx, priorityQueueName, batchSize, factorialBatchHandler)
+			if err != nil {
+				log.Fatalf("Priority consumer error: %v", err)
+			}
+		}()
+	}
+
+	log.Println("Worker started, waiting for messages...")
+
+	// Wait for the shutdown signal
+	<-ctx.Done()
+	log.Println("Received shutdown signal, starting graceful shutdown...")
+
+	// Consumers stop accepting deliveries and finish the batches in flight
+	drained := make(chan struct{})
+	go func() {
+		consumers.Wait()
+		close(drained)
+	}()
+
+	shutdownTimeout := time.Duration(cfg.SHUTDOWN_TIMEOUT_SECONDS) * time.Second
+	select {
+	case <-drained:
+		log.Println("Worker stopped")
+	case <-time.After(shutdownTimeout):
+		log.Printf("Worker forced to stop after %v, unacked messages will be redelivered", shutdownTimeout)
+	}
+	return nil
+}
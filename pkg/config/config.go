@@ -2,7 +2,9 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"runtime"
 	"strconv"
 )
 
@@ -11,6 +13,31 @@ func LoadConfig() *Config {
 	redisThreshold, _ := strconv.Atoi(getEnvOrDefault("REDIS_THRESHOLD", "50"))
 	workerBatchSize, _ := strconv.Atoi(getEnvOrDefault("WORKER_BATCH_SIZE", "100"))
 	workerMaxBatches, _ := strconv.Atoi(getEnvOrDefault("WORKER_MAX_BATCHES", "1"))
+	workerBatchWindowMs, _ := strconv.Atoi(getEnvOrDefault("WORKER_BATCH_WINDOW_MS", "1000"))
+	workerPriorityBatches, _ := strconv.Atoi(getEnvOrDefault("WORKER_PRIORITY_BATCHES", "1"))
+	priorityNumberThreshold, _ := strconv.Atoi(getEnvOrDefault("PRIORITY_NUMBER_THRESHOLD", "1000"))
+	directCalculationThreshold, _ := strconv.Atoi(getEnvOrDefault("DIRECT_CALCULATION_THRESHOLD", "1000"))
+	checkpointInterval, _ := strconv.Atoi(getEnvOrDefault("CHECKPOINT_INTERVAL", "1"))
+	checkpointRatio, _ := strconv.ParseFloat(getEnvOrDefault("CHECKPOINT_RATIO", "2"), 64)
+	webhookMaxAttempts, _ := strconv.Atoi(getEnvOrDefault("WEBHOOK_MAX_ATTEMPTS", "5"))
+	webhookBackoffSeconds, _ := strconv.Atoi(getEnvOrDefault("WEBHOOK_BACKOFF_SECONDS", "2"))
+	webhookMaxBackoffSeconds, _ := strconv.Atoi(getEnvOrDefault("WEBHOOK_MAX_BACKOFF_SECONDS", "300"))
+	webhookTimeoutSeconds, _ := strconv.Atoi(getEnvOrDefault("WEBHOOK_TIMEOUT_SECONDS", "10"))
+	webhookAllowPrivateNetworks, _ := strconv.ParseBool(getEnvOrDefault("WEBHOOK_ALLOW_PRIVATE_NETWORKS", "false"))
+	progressWindowSeconds, _ := strconv.Atoi(getEnvOrDefault("PROGRESS_WINDOW_SECONDS", "300"))
+	consumerMaxAttempts, _ := strconv.Atoi(getEnvOrDefault("CONSUMER_MAX_ATTEMPTS", "5"))
+	consumerBackoffSeconds, _ := strconv.Atoi(getEnvOrDefault("CONSUMER_BACKOFF_SECONDS", "2"))
+	consumerMaxBackoffSeconds, _ := strconv.Atoi(getEnvOrDefault("CONSUMER_MAX_BACKOFF_SECONDS", "60"))
+	idempotencyWindowSeconds, _ := strconv.Atoi(getEnvOrDefault("IDEMPOTENCY_WINDOW_SECONDS", "86400"))
+	outboxRelayIntervalMs, _ := strconv.Atoi(getEnvOrDefault("OUTBOX_RELAY_INTERVAL_MS", "200"))
+	outboxMaxAttempts, _ := strconv.Atoi(getEnvOrDefault("OUTBOX_MAX_ATTEMPTS", "10"))
+	s3PartSizeMB, _ := strconv.Atoi(getEnvOrDefault("S3_PART_SIZE_MB", "8"))
+	s3Concurrency, _ := strconv.Atoi(getEnvOrDefault("S3_CONCURRENCY", "4"))
+	scrubIntervalSeconds, _ := strconv.Atoi(getEnvOrDefault("SCRUB_INTERVAL_SECONDS", "3600"))
+	scrubBytesPerSecond, _ := strconv.Atoi(getEnvOrDefault("SCRUB_BYTES_PER_SECOND", "4194304"))
+	scrubSampleRate, _ := strconv.ParseFloat(getEnvOrDefault("SCRUB_SAMPLE_RATE", "0.1"), 64)
+	shutdownTimeoutSeconds, _ := strconv.Atoi(getEnvOrDefault("SHUTDOWN_TIMEOUT_SECONDS", "30"))
+	calculationParallelism, _ := strconv.Atoi(getEnvOrDefault("CALCULATION_PARALLELISM", strconv.Itoa(runtime.NumCPU())))
 
 	return &Config{
 		SERVER_PORT:                       getEnvOrDefault("SERVER_PORT", ":8080"),
@@ -34,41 +61,107 @@ func LoadConfig() *Config {
 		AWS_REGION:                        getEnvOrDefault("AWS_REGION", "us-east-1"),
 		S3_BUCKET_NAME:                    getEnvOrDefault("S3_BUCKET_NAME", "factorial-calculator-service"),
 		STORAGE_TYPE:                      getEnvOrDefault("STORAGE_TYPE", "local"),
+		LOCAL_STORAGE_PATH:                getEnvOrDefault("LOCAL_STORAGE_PATH", "/tmp/factorial-storage"),
+		S3_ENDPOINT:                       getEnvOrDefault("S3_ENDPOINT", ""),
+		STORAGE_ENCODING:                  getEnvOrDefault("STORAGE_ENCODING", "text"),
 		QUEUE_TYPE:                        getEnvOrDefault("QUEUE_TYPE", "rabbitmq"),
+		NATS_URL:                          getEnvOrDefault("NATS_URL", "nats://localhost:4222"),
+		KAFKA_BROKERS:                     getEnvOrDefault("KAFKA_BROKERS", "localhost:9092"),
+		FACTORIAL_ALGORITHM:               getEnvOrDefault("FACTORIAL_ALGORITHM", "prime-swing"),
+		CHECKPOINT_POLICY:                 getEnvOrDefault("CHECKPOINT_POLICY", "every"),
+		WEBHOOK_SECRET:                    getEnvOrDefault("WEBHOOK_SECRET", ""),
 		MAX_FACTORIAL:                     maxFactorial,
 		REDIS_THRESHOLD:                   redisThreshold,
 		WORKER_BATCH_SIZE:                 workerBatchSize,
 		WORKER_MAX_BATCHES:                workerMaxBatches,
+		WORKER_BATCH_WINDOW_MS:            workerBatchWindowMs,
+		WORKER_PRIORITY_BATCHES:           workerPriorityBatches,
+		PRIORITY_NUMBER_THRESHOLD:         priorityNumberThreshold,
+		DIRECT_CALCULATION_THRESHOLD:      directCalculationThreshold,
+		CALCULATION_PARALLELISM:           calculationParallelism,
+		CHECKPOINT_INTERVAL:               checkpointInterval,
+		CHECKPOINT_RATIO:                  checkpointRatio,
+		WEBHOOK_MAX_ATTEMPTS:              webhookMaxAttempts,
+		WEBHOOK_BACKOFF_SECONDS:           webhookBackoffSeconds,
+		WEBHOOK_MAX_BACKOFF_SECONDS:       webhookMaxBackoffSeconds,
+		WEBHOOK_TIMEOUT_SECONDS:           webhookTimeoutSeconds,
+		WEBHOOK_ALLOW_PRIVATE_NETWORKS:    webhookAllowPrivateNetworks,
+		PROGRESS_WINDOW_SECONDS:           progressWindowSeconds,
+		CONSUMER_MAX_ATTEMPTS:             consumerMaxAttempts,
+		CONSUMER_BACKOFF_SECONDS:          consumerBackoffSeconds,
+		CONSUMER_MAX_BACKOFF_SECONDS:      consumerMaxBackoffSeconds,
+		OUTBOX_RELAY_INTERVAL_MS:          outboxRelayIntervalMs,
+		OUTBOX_MAX_ATTEMPTS:               outboxMaxAttempts,
+		IDEMPOTENCY_WINDOW_SECONDS:        idempotencyWindowSeconds,
+		S3_PART_SIZE_MB:                   s3PartSizeMB,
+		S3_CONCURRENCY:                    s3Concurrency,
+		SCRUB_INTERVAL_SECONDS:            scrubIntervalSeconds,
+		SCRUB_BYTES_PER_SECOND:            scrubBytesPerSecond,
+		SCRUB_SAMPLE_RATE:                 scrubSampleRate,
+		SHUTDOWN_TIMEOUT_SECONDS:          shutdownTimeoutSeconds,
 	}
 }
 
 type Config struct {
-	SERVER_PORT                       string `mapstructure:"SERVER_PORT"`
-	DB_USER                           string `mapstructure:"DB_USER"`
-	DB_PASSWORD                       string `mapstructure:"DB_PASSWORD"`
-	DB_HOST                           string `mapstructure:"DB_HOST"`
-	DB_PORT                           string `mapstructure:"DB_PORT"`
-	DB_NAME                           string `mapstructure:"DB_NAME"`
-	DB_SSLMODE                        string `mapstructure:"DB_SSLMODE"`
-	DB_TYPE                           string `mapstructure:"DB_TYPE"`
-	RABBITMQ_USER                     string `mapstructure:"RABBITMQ_USER"`
-	RABBITMQ_PASSWORD                 string `mapstructure:"RABBITMQ_PASSWORD"`
-	RABBITMQ_HOST                     string `mapstructure:"RABBITMQ_HOST"`
-	RABBITMQ_PORT                     string `mapstructure:"RABBITMQ_PORT"`
-	FACTORIAL_CAL_SERVICES_QUEUE_NAME string `mapstructure:"FACTORIAL_CAL_SERVICES_QUEUE_NAME"`
-	SWAGGER_HOST                      string `mapstructure:"SWAGGER_HOST"`
-	RABBITMQ_CA                       string `mapstructure:"RABBITMQ_CA"`
-	REDIS_HOST                        string `mapstructure:"REDIS_HOST"`
-	REDIS_PORT                        string `mapstructure:"REDIS_PORT"`
-	REDIS_PASSWORD                    string `mapstructure:"REDIS_PASSWORD"`
-	AWS_REGION                        string `mapstructure:"AWS_REGION"`
-	S3_BUCKET_NAME                    string `mapstructure:"S3_BUCKET_NAME"`
-	STORAGE_TYPE                      string `mapstructure:"STORAGE_TYPE"`
-	QUEUE_TYPE                        string `mapstructure:"QUEUE_TYPE"`
-	MAX_FACTORIAL                     int    `mapstructure:"MAX_FACTORIAL"`
-	REDIS_THRESHOLD                   int    `mapstructure:"REDIS_THRESHOLD"`
-	WORKER_BATCH_SIZE                 int    `mapstructure:"WORKER_BATCH_SIZE"`
-	WORKER_MAX_BATCHES                int    `mapstructure:"WORKER_MAX_BATCHES"`
+	SERVER_PORT                       string  `mapstructure:"SERVER_PORT"`
+	DB_USER                           string  `mapstructure:"DB_USER"`
+	DB_PASSWORD                       string  `mapstructure:"DB_PASSWORD"`
+	DB_HOST                           string  `mapstructure:"DB_HOST"`
+	DB_PORT                           string  `mapstructure:"DB_PORT"`
+	DB_NAME                           string  `mapstructure:"DB_NAME"`
+	DB_SSLMODE                        string  `mapstructure:"DB_SSLMODE"`
+	DB_TYPE                           string  `mapstructure:"DB_TYPE"`
+	RABBITMQ_USER                     string  `mapstructure:"RABBITMQ_USER"`
+	RABBITMQ_PASSWORD                 string  `mapstructure:"RABBITMQ_PASSWORD"`
+	RABBITMQ_HOST                     string  `mapstructure:"RABBITMQ_HOST"`
+	RABBITMQ_PORT                     string  `mapstructure:"RABBITMQ_PORT"`
+	FACTORIAL_CAL_SERVICES_QUEUE_NAME string  `mapstructure:"FACTORIAL_CAL_SERVICES_QUEUE_NAME"`
+	SWAGGER_HOST                      string  `mapstructure:"SWAGGER_HOST"`
+	RABBITMQ_CA                       string  `mapstructure:"RABBITMQ_CA"`
+	REDIS_HOST                        string  `mapstructure:"REDIS_HOST"`
+	REDIS_PORT                        string  `mapstructure:"REDIS_PORT"`
+	REDIS_PASSWORD                    string  `mapstructure:"REDIS_PASSWORD"`
+	AWS_REGION                        string  `mapstructure:"AWS_REGION"`
+	S3_BUCKET_NAME                    string  `mapstructure:"S3_BUCKET_NAME"`
+	STORAGE_TYPE                      string  `mapstructure:"STORAGE_TYPE"`
+	LOCAL_STORAGE_PATH                string  `mapstructure:"LOCAL_STORAGE_PATH"`
+	S3_ENDPOINT                       string  `mapstructure:"S3_ENDPOINT"`
+	STORAGE_ENCODING                  string  `mapstructure:"STORAGE_ENCODING"`
+	QUEUE_TYPE                        string  `mapstructure:"QUEUE_TYPE"`
+	NATS_URL                          string  `mapstructure:"NATS_URL"`
+	KAFKA_BROKERS                     string  `mapstructure:"KAFKA_BROKERS"`
+	FACTORIAL_ALGORITHM               string  `mapstructure:"FACTORIAL_ALGORITHM"`
+	CHECKPOINT_POLICY                 string  `mapstructure:"CHECKPOINT_POLICY"`
+	WEBHOOK_SECRET                    string  `mapstructure:"WEBHOOK_SECRET"`
+	MAX_FACTORIAL                     int     `mapstructure:"MAX_FACTORIAL"`
+	REDIS_THRESHOLD                   int     `mapstructure:"REDIS_THRESHOLD"`
+	WORKER_BATCH_SIZE                 int     `mapstructure:"WORKER_BATCH_SIZE"`
+	WORKER_MAX_BATCHES                int     `mapstructure:"WORKER_MAX_BATCHES"`
+	WORKER_BATCH_WINDOW_MS            int     `mapstructure:"WORKER_BATCH_WINDOW_MS"`
+	WORKER_PRIORITY_BATCHES           int     `mapstructure:"WORKER_PRIORITY_BATCHES"`
+	PRIORITY_NUMBER_THRESHOLD         int     `mapstructure:"PRIORITY_NUMBER_THRESHOLD"`
+	DIRECT_CALCULATION_THRESHOLD      int     `mapstructure:"DIRECT_CALCULATION_THRESHOLD"`
+	CALCULATION_PARALLELISM           int     `mapstructure:"CALCULATION_PARALLELISM"`
+	CHECKPOINT_INTERVAL               int     `mapstructure:"CHECKPOINT_INTERVAL"`
+	CHECKPOINT_RATIO                  float64 `mapstructure:"CHECKPOINT_RATIO"`
+	WEBHOOK_MAX_ATTEMPTS              int     `mapstructure:"WEBHOOK_MAX_ATTEMPTS"`
+	WEBHOOK_BACKOFF_SECONDS           int     `mapstructure:"WEBHOOK_BACKOFF_SECONDS"`
+	WEBHOOK_MAX_BACKOFF_SECONDS       int     `mapstructure:"WEBHOOK_MAX_BACKOFF_SECONDS"`
+	WEBHOOK_TIMEOUT_SECONDS           int     `mapstructure:"WEBHOOK_TIMEOUT_SECONDS"`
+	WEBHOOK_ALLOW_PRIVATE_NETWORKS    bool    `mapstructure:"WEBHOOK_ALLOW_PRIVATE_NETWORKS"`
+	PROGRESS_WINDOW_SECONDS           int     `mapstructure:"PROGRESS_WINDOW_SECONDS"`
+	CONSUMER_MAX_ATTEMPTS             int     `mapstructure:"CONSUMER_MAX_ATTEMPTS"`
+	CONSUMER_BACKOFF_SECONDS          int     `mapstructure:"CONSUMER_BACKOFF_SECONDS"`
+	CONSUMER_MAX_BACKOFF_SECONDS      int     `mapstructure:"CONSUMER_MAX_BACKOFF_SECONDS"`
+	OUTBOX_RELAY_INTERVAL_MS          int     `mapstructure:"OUTBOX_RELAY_INTERVAL_MS"`
+	OUTBOX_MAX_ATTEMPTS               int     `mapstructure:"OUTBOX_MAX_ATTEMPTS"`
+	IDEMPOTENCY_WINDOW_SECONDS        int     `mapstructure:"IDEMPOTENCY_WINDOW_SECONDS"`
+	S3_PART_SIZE_MB                   int     `mapstructure:"S3_PART_SIZE_MB"`
+	S3_CONCURRENCY                    int     `mapstructure:"S3_CONCURRENCY"`
+	SCRUB_INTERVAL_SECONDS            int     `mapstructure:"SCRUB_INTERVAL_SECONDS"`
+	SCRUB_BYTES_PER_SECOND            int     `mapstructure:"SCRUB_BYTES_PER_SECOND"`
+	SCRUB_SAMPLE_RATE                 float64 `mapstructure:"SCRUB_SAMPLE_RATE"`
+	SHUTDOWN_TIMEOUT_SECONDS          int     `mapstructure:"SHUTDOWN_TIMEOUT_SECONDS"`
 }
 
 func (c *Config) DSN() string {
@@ -91,8 +184,19 @@ func (c *Config) RedisAddr() string {
 	return fmt.Sprintf("%s:%s", c.REDIS_HOST, c.REDIS_PORT)
 }
 
-// Validate validates the configuration and returns an error if required fields are missing or invalid
+// Validate validates the configuration of a service running in its own process and returns an
+// error if required fields are missing or invalid
 func (c *Config) Validate() error {
+	return c.validate(false)
+}
+
+// ValidateStandalone validates the configuration of the standalone binary, which runs the API,
+// worker and calculator in one process and so also accepts the memory queue and storage
+func (c *Config) ValidateStandalone() error {
+	return c.validate(true)
+}
+
+func (c *Config) validate(standalone bool) error {
 	// Required database fields
 	if c.DB_HOST == "" {
 		return fmt.Errorf("DB_HOST is required")
@@ -112,17 +216,74 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("DB_PORT must be numeric: %w", err)
 	}
 
-	// Required RabbitMQ fields
-	if c.RABBITMQ_HOST == "" {
-		return fmt.Errorf("RABBITMQ_HOST is required")
+	// Required fields of the queue backend
+	switch c.QUEUE_TYPE {
+	case "rabbitmq":
+		if c.RABBITMQ_HOST == "" {
+			return fmt.Errorf("RABBITMQ_HOST is required")
+		}
+		if c.RABBITMQ_PORT == "" {
+			return fmt.Errorf("RABBITMQ_PORT is required")
+		}
+
+		// Validate RABBITMQ_PORT is numeric
+		if _, err := strconv.Atoi(c.RABBITMQ_PORT); err != nil {
+			return fmt.Errorf("RABBITMQ_PORT must be numeric: %w", err)
+		}
+	case "nats":
+		if c.NATS_URL == "" {
+			return fmt.Errorf("NATS_URL is required")
+		}
+	case "kafka":
+		if c.KAFKA_BROKERS == "" {
+			return fmt.Errorf("KAFKA_BROKERS is required")
+		}
+	case "postgres":
+	case "memory":
+		// Messages published by the API would never reach a worker in another process
+		if !standalone {
+			return fmt.Errorf("QUEUE_TYPE memory only connects services of one process, run cmd/standalone to use it")
+		}
+	default:
+		return fmt.Errorf("QUEUE_TYPE must be one of rabbitmq, nats, kafka, postgres or memory (got %q)", c.QUEUE_TYPE)
 	}
-	if c.RABBITMQ_PORT == "" {
-		return fmt.Errorf("RABBITMQ_PORT is required")
+
+	// Required fields of the storage backend
+	switch c.STORAGE_TYPE {
+	case "s3":
+		if c.AWS_REGION == "" || c.S3_BUCKET_NAME == "" {
+			return fmt.Errorf("AWS_REGION and S3_BUCKET_NAME are required")
+		}
+	case "s3-compatible":
+		if c.S3_ENDPOINT == "" || c.S3_BUCKET_NAME == "" {
+			return fmt.Errorf("S3_ENDPOINT and S3_BUCKET_NAME are required")
+		}
+		if endpoint, err := url.Parse(c.S3_ENDPOINT); err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+			return fmt.Errorf("S3_ENDPOINT must be an http or https URL (got %q)", c.S3_ENDPOINT)
+		}
+		if c.AWS_REGION == "" {
+			return fmt.Errorf("AWS_REGION is required")
+		}
+	case "local":
+		// Separate services only see each other's results when LOCAL_STORAGE_PATH is on a volume
+		// they all mount, which cannot be checked from here
+		if c.LOCAL_STORAGE_PATH == "" {
+			return fmt.Errorf("LOCAL_STORAGE_PATH is required")
+		}
+	case "memory":
+		// Unlike a local directory, memory cannot be shared with another process at all
+		if !standalone {
+			return fmt.Errorf("STORAGE_TYPE memory only shares results between services of one process, run cmd/standalone to use it")
+		}
+	default:
+		return fmt.Errorf("STORAGE_TYPE must be one of local, memory, s3 or s3-compatible (got %q)", c.STORAGE_TYPE)
 	}
 
-	// Validate RABBITMQ_PORT is numeric
-	if _, err := strconv.Atoi(c.RABBITMQ_PORT); err != nil {
-		return fmt.Errorf("RABBITMQ_PORT must be numeric: %w", err)
+	// Validate STORAGE_ENCODING is a known encoding
+	switch c.STORAGE_ENCODING {
+	case "text", "gzip", "zstd", "binary":
+	default:
+		return fmt.Errorf("STORAGE_ENCODING must be one of text, gzip, zstd or binary (got %q)", c.STORAGE_ENCODING)
 	}
 
 	// Validate batch sizes are positive
@@ -132,6 +293,12 @@ func (c *Config) Validate() error {
 	if c.WORKER_MAX_BATCHES <= 0 {
 		return fmt.Errorf("WORKER_MAX_BATCHES must be positive (got %d)", c.WORKER_MAX_BATCHES)
 	}
+	if c.WORKER_BATCH_WINDOW_MS <= 0 {
+		return fmt.Errorf("WORKER_BATCH_WINDOW_MS must be positive (got %d)", c.WORKER_BATCH_WINDOW_MS)
+	}
+	if c.WORKER_PRIORITY_BATCHES <= 0 {
+		return fmt.Errorf("WORKER_PRIORITY_BATCHES must be positive (got %d)", c.WORKER_PRIORITY_BATCHES)
+	}
 
 	// Validate MAX_FACTORIAL is positive
 	if c.MAX_FACTORIAL <= 0 {
@@ -143,10 +310,88 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("REDIS_THRESHOLD must be positive (got %d)", c.REDIS_THRESHOLD)
 	}
 
-	// Warn about optional but recommended fields
-	if c.AWS_REGION == "" || c.S3_BUCKET_NAME == "" {
-		// Log warning but don't fail (S3 might not be configured for local development)
-		fmt.Println("Warning: AWS_REGION and S3_BUCKET_NAME are not set. S3 operations will fail if attempted.")
+	// Validate DIRECT_CALCULATION_THRESHOLD is positive
+	if c.DIRECT_CALCULATION_THRESHOLD <= 0 {
+		return fmt.Errorf("DIRECT_CALCULATION_THRESHOLD must be positive (got %d)", c.DIRECT_CALCULATION_THRESHOLD)
+	}
+
+	// Validate CALCULATION_PARALLELISM is positive
+	if c.CALCULATION_PARALLELISM <= 0 {
+		return fmt.Errorf("CALCULATION_PARALLELISM must be positive (got %d)", c.CALCULATION_PARALLELISM)
+	}
+
+	// Validate CHECKPOINT_INTERVAL is positive
+	if c.CHECKPOINT_INTERVAL <= 0 {
+		return fmt.Errorf("CHECKPOINT_INTERVAL must be positive (got %d)", c.CHECKPOINT_INTERVAL)
+	}
+
+	// Validate webhook retry settings are positive
+	if c.WEBHOOK_MAX_ATTEMPTS <= 0 {
+		return fmt.Errorf("WEBHOOK_MAX_ATTEMPTS must be positive (got %d)", c.WEBHOOK_MAX_ATTEMPTS)
+	}
+	if c.WEBHOOK_BACKOFF_SECONDS <= 0 {
+		return fmt.Errorf("WEBHOOK_BACKOFF_SECONDS must be positive (got %d)", c.WEBHOOK_BACKOFF_SECONDS)
+	}
+	if c.WEBHOOK_MAX_BACKOFF_SECONDS < c.WEBHOOK_BACKOFF_SECONDS {
+		return fmt.Errorf("WEBHOOK_MAX_BACKOFF_SECONDS must not be less than WEBHOOK_BACKOFF_SECONDS (got %d)", c.WEBHOOK_MAX_BACKOFF_SECONDS)
+	}
+	if c.WEBHOOK_TIMEOUT_SECONDS <= 0 {
+		return fmt.Errorf("WEBHOOK_TIMEOUT_SECONDS must be positive (got %d)", c.WEBHOOK_TIMEOUT_SECONDS)
+	}
+
+	// Validate PROGRESS_WINDOW_SECONDS is positive
+	if c.PROGRESS_WINDOW_SECONDS <= 0 {
+		return fmt.Errorf("PROGRESS_WINDOW_SECONDS must be positive (got %d)", c.PROGRESS_WINDOW_SECONDS)
+	}
+
+	// Validate consumer retry settings are positive
+	if c.CONSUMER_MAX_ATTEMPTS <= 0 {
+		return fmt.Errorf("CONSUMER_MAX_ATTEMPTS must be positive (got %d)", c.CONSUMER_MAX_ATTEMPTS)
+	}
+	if c.CONSUMER_BACKOFF_SECONDS <= 0 {
+		return fmt.Errorf("CONSUMER_BACKOFF_SECONDS must be positive (got %d)", c.CONSUMER_BACKOFF_SECONDS)
+	}
+	if c.CONSUMER_MAX_BACKOFF_SECONDS < c.CONSUMER_BACKOFF_SECONDS {
+		return fmt.Errorf("CONSUMER_MAX_BACKOFF_SECONDS must not be less than CONSUMER_BACKOFF_SECONDS (got %d)", c.CONSUMER_MAX_BACKOFF_SECONDS)
+	}
+
+	// Validate OUTBOX_RELAY_INTERVAL_MS is positive
+	if c.OUTBOX_RELAY_INTERVAL_MS <= 0 {
+		return fmt.Errorf("OUTBOX_RELAY_INTERVAL_MS must be positive (got %d)", c.OUTBOX_RELAY_INTERVAL_MS)
+	}
+
+	// Validate OUTBOX_MAX_ATTEMPTS is positive
+	if c.OUTBOX_MAX_ATTEMPTS <= 0 {
+		return fmt.Errorf("OUTBOX_MAX_ATTEMPTS must be positive (got %d)", c.OUTBOX_MAX_ATTEMPTS)
+	}
+
+	// Validate IDEMPOTENCY_WINDOW_SECONDS is positive
+	if c.IDEMPOTENCY_WINDOW_SECONDS <= 0 {
+		return fmt.Errorf("IDEMPOTENCY_WINDOW_SECONDS must be positive (got %d)", c.IDEMPOTENCY_WINDOW_SECONDS)
+	}
+
+	// Validate S3 transfer settings, S3 rejects parts below 5 MiB and above 5 GiB
+	if c.S3_PART_SIZE_MB < 5 || c.S3_PART_SIZE_MB > 5120 {
+		return fmt.Errorf("S3_PART_SIZE_MB must be between 5 and 5120 (got %d)", c.S3_PART_SIZE_MB)
+	}
+	if c.S3_CONCURRENCY <= 0 {
+		return fmt.Errorf("S3_CONCURRENCY must be positive (got %d)", c.S3_CONCURRENCY)
+	}
+
+	// Validate scrubber settings
+	if c.SCRUB_INTERVAL_SECONDS <= 0 {
+		return fmt.Errorf("SCRUB_INTERVAL_SECONDS must be positive (got %d)", c.SCRUB_INTERVAL_SECONDS)
+	}
+	if c.SCRUB_BYTES_PER_SECOND <= 0 {
+		return fmt.Errorf("SCRUB_BYTES_PER_SECOND must be positive (got %d)", c.SCRUB_BYTES_PER_SECOND)
+	}
+	if c.SCRUB_SAMPLE_RATE < 0 || c.SCRUB_SAMPLE_RATE > 1 {
+		return fmt.Errorf("SCRUB_SAMPLE_RATE must be between 0 and 1 (got %v)", c.SCRUB_SAMPLE_RATE)
+	}
+
+	// Validate SHUTDOWN_TIMEOUT_SECONDS is positive
+	if c.SHUTDOWN_TIMEOUT_SECONDS <= 0 {
+		return fmt.Errorf("SHUTDOWN_TIMEOUT_SECONDS must be positive (got %d)", c.SHUTDOWN_TIMEOUT_SECONDS)
 	}
 
 	return nil
@@ -1,6 +1,9 @@
 package consumer
 
-import "context"
+import (
+	"context"
+	"fmt"
+)
 
 // Consumer represents a queue consumer (SQS, Kafka, RabbitMQ, etc.)
 type Consumer interface {
@@ -12,5 +15,15 @@ type Consumer interface {
 // MessageHandler is a function type for processing messages
 type MessageHandler func(ctx context.Context, payload []byte) error
 
-// BatchMessageHandler is a function type for processing batches of messages
+// BatchMessageHandler is a function type for processing batches of messages.
+// Returning a *BatchError fails only the listed messages; any other error fails the whole batch.
 type BatchMessageHandler func(ctx context.Context, payloads [][]byte) error
+
+// BatchError reports the messages of a batch that failed, keyed by their index in the batch
+type BatchError struct {
+	Failed map[int]error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("%d messages of the batch failed", len(e.Failed))
+}
@@ -0,0 +1,237 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// headerNotBefore holds the unix time in milliseconds before which a retried message is not handled
+const headerNotBefore = "x-retry-not-before"
+
+// kafkaReader reads the partitions of a topic assigned to a consumer group member
+type kafkaReader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// kafkaWriter writes messages to the topics they name
+type kafkaWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+// kafkaSubscriber subscribes to Kafka topics as a member of a consumer group
+type kafkaSubscriber struct {
+	writer    kafkaWriter
+	delays    []time.Duration
+	newReader func(topic string) kafkaReader
+}
+
+// NewKafkaConsumer creates a consumer of Kafka topics. Kafka has no delayed redelivery, so a
+// retried message is appended to the retry topic of its delay, like the TTL queues of RabbitMQ.
+// A retry topic only holds messages of a single delay, so they fall due in the order they were
+// written and waiting for the first one never holds back the main topic or a shorter delay.
+// The writer publishes the retries and the dead-lettered messages.
+func NewKafkaConsumer(brokers []string, group string, writer *kafka.Writer, retryPolicy RetryPolicy, batchWindow time.Duration) *PollingConsumer {
+	subscriber := &kafkaSubscriber{
+		writer: writer,
+		delays: retryPolicy.Delays(),
+		newReader: func(topic string) kafkaReader {
+			return kafka.NewReader(kafka.ReaderConfig{
+				Brokers: brokers,
+				GroupID: group,
+				Topic:   topic,
+				MaxWait: pollWait,
+			})
+		},
+	}
+	return NewPollingConsumer(subscriber, retryPolicy, batchWindow)
+}
+
+// Subscribe joins the consumer group of the topic and of its retry topics with a reader each
+func (s *kafkaSubscriber) Subscribe(ctx context.Context, queueName string) (Subscription, error) {
+	readCtx, cancel := context.WithCancel(context.Background())
+	sub := &kafkaSubscription{
+		writer:    s.writer,
+		queueName: queueName,
+		fetched:   make(chan kafkaFetched),
+		cancel:    cancel,
+	}
+
+	sub.start(readCtx, s.newReader(queueName))
+	for _, delay := range s.delays {
+		sub.start(readCtx, s.newReader(retryQueueName(queueName, delay)))
+	}
+	return sub, nil
+}
+
+// kafkaFetched is a message read from one of the topics of a subscription, or the read error
+type kafkaFetched struct {
+	reader kafkaReader
+	msg    kafka.Message
+	err    error
+}
+
+type kafkaSubscription struct {
+	writer    kafkaWriter
+	queueName string
+	fetched   chan kafkaFetched
+	cancel    context.CancelFunc
+
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	readers []kafkaReader
+}
+
+// start reads the topic of reader until the subscription is closed. Offsets are committed in
+// order, so the next message of a reader is only handed over once the previous one was taken;
+// a retried message is held back until its delay has elapsed.
+func (s *kafkaSubscription) start(ctx context.Context, reader kafkaReader) {
+	s.mu.Lock()
+	s.readers = append(s.readers, reader)
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			msg, err := reader.FetchMessage(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+			if err == nil {
+				if notBefore, ok := kafkaHeaderInt(msg.Headers, headerNotBefore); ok {
+					sleepContext(ctx, time.Until(time.UnixMilli(notBefore)))
+				}
+			}
+
+			select {
+			case s.fetched <- kafkaFetched{reader: reader, msg: msg, err: err}:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+}
+
+// Fetch returns the messages the readers handed over, waiting up to wait for the first one
+func (s *kafkaSubscription) Fetch(ctx context.Context, max int, wait time.Duration) ([]Delivery, error) {
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	var deliveries []Delivery
+	for len(deliveries) < max {
+		var fetched kafkaFetched
+		if len(deliveries) == 0 {
+			select {
+			case fetched = <-s.fetched:
+			case <-timer.C:
+				return nil, nil
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		} else {
+			select {
+			case fetched = <-s.fetched:
+			default:
+				return deliveries, nil
+			}
+		}
+
+		if fetched.err != nil {
+			return deliveries, fmt.Errorf("failed to fetch message: %w", fetched.err)
+		}
+		deliveries = append(deliveries, &kafkaDelivery{subscription: s, reader: fetched.reader, msg: fetched.msg})
+	}
+	return deliveries, nil
+}
+
+// Close stops the readers; messages handed over but not committed are delivered again
+func (s *kafkaSubscription) Close() error {
+	s.cancel()
+	s.wg.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var errs []error
+	for _, reader := range s.readers {
+		if err := reader.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	s.readers = nil
+	return errors.Join(errs...)
+}
+
+// kafkaDelivery is a message whose offset is committed once it is settled
+type kafkaDelivery struct {
+	subscription *kafkaSubscription
+	reader       kafkaReader
+	msg          kafka.Message
+}
+
+func (d *kafkaDelivery) Body() []byte {
+	return d.msg.Value
+}
+
+func (d *kafkaDelivery) Attempt() int {
+	attempt, _ := kafkaHeaderInt(d.msg.Headers, headerAttempt)
+	return int(attempt) + 1
+}
+
+func (d *kafkaDelivery) Ack(ctx context.Context) error {
+	return d.reader.CommitMessages(ctx, d.msg)
+}
+
+// Retry appends the message to the retry topic of delay, due after delay, and commits the original
+func (d *kafkaDelivery) Retry(ctx context.Context, delay time.Duration, cause error) error {
+	notBefore := strconv.FormatInt(time.Now().Add(delay).UnixMilli(), 10)
+	return d.republish(ctx, retryQueueName(d.subscription.queueName, delay), cause,
+		kafka.Header{Key: headerNotBefore, Value: []byte(notBefore)})
+}
+
+// DeadLetter publishes the message to the dead-letter topic and commits the original
+func (d *kafkaDelivery) DeadLetter(ctx context.Context, cause error) error {
+	return d.republish(ctx, deadLetterQueueName(d.subscription.queueName), cause)
+}
+
+// republish writes a copy of the message to topic with its failure recorded in the headers;
+// the original is only committed once the copy is written, so it is never lost
+func (d *kafkaDelivery) republish(ctx context.Context, topic string, cause error, extra ...kafka.Header) error {
+	headers := []kafka.Header{
+		{Key: headerAttempt, Value: []byte(strconv.Itoa(d.Attempt()))},
+		{Key: headerLastError, Value: []byte(truncate(cause.Error(), maxLastErrorLength))},
+	}
+	headers = append(headers, extra...)
+
+	err := d.subscription.writer.WriteMessages(ctx, kafka.Message{
+		Topic:   topic,
+		Key:     d.msg.Key,
+		Value:   d.msg.Value,
+		Headers: headers,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to republish message to %s: %w", topic, err)
+	}
+	return d.Ack(ctx)
+}
+
+// kafkaHeaderInt parses the integer header key
+func kafkaHeaderInt(headers []kafka.Header, key string) (int64, bool) {
+	for _, h := range headers {
+		if h.Key == key {
+			v, err := strconv.ParseInt(string(h.Value), 10, 64)
+			return v, err == nil
+		}
+	}
+	return 0, false
+}
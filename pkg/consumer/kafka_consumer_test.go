@@ -0,0 +1,295 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// fakeKafkaReader hands out the messages written to its topic and records the commits
+type fakeKafkaReader struct {
+	messages chan kafka.Message
+
+	mu        sync.Mutex
+	committed []kafka.Message
+	closed    bool
+}
+
+func (r *fakeKafkaReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	select {
+	case msg := <-r.messages:
+		return msg, nil
+	case <-ctx.Done():
+		return kafka.Message{}, ctx.Err()
+	}
+}
+
+func (r *fakeKafkaReader) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.committed = append(r.committed, msgs...)
+	return nil
+}
+
+func (r *fakeKafkaReader) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	return nil
+}
+
+func (r *fakeKafkaReader) commits() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.committed)
+}
+
+// fakeKafka is an in-process cluster with one reader per topic
+type fakeKafka struct {
+	mu      sync.Mutex
+	readers map[string]*fakeKafkaReader
+	written []kafka.Message
+	// failTopic rejects every write to the topic
+	failTopic string
+}
+
+func newFakeKafka() *fakeKafka {
+	return &fakeKafka{readers: make(map[string]*fakeKafkaReader)}
+}
+
+func (k *fakeKafka) reader(topic string) *fakeKafkaReader {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	r, ok := k.readers[topic]
+	if !ok {
+		r = &fakeKafkaReader{messages: make(chan kafka.Message, 16)}
+		k.readers[topic] = r
+	}
+	return r
+}
+
+func (k *fakeKafka) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	for _, msg := range msgs {
+		k.mu.Lock()
+		if msg.Topic == k.failTopic {
+			k.mu.Unlock()
+			return errors.New("broker unavailable")
+		}
+		k.written = append(k.written, msg)
+		k.mu.Unlock()
+		k.reader(msg.Topic).messages <- msg
+	}
+	return nil
+}
+
+func (k *fakeKafka) subscribe(t *testing.T, delays ...time.Duration) Subscription {
+	t.Helper()
+	subscriber := &kafkaSubscriber{
+		writer:    k,
+		delays:    delays,
+		newReader: func(topic string) kafkaReader { return k.reader(topic) },
+	}
+	sub, err := subscriber.Subscribe(context.Background(), "q")
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	t.Cleanup(func() { sub.Close() })
+	return sub
+}
+
+func fetchOne(t *testing.T, sub Subscription, wait time.Duration) Delivery {
+	t.Helper()
+	deliveries, err := sub.Fetch(context.Background(), 1, wait)
+	if err != nil || len(deliveries) != 1 {
+		t.Fatalf("Expected a delivery, got %d (%v)", len(deliveries), err)
+	}
+	return deliveries[0]
+}
+
+func TestKafkaDelivery_AckAndDeadLetter(t *testing.T) {
+	cluster := newFakeKafka()
+	sub := cluster.subscribe(t)
+	ctx := context.Background()
+	cluster.WriteMessages(ctx, kafka.Message{Topic: "q", Value: []byte("a")}, kafka.Message{Topic: "q", Value: []byte("b")})
+
+	first := fetchOne(t, sub, time.Second)
+	if string(first.Body()) != "a" || first.Attempt() != 1 {
+		t.Errorf("Expected a on its first attempt, got %q on attempt %d", first.Body(), first.Attempt())
+	}
+	if err := first.Ack(ctx); err != nil {
+		t.Fatalf("Ack failed: %v", err)
+	}
+	if cluster.reader("q").commits() != 1 {
+		t.Errorf("Expected the offset of a to be committed")
+	}
+
+	second := fetchOne(t, sub, time.Second)
+	if err := second.DeadLetter(ctx, errors.New("gave up")); err != nil {
+		t.Fatalf("DeadLetter failed: %v", err)
+	}
+	dlq := cluster.reader(deadLetterQueueName("q"))
+	if len(dlq.messages) != 1 || cluster.reader("q").commits() != 2 {
+		t.Fatalf("Expected b dead-lettered and committed, got %d dead-lettered, %d commits", len(dlq.messages), cluster.reader("q").commits())
+	}
+	dead := <-dlq.messages
+	if string(dead.Value) != "b" {
+		t.Errorf("Expected b dead-lettered, got %q", dead.Value)
+	}
+	if attempt, _ := kafkaHeaderInt(dead.Headers, headerAttempt); attempt != 1 {
+		t.Errorf("Expected attempt header 1, got %d", attempt)
+	}
+}
+
+func TestKafkaDelivery_RetryGoesToDelayTopicWithoutBlocking(t *testing.T) {
+	delay := 200 * time.Millisecond
+	cluster := newFakeKafka()
+	sub := cluster.subscribe(t, delay)
+	ctx := context.Background()
+	cluster.WriteMessages(ctx, kafka.Message{Topic: "q", Value: []byte("failing")})
+
+	failing := fetchOne(t, sub, time.Second)
+	retriedAt := time.Now()
+	if err := failing.Retry(ctx, delay, errors.New("transient failure")); err != nil {
+		t.Fatalf("Retry failed: %v", err)
+	}
+	if cluster.reader("q").commits() != 1 {
+		t.Error("Expected the original to be committed")
+	}
+	cluster.mu.Lock()
+	retry := cluster.written[len(cluster.written)-1]
+	cluster.mu.Unlock()
+	if retry.Topic != retryQueueName("q", delay) {
+		t.Errorf("Expected the retry on %s, got %s", retryQueueName("q", delay), retry.Topic)
+	}
+	notBefore, ok := kafkaHeaderInt(retry.Headers, headerNotBefore)
+	if !ok || notBefore < retriedAt.Add(delay).UnixMilli()-1 {
+		t.Errorf("Expected a not-before header after the delay, got %v", retry.Headers)
+	}
+
+	// A message written after the retry is not held back by the waiting retry
+	cluster.WriteMessages(ctx, kafka.Message{Topic: "q", Value: []byte("next")})
+	next := fetchOne(t, sub, time.Second)
+	if string(next.Body()) != "next" || time.Since(retriedAt) >= delay {
+		t.Fatalf("Expected next before the retry was due, got %q after %v", next.Body(), time.Since(retriedAt))
+	}
+	next.Ack(ctx)
+
+	retried := fetchOne(t, sub, time.Second)
+	if string(retried.Body()) != "failing" || retried.Attempt() != 2 {
+		t.Fatalf("Expected failing on its second attempt, got %q on attempt %d", retried.Body(), retried.Attempt())
+	}
+	if now := time.Now().UnixMilli(); now < notBefore {
+		t.Errorf("Expected the retry once due, got it %dms early", notBefore-now)
+	}
+	if err := retried.Ack(ctx); err != nil {
+		t.Fatalf("Ack failed: %v", err)
+	}
+	if cluster.reader(retryQueueName("q", delay)).commits() != 1 {
+		t.Error("Expected the retry to be committed on its retry topic")
+	}
+}
+
+func TestPollingConsumer_KafkaRetryFailureIsNotCommittedPast(t *testing.T) {
+	delay := time.Millisecond
+	cluster := newFakeKafka()
+	cluster.failTopic = retryQueueName("q", delay)
+	messages := []kafka.Message{{Topic: "q", Value: []byte("fail"), Offset: 0}, {Topic: "q", Value: []byte("ok"), Offset: 1}}
+
+	// Kafka delivers every message after the last committed offset to a new subscription
+	var subscriptions int
+	subscriber := &kafkaSubscriber{
+		writer: cluster,
+		delays: []time.Duration{delay},
+		newReader: func(topic string) kafkaReader {
+			r := cluster.reader(topic)
+			if topic == "q" {
+				subscriptions++
+				for len(r.messages) > 0 {
+					<-r.messages
+				}
+				for _, msg := range messages[r.commits():] {
+					r.messages <- msg
+				}
+			}
+			return r
+		},
+	}
+
+	var mu sync.Mutex
+	handled := map[string]int{}
+	handler := func(ctx context.Context, payload []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		handled[string(payload)]++
+		if string(payload) == "fail" {
+			return errors.New("transient failure")
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := NewPollingConsumer(subscriber, NewRetryPolicy(3, delay, delay), time.Millisecond)
+	done := make(chan error, 1)
+	go func() { done <- c.Consume(ctx, "q", handler) }()
+
+	// The retry cannot be written, so ok must not be acked past fail
+	waitFor(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return handled["fail"] >= 1
+	})
+	if commits := cluster.reader("q").commits(); commits != 0 {
+		t.Fatalf("Expected no offset committed past the unsettled message, got %d commits", commits)
+	}
+
+	// Once the retry topic is writable again, the new subscription settles both
+	cluster.mu.Lock()
+	cluster.failTopic = ""
+	cluster.mu.Unlock()
+	waitFor(t, func() bool { return cluster.reader("q").commits() == 2 })
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+
+	if subscriptions < 2 {
+		t.Errorf("Expected the consumer to subscribe again, got %d subscriptions", subscriptions)
+	}
+	cluster.mu.Lock()
+	defer cluster.mu.Unlock()
+	if len(cluster.written) == 0 || string(cluster.written[0].Value) != "fail" || cluster.written[0].Topic != retryQueueName("q", delay) {
+		t.Errorf("Expected fail written to its retry topic, got %v", cluster.written)
+	}
+}
+
+func TestKafkaSubscription_CloseStopsReaders(t *testing.T) {
+	cluster := newFakeKafka()
+	sub := cluster.subscribe(t, time.Millisecond, 2*time.Millisecond)
+
+	if err := sub.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	for _, topic := range []string{"q", retryQueueName("q", time.Millisecond), retryQueueName("q", 2*time.Millisecond)} {
+		if r := cluster.reader(topic); !r.closed {
+			t.Errorf("Expected the reader of %s to be closed", topic)
+		}
+	}
+	if deliveries, err := sub.Fetch(context.Background(), 1, 10*time.Millisecond); err != nil || len(deliveries) != 0 {
+		t.Errorf("Expected no deliveries after Close, got %d (%v)", len(deliveries), err)
+	}
+}
+
+func TestKafkaHeaderInt(t *testing.T) {
+	headers := []kafka.Header{{Key: headerAttempt, Value: []byte(strconv.Itoa(3))}, {Key: headerLastError, Value: []byte("x")}}
+	if v, ok := kafkaHeaderInt(headers, headerAttempt); !ok || v != 3 {
+		t.Errorf("Expected 3, got %d (%v)", v, ok)
+	}
+	if _, ok := kafkaHeaderInt(headers, headerLastError); ok {
+		t.Error("Expected a non-integer header not to parse")
+	}
+}
@@ -0,0 +1,74 @@
+package consumer
+
+import (
+	"context"
+	"time"
+
+	"factorial-cal-services/pkg/memqueue"
+)
+
+// memorySubscriber subscribes to queues of an in-memory broker
+type memorySubscriber struct {
+	broker *memqueue.Broker
+}
+
+// NewMemoryConsumer creates a consumer of an in-memory broker
+func NewMemoryConsumer(broker *memqueue.Broker, retryPolicy RetryPolicy, batchWindow time.Duration) *PollingConsumer {
+	return NewPollingConsumer(&memorySubscriber{broker: broker}, retryPolicy, batchWindow)
+}
+
+func (s *memorySubscriber) Subscribe(ctx context.Context, queueName string) (Subscription, error) {
+	return &memorySubscription{broker: s.broker, queueName: queueName}, nil
+}
+
+type memorySubscription struct {
+	broker    *memqueue.Broker
+	queueName string
+}
+
+func (s *memorySubscription) Fetch(ctx context.Context, max int, wait time.Duration) ([]Delivery, error) {
+	msgs, err := s.broker.Fetch(ctx, s.queueName, max, wait)
+	if err != nil {
+		return nil, err
+	}
+	deliveries := make([]Delivery, len(msgs))
+	for i, msg := range msgs {
+		deliveries[i] = &memoryDelivery{broker: s.broker, queueName: s.queueName, msg: msg}
+	}
+	return deliveries, nil
+}
+
+func (s *memorySubscription) Close() error {
+	return nil
+}
+
+// memoryDelivery is a message fetched from an in-memory broker; it has left the queue already
+type memoryDelivery struct {
+	broker    *memqueue.Broker
+	queueName string
+	msg       *memqueue.Message
+}
+
+func (d *memoryDelivery) Body() []byte {
+	return d.msg.Body
+}
+
+func (d *memoryDelivery) Attempt() int {
+	return d.msg.Attempts
+}
+
+func (d *memoryDelivery) Ack(ctx context.Context) error {
+	return nil
+}
+
+func (d *memoryDelivery) Retry(ctx context.Context, delay time.Duration, cause error) error {
+	d.msg.LastError = truncate(cause.Error(), maxLastErrorLength)
+	d.broker.PublishAfter(d.queueName, d.msg, delay)
+	return nil
+}
+
+func (d *memoryDelivery) DeadLetter(ctx context.Context, cause error) error {
+	d.msg.LastError = truncate(cause.Error(), maxLastErrorLength)
+	d.broker.PublishAfter(deadLetterQueueName(d.queueName), d.msg, 0)
+	return nil
+}
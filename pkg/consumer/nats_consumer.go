@@ -0,0 +1,164 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// natsAckWait is how long JetStream waits for an ack before delivering a message again
+const natsAckWait = 5 * time.Minute
+
+// natsPublisher publishes messages to JetStream subjects
+type natsPublisher interface {
+	PublishMsg(ctx context.Context, msg *nats.Msg, opts ...jetstream.PublishOpt) (*jetstream.PubAck, error)
+}
+
+// natsSubscriber subscribes to queues stored in JetStream streams
+type natsSubscriber struct {
+	js    jetstream.JetStream
+	group string
+}
+
+// NewNATSConsumer creates a consumer of NATS JetStream. Every queue is a stream holding the
+// queue subject and its dead-letter subject; workers share the durable pull consumer group.
+func NewNATSConsumer(js jetstream.JetStream, group string, retryPolicy RetryPolicy, batchWindow time.Duration) *PollingConsumer {
+	return NewPollingConsumer(&natsSubscriber{js: js, group: group}, retryPolicy, batchWindow)
+}
+
+// natsStreamName returns the stream of queueName; stream names cannot contain dots
+func natsStreamName(queueName string) string {
+	return strings.NewReplacer(".", "_", "*", "_", ">", "_", " ", "_").Replace(queueName)
+}
+
+// DeclareNATSStream declares the stream holding the queue subject and its dead-letter subject.
+// Producers declare it too, so messages published before a worker subscribed are kept.
+func DeclareNATSStream(ctx context.Context, js jetstream.JetStream, queueName string) (jetstream.Stream, error) {
+	stream, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     natsStreamName(queueName),
+		Subjects: []string{queueName, deadLetterQueueName(queueName)},
+		Storage:  jetstream.FileStorage,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to declare stream: %w", err)
+	}
+	return stream, nil
+}
+
+// Subscribe declares the stream and the durable consumer of the queue
+func (s *natsSubscriber) Subscribe(ctx context.Context, queueName string) (Subscription, error) {
+	stream, err := DeclareNATSStream(ctx, s.js, queueName)
+	if err != nil {
+		return nil, err
+	}
+
+	consumer, err := stream.CreateOrUpdateConsumer(ctx, jetstream.ConsumerConfig{
+		Durable:       natsStreamName(s.group),
+		FilterSubject: queueName,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       natsAckWait,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to declare consumer: %w", err)
+	}
+
+	return &natsSubscription{js: s.js, consumer: consumer, queueName: queueName}, nil
+}
+
+type natsSubscription struct {
+	js        jetstream.JetStream
+	consumer  jetstream.Consumer
+	queueName string
+}
+
+// Fetch returns the messages available right away, or waits for the first one
+func (s *natsSubscription) Fetch(ctx context.Context, max int, wait time.Duration) ([]Delivery, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	batch, err := s.consumer.FetchNoWait(max)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch messages: %w", err)
+	}
+	deliveries, err := s.collect(batch)
+	if err != nil || len(deliveries) > 0 {
+		return deliveries, err
+	}
+
+	batch, err = s.consumer.Fetch(1, jetstream.FetchMaxWait(wait))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch messages: %w", err)
+	}
+	return s.collect(batch)
+}
+
+func (s *natsSubscription) collect(batch jetstream.MessageBatch) ([]Delivery, error) {
+	var deliveries []Delivery
+	for msg := range batch.Messages() {
+		deliveries = append(deliveries, &natsDelivery{js: s.js, queueName: s.queueName, msg: msg})
+	}
+	if err := batch.Error(); err != nil && !errors.Is(err, jetstream.ErrNoMessages) {
+		return deliveries, fmt.Errorf("failed to fetch messages: %w", err)
+	}
+	return deliveries, nil
+}
+
+func (s *natsSubscription) Close() error {
+	return nil
+}
+
+// natsDelivery is a JetStream message waiting for its ack
+type natsDelivery struct {
+	js        natsPublisher
+	queueName string
+	msg       jetstream.Msg
+}
+
+func (d *natsDelivery) Body() []byte {
+	return d.msg.Data()
+}
+
+func (d *natsDelivery) Attempt() int {
+	meta, err := d.msg.Metadata()
+	if err != nil {
+		return 1
+	}
+	return int(meta.NumDelivered)
+}
+
+func (d *natsDelivery) Ack(ctx context.Context) error {
+	return d.msg.DoubleAck(ctx)
+}
+
+// Retry asks JetStream to deliver the message again after delay
+func (d *natsDelivery) Retry(ctx context.Context, delay time.Duration, cause error) error {
+	return d.msg.NakWithDelay(delay)
+}
+
+// DeadLetter publishes the message to the dead-letter subject before acking it,
+// so it is delivered again rather than lost when the publish fails
+func (d *natsDelivery) DeadLetter(ctx context.Context, cause error) error {
+	header := nats.Header{}
+	for k, v := range d.msg.Headers() {
+		header[k] = v
+	}
+	header.Set(headerAttempt, strconv.Itoa(d.Attempt()))
+	header.Set(headerLastError, truncate(cause.Error(), maxLastErrorLength))
+
+	_, err := d.js.PublishMsg(ctx, &nats.Msg{
+		Subject: deadLetterQueueName(d.queueName),
+		Header:  header,
+		Data:    d.msg.Data(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish to dead-letter subject: %w", err)
+	}
+	return d.msg.Ack()
+}
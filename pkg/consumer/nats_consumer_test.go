@@ -0,0 +1,128 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// fakeNATSMsg is a JetStream message recording how it was settled
+type fakeNATSMsg struct {
+	jetstream.Msg
+	data      []byte
+	header    nats.Header
+	delivered uint64
+	acked     bool
+	nakDelay  time.Duration
+	naked     bool
+}
+
+func (m *fakeNATSMsg) Data() []byte {
+	return m.data
+}
+
+func (m *fakeNATSMsg) Headers() nats.Header {
+	return m.header
+}
+
+func (m *fakeNATSMsg) Metadata() (*jetstream.MsgMetadata, error) {
+	return &jetstream.MsgMetadata{NumDelivered: m.delivered}, nil
+}
+
+func (m *fakeNATSMsg) Ack() error {
+	m.acked = true
+	return nil
+}
+
+func (m *fakeNATSMsg) DoubleAck(ctx context.Context) error {
+	m.acked = true
+	return nil
+}
+
+func (m *fakeNATSMsg) NakWithDelay(delay time.Duration) error {
+	m.naked = true
+	m.nakDelay = delay
+	return nil
+}
+
+// fakeNATSPublisher records the published messages, or fails every publish with err
+type fakeNATSPublisher struct {
+	published []*nats.Msg
+	err       error
+}
+
+func (p *fakeNATSPublisher) PublishMsg(ctx context.Context, msg *nats.Msg, opts ...jetstream.PublishOpt) (*jetstream.PubAck, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	p.published = append(p.published, msg)
+	return &jetstream.PubAck{}, nil
+}
+
+func newFakeNATSDelivery(publisher *fakeNATSPublisher) (*natsDelivery, *fakeNATSMsg) {
+	msg := &fakeNATSMsg{
+		data:      []byte(`{"number":"5"}`),
+		header:    nats.Header{"Nats-Msg-Id": []string{"id-1"}},
+		delivered: 2,
+	}
+	return &natsDelivery{js: publisher, queueName: "q", msg: msg}, msg
+}
+
+func TestNATSDelivery_AckAndRetry(t *testing.T) {
+	ctx := context.Background()
+	delivery, msg := newFakeNATSDelivery(&fakeNATSPublisher{})
+
+	if delivery.Attempt() != 2 || string(delivery.Body()) != `{"number":"5"}` {
+		t.Errorf("Unexpected delivery: attempt %d, body %q", delivery.Attempt(), delivery.Body())
+	}
+	if err := delivery.Retry(ctx, 3*time.Second, errors.New("transient failure")); err != nil {
+		t.Fatalf("Retry failed: %v", err)
+	}
+	if !msg.naked || msg.nakDelay != 3*time.Second || msg.acked {
+		t.Errorf("Expected a nak delayed by 3s, got naked %v after %v, acked %v", msg.naked, msg.nakDelay, msg.acked)
+	}
+
+	if err := delivery.Ack(ctx); err != nil {
+		t.Fatalf("Ack failed: %v", err)
+	}
+	if !msg.acked {
+		t.Error("Expected the message to be acked")
+	}
+}
+
+func TestNATSDelivery_DeadLetter(t *testing.T) {
+	publisher := &fakeNATSPublisher{}
+	delivery, msg := newFakeNATSDelivery(publisher)
+
+	if err := delivery.DeadLetter(context.Background(), errors.New("gave up")); err != nil {
+		t.Fatalf("DeadLetter failed: %v", err)
+	}
+	if len(publisher.published) != 1 {
+		t.Fatalf("Expected one dead-lettered message, got %d", len(publisher.published))
+	}
+	dead := publisher.published[0]
+	if dead.Subject != deadLetterQueueName("q") || string(dead.Data) != string(msg.data) {
+		t.Errorf("Unexpected dead-lettered message: %s %q", dead.Subject, dead.Data)
+	}
+	if dead.Header.Get(headerAttempt) != "2" || dead.Header.Get(headerLastError) != "gave up" || dead.Header.Get("Nats-Msg-Id") != "id-1" {
+		t.Errorf("Unexpected dead-letter headers: %v", dead.Header)
+	}
+	if !msg.acked {
+		t.Error("Expected the original to be acked")
+	}
+}
+
+func TestNATSDelivery_DeadLetterPublishFailureKeepsMessage(t *testing.T) {
+	delivery, msg := newFakeNATSDelivery(&fakeNATSPublisher{err: errors.New("no responders")})
+
+	if err := delivery.DeadLetter(context.Background(), errors.New("gave up")); err == nil {
+		t.Fatal("Expected DeadLetter to fail")
+	}
+	if msg.acked {
+		t.Error("Expected the original not to be acked, so it is delivered again")
+	}
+}
@@ -0,0 +1,297 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"runtime"
+	"sync"
+	"time"
+
+	"factorial-cal-services/pkg/utils/patterns"
+)
+
+// pollWait is how long a fetch waits for the first message before the consumer checks ctx again
+const pollWait = time.Second
+
+// Delivery is a message received from a polled backend
+type Delivery interface {
+	Body() []byte
+	// Attempt is the delivery attempt of the message, starting at 1
+	Attempt() int
+	// Ack removes the handled message from the queue
+	Ack(ctx context.Context) error
+	// Retry delivers the message again after delay
+	Retry(ctx context.Context, delay time.Duration, cause error) error
+	// DeadLetter moves the message to the dead-letter queue
+	DeadLetter(ctx context.Context, cause error) error
+}
+
+// Subscription receives the messages of a single queue
+type Subscription interface {
+	// Fetch returns up to max messages, waiting up to wait for the first one.
+	// It returns no messages and no error when none arrived in time.
+	Fetch(ctx context.Context, max int, wait time.Duration) ([]Delivery, error)
+	Close() error
+}
+
+// Subscriber opens subscriptions on a polled backend
+type Subscriber interface {
+	Subscribe(ctx context.Context, queueName string) (Subscription, error)
+}
+
+// PollingConsumer implements Consumer for backends the consumer pulls messages from
+// (NATS JetStream, Kafka, Postgres, in-memory). Failed messages are retried and
+// dead-lettered with the retry policy through the Delivery of the backend.
+type PollingConsumer struct {
+	subscriber  Subscriber
+	semaphore   *patterns.Semaphore
+	retryPolicy RetryPolicy
+	batchWindow time.Duration
+
+	mu            sync.Mutex
+	subscriptions map[Subscription]struct{}
+}
+
+// NewPollingConsumer creates a consumer pulling messages through subscriber
+func NewPollingConsumer(subscriber Subscriber, retryPolicy RetryPolicy, batchWindow time.Duration) *PollingConsumer {
+	if batchWindow <= 0 {
+		batchWindow = DefaultBatchWindow
+	}
+	return &PollingConsumer{
+		subscriber:    subscriber,
+		semaphore:     patterns.NewSemaphore(runtime.NumCPU() * 4),
+		retryPolicy:   retryPolicy,
+		batchWindow:   batchWindow,
+		subscriptions: make(map[Subscription]struct{}),
+	}
+}
+
+// Consume starts consuming messages from the specified queue. Fetched messages are handled
+// concurrently and settled in the order they were fetched, so backends committing offsets
+// never skip a message. Once ctx is done the messages in flight are finished.
+func (c *PollingConsumer) Consume(ctx context.Context, queueName string, handler MessageHandler) error {
+	return c.run(ctx, queueName, func(sub Subscription) error {
+		// Messages in flight are finished even when ctx is cancelled
+		handlerCtx := context.WithoutCancel(ctx)
+
+		log.Printf("Started polling queue: %s", queueName)
+		for ctx.Err() == nil {
+			deliveries, err := sub.Fetch(ctx, consumePrefetch, pollWait)
+			if err != nil {
+				return err
+			}
+
+			outcomes := make([]error, len(deliveries))
+			var wg sync.WaitGroup
+			for i, delivery := range deliveries {
+				wg.Add(1)
+				c.semaphore.Submit(func() error {
+					defer wg.Done()
+					outcomes[i] = handleRecovered(handlerCtx, delivery.Body(), handler)
+					return nil
+				})
+			}
+			wg.Wait()
+
+			for i, delivery := range deliveries {
+				if err := c.settle(handlerCtx, queueName, delivery, outcomes[i]); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// ConsumeBatch starts consuming messages from the specified queue in batches of up to
+// batchSize, flushing a partial batch once the batch window has elapsed since its first
+// message. A batch fetched when ctx is done is still handled.
+func (c *PollingConsumer) ConsumeBatch(ctx context.Context, queueName string, batchSize int, handler BatchMessageHandler) error {
+	if batchSize <= 0 {
+		return fmt.Errorf("batch size must be positive (got %d)", batchSize)
+	}
+	return c.run(ctx, queueName, func(sub Subscription) error {
+		handlerCtx := context.WithoutCancel(ctx)
+
+		log.Printf("Started polling batches of up to %d messages from queue: %s", batchSize, queueName)
+		for ctx.Err() == nil {
+			batch, fetchErr := c.collectBatch(ctx, sub, batchSize)
+			if len(batch) > 0 {
+				if err := c.handleBatch(handlerCtx, queueName, batch, handler); err != nil {
+					return err
+				}
+			}
+			if fetchErr != nil {
+				return fetchErr
+			}
+		}
+		return nil
+	})
+}
+
+// collectBatch fetches until the batch is full or the batch window of its first message elapsed
+func (c *PollingConsumer) collectBatch(ctx context.Context, sub Subscription, batchSize int) ([]Delivery, error) {
+	batch, err := sub.Fetch(ctx, batchSize, pollWait)
+	if err != nil || len(batch) == 0 {
+		return batch, err
+	}
+
+	deadline := time.Now().Add(c.batchWindow)
+	for len(batch) < batchSize {
+		wait := time.Until(deadline)
+		if wait <= 0 {
+			break
+		}
+		more, err := sub.Fetch(ctx, batchSize-len(batch), wait)
+		batch = append(batch, more...)
+		if err != nil {
+			return batch, err
+		}
+	}
+	return batch, nil
+}
+
+// handleBatch runs the batch handler and settles every message with its outcome, stopping at
+// the first message that could not be settled
+func (c *PollingConsumer) handleBatch(ctx context.Context, queueName string, batch []Delivery, handler BatchMessageHandler) error {
+	payloads := make([][]byte, len(batch))
+	for i, delivery := range batch {
+		payloads[i] = delivery.Body()
+	}
+	log.Printf("Received batch of %d messages", len(batch))
+
+	err := handleBatchRecovered(ctx, payloads, handler)
+	var batchErr *BatchError
+	isBatchErr := errors.As(err, &batchErr)
+	for i, delivery := range batch {
+		outcome := err
+		if isBatchErr {
+			outcome = batchErr.Failed[i]
+		}
+		if err := c.settle(ctx, queueName, delivery, outcome); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// settle acks a handled message, or retries or dead-letters a failed one. A failed message
+// that could be neither retried nor dead-lettered is returned as an error, and no later message
+// may be settled: Kafka commits every earlier offset with a later one, so acking the next
+// message would lose it. The subscription is opened again instead, delivering it once more.
+func (c *PollingConsumer) settle(ctx context.Context, queueName string, delivery Delivery, cause error) error {
+	if cause == nil {
+		if err := delivery.Ack(ctx); err != nil {
+			log.Printf("Error acking message of queue %s: %v", queueName, err)
+		}
+		return nil
+	}
+
+	attempt := delivery.Attempt()
+	if c.retryPolicy.ShouldRetry(attempt, cause) {
+		delay := c.retryPolicy.Delay(attempt)
+		if err := delivery.Retry(ctx, delay, cause); err != nil {
+			return fmt.Errorf("failed to schedule retry of message of queue %s: %w", queueName, err)
+		}
+		log.Printf("Message of queue %s failed attempt %d, retrying in %v: %v", queueName, attempt, delay, cause)
+		return nil
+	}
+
+	if err := delivery.DeadLetter(ctx, cause); err != nil {
+		return fmt.Errorf("failed to dead-letter message of queue %s: %w", queueName, err)
+	}
+	log.Printf("Message of queue %s failed attempt %d, dead-lettered: %v", queueName, attempt, cause)
+	return nil
+}
+
+// run keeps a subscription to the queue open and passes it to process, subscribing
+// again when process fails
+func (c *PollingConsumer) run(ctx context.Context, queueName string, process func(sub Subscription) error) error {
+	for first := true; ; first = false {
+		sub, err := c.subscriber.Subscribe(ctx, queueName)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			// A broken setup is reported at startup, later failures are retried
+			if first {
+				return err
+			}
+			log.Printf("Failed to subscribe to queue %s, retrying: %v", queueName, err)
+			sleepContext(ctx, resubscribeDelay)
+			continue
+		}
+
+		c.track(sub)
+		err = process(sub)
+		c.release(sub)
+
+		if ctx.Err() != nil {
+			return nil
+		}
+		log.Printf("Subscription to queue %s failed, re-subscribing: %v", queueName, err)
+		sleepContext(ctx, resubscribeDelay)
+	}
+}
+
+func (c *PollingConsumer) track(sub Subscription) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.subscriptions[sub] = struct{}{}
+}
+
+func (c *PollingConsumer) release(sub Subscription) {
+	c.mu.Lock()
+	delete(c.subscriptions, sub)
+	c.mu.Unlock()
+	if err := sub.Close(); err != nil {
+		log.Printf("Error closing subscription: %v", err)
+	}
+}
+
+// Close closes the open subscriptions; connections are closed by their owner
+func (c *PollingConsumer) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var errs []error
+	for sub := range c.subscriptions {
+		errs = append(errs, sub.Close())
+		delete(c.subscriptions, sub)
+	}
+	return errors.Join(errs...)
+}
+
+// handleRecovered runs handler, turning a panic into an error
+func handleRecovered(ctx context.Context, payload []byte, handler MessageHandler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("PANIC recovered while processing message: %v", r)
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handler(ctx, payload)
+}
+
+// handleBatchRecovered runs handler, turning a panic into an error
+func handleBatchRecovered(ctx context.Context, payloads [][]byte, handler BatchMessageHandler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("PANIC recovered while processing batch of %d messages: %v", len(payloads), r)
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handler(ctx, payloads)
+}
+
+// sleepContext pauses for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
@@ -0,0 +1,110 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"factorial-cal-services/pkg/memqueue"
+)
+
+// waitFor polls condition until it holds or the test times out
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for condition")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestPollingConsumer_Consume_RetriesThenDeadLetters(t *testing.T) {
+	broker := memqueue.NewBroker()
+	broker.Publish("q", []byte("good"))
+	broker.Publish("q", []byte("bad"))
+
+	var mu sync.Mutex
+	calls := map[string]int{}
+	handler := func(ctx context.Context, payload []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		calls[string(payload)]++
+		if string(payload) == "bad" {
+			return errors.New("transient failure")
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := NewMemoryConsumer(broker, NewRetryPolicy(3, time.Millisecond, time.Millisecond), time.Millisecond)
+	done := make(chan error, 1)
+	go func() { done <- c.Consume(ctx, "q", handler) }()
+
+	waitFor(t, func() bool { return broker.Len(deadLetterQueueName("q")) == 1 })
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if calls["good"] != 1 || calls["bad"] != 3 {
+		t.Errorf("Expected good handled once and bad 3 times, got %v", calls)
+	}
+	if broker.Len("q") != 0 {
+		t.Errorf("Expected the queue to be drained, got %d messages", broker.Len("q"))
+	}
+}
+
+func TestPollingConsumer_ConsumeBatch_PartialFailure(t *testing.T) {
+	broker := memqueue.NewBroker()
+	for _, body := range []string{"1", "bad", "3"} {
+		broker.Publish("q", []byte(body))
+	}
+
+	var mu sync.Mutex
+	var batches [][]string
+	handler := func(ctx context.Context, payloads [][]byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		batch := make([]string, len(payloads))
+		failed := map[int]error{}
+		for i, payload := range payloads {
+			batch[i] = string(payload)
+			if string(payload) == "bad" {
+				failed[i] = fmt.Errorf("%w: failed to parse message", ErrPermanent)
+			}
+		}
+		batches = append(batches, batch)
+		if len(failed) > 0 {
+			return &BatchError{Failed: failed}
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := NewMemoryConsumer(broker, NewRetryPolicy(3, time.Millisecond, time.Millisecond), 50*time.Millisecond)
+	done := make(chan error, 1)
+	go func() { done <- c.ConsumeBatch(ctx, "q", 10, handler) }()
+
+	waitFor(t, func() bool { return broker.Len(deadLetterQueueName("q")) == 1 })
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("ConsumeBatch failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	// The permanent failure is dead-lettered without a retry, the others are acked
+	if len(batches) != 1 || len(batches[0]) != 3 {
+		t.Errorf("Expected a single batch of 3 messages, got %v", batches)
+	}
+	if broker.Len("q") != 0 {
+		t.Errorf("Expected the queue to be drained, got %d messages", broker.Len("q"))
+	}
+}
@@ -0,0 +1,103 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"factorial-cal-services/pkg/domain"
+	"factorial-cal-services/pkg/repository"
+)
+
+const (
+	// postgresPollInterval is the pause between two leases of an empty queue
+	postgresPollInterval = 200 * time.Millisecond
+	// postgresLease hides a leased message from other consumers; it is delivered again
+	// when its consumer neither acks nor retries it in time
+	postgresLease = 5 * time.Minute
+)
+
+// postgresSubscriber subscribes to queues stored in the queue_messages table
+type postgresSubscriber struct {
+	repository repository.QueueRepository
+	lease      time.Duration
+}
+
+// NewPostgresConsumer creates a consumer leasing messages from the queue_messages table
+// with SELECT ... FOR UPDATE SKIP LOCKED, so concurrent workers never fetch the same message
+func NewPostgresConsumer(repository repository.QueueRepository, retryPolicy RetryPolicy, batchWindow time.Duration) *PollingConsumer {
+	return NewPollingConsumer(&postgresSubscriber{repository: repository, lease: postgresLease}, retryPolicy, batchWindow)
+}
+
+func (s *postgresSubscriber) Subscribe(ctx context.Context, queueName string) (Subscription, error) {
+	if err := s.repository.Ping(); err != nil {
+		return nil, fmt.Errorf("failed to reach the queue database: %w", err)
+	}
+	return &postgresSubscription{repository: s.repository, queueName: queueName, lease: s.lease}, nil
+}
+
+type postgresSubscription struct {
+	repository repository.QueueRepository
+	queueName  string
+	lease      time.Duration
+}
+
+func (s *postgresSubscription) Fetch(ctx context.Context, max int, wait time.Duration) ([]Delivery, error) {
+	deadline := time.Now().Add(wait)
+	for {
+		messages, err := s.repository.Lease(s.queueName, max, s.lease)
+		if err != nil {
+			return nil, fmt.Errorf("failed to lease messages: %w", err)
+		}
+		if len(messages) > 0 {
+			deliveries := make([]Delivery, len(messages))
+			for i := range messages {
+				deliveries[i] = &postgresDelivery{repository: s.repository, queueName: s.queueName, message: &messages[i]}
+			}
+			return deliveries, nil
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil, nil
+		}
+		timer := time.NewTimer(min(remaining, postgresPollInterval))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (s *postgresSubscription) Close() error {
+	return nil
+}
+
+// postgresDelivery is a leased row of the queue_messages table
+type postgresDelivery struct {
+	repository repository.QueueRepository
+	queueName  string
+	message    *domain.QueueMessage
+}
+
+func (d *postgresDelivery) Body() []byte {
+	return d.message.Payload
+}
+
+func (d *postgresDelivery) Attempt() int {
+	return d.message.Attempts
+}
+
+func (d *postgresDelivery) Ack(ctx context.Context) error {
+	return d.repository.Delete(d.message.ID)
+}
+
+func (d *postgresDelivery) Retry(ctx context.Context, delay time.Duration, cause error) error {
+	return d.repository.Reschedule(d.message.ID, time.Now().Add(delay), truncate(cause.Error(), maxLastErrorLength))
+}
+
+func (d *postgresDelivery) DeadLetter(ctx context.Context, cause error) error {
+	return d.repository.Move(d.message.ID, deadLetterQueueName(d.queueName), truncate(cause.Error(), maxLastErrorLength))
+}
@@ -0,0 +1,198 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"factorial-cal-services/pkg/domain"
+	"factorial-cal-services/pkg/repository"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupQueueDB opens an in-memory queue database on a single connection, so every
+// session of the test sees the same tables
+func setupQueueDB(t *testing.T) (*gorm.DB, repository.QueueRepository) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("Failed to get database: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&domain.QueueMessage{}); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+	return db, repository.NewQueueRepository(db)
+}
+
+func enqueue(t *testing.T, repo repository.QueueRepository, bodies ...string) {
+	t.Helper()
+	for _, body := range bodies {
+		if err := repo.Enqueue(&domain.QueueMessage{Queue: "q", Payload: []byte(body)}); err != nil {
+			t.Fatalf("Failed to enqueue: %v", err)
+		}
+	}
+}
+
+func TestPostgresConsumer_LeasesAndSettles(t *testing.T) {
+	db, repo := setupQueueDB(t)
+	enqueue(t, repo, "a", "b")
+
+	sub, err := (&postgresSubscriber{repository: repo, lease: postgresLease}).Subscribe(context.Background(), "q")
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	ctx := context.Background()
+	deliveries, err := sub.Fetch(ctx, 10, time.Millisecond)
+	if err != nil || len(deliveries) != 2 {
+		t.Fatalf("Expected 2 deliveries, got %d (%v)", len(deliveries), err)
+	}
+	if deliveries[0].Attempt() != 1 {
+		t.Errorf("Expected first attempt, got %d", deliveries[0].Attempt())
+	}
+
+	// Leased messages are hidden from other consumers
+	leased, err := sub.Fetch(ctx, 10, time.Millisecond)
+	if err != nil || len(leased) != 0 {
+		t.Fatalf("Expected leased messages to be hidden, got %d (%v)", len(leased), err)
+	}
+
+	if err := deliveries[0].Ack(ctx); err != nil {
+		t.Fatalf("Ack failed: %v", err)
+	}
+	if err := deliveries[1].Retry(ctx, 0, errors.New("transient failure")); err != nil {
+		t.Fatalf("Retry failed: %v", err)
+	}
+
+	retried, err := sub.Fetch(ctx, 10, time.Millisecond)
+	if err != nil || len(retried) != 1 || string(retried[0].Body()) != "b" || retried[0].Attempt() != 2 {
+		t.Fatalf("Expected b on its second attempt, got %v (%v)", retried, err)
+	}
+	if err := retried[0].DeadLetter(ctx, errors.New("gave up")); err != nil {
+		t.Fatalf("DeadLetter failed: %v", err)
+	}
+
+	var dead domain.QueueMessage
+	if err := db.Where("queue = ?", deadLetterQueueName("q")).First(&dead).Error; err != nil {
+		t.Fatalf("Expected a dead-lettered message: %v", err)
+	}
+	if string(dead.Payload) != "b" || dead.LastError != "gave up" {
+		t.Errorf("Unexpected dead-lettered message: %+v", dead)
+	}
+	var remaining int64
+	db.Model(&domain.QueueMessage{}).Where("queue = ?", "q").Count(&remaining)
+	if remaining != 0 {
+		t.Errorf("Expected the queue to be empty, got %d messages", remaining)
+	}
+}
+
+func TestPostgresConsumer_ExpiredLeaseIsDeliveredAgain(t *testing.T) {
+	_, repo := setupQueueDB(t)
+	enqueue(t, repo, "a")
+
+	lease := 50 * time.Millisecond
+	sub, err := (&postgresSubscriber{repository: repo, lease: lease}).Subscribe(context.Background(), "q")
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	ctx := context.Background()
+	leased, err := sub.Fetch(ctx, 10, time.Millisecond)
+	if err != nil || len(leased) != 1 {
+		t.Fatalf("Expected a delivery, got %d (%v)", len(leased), err)
+	}
+
+	// The consumer died without settling: the message is hidden until its lease expires
+	if hidden, err := sub.Fetch(ctx, 10, lease/5); err != nil || len(hidden) != 0 {
+		t.Fatalf("Expected the leased message to be hidden, got %d (%v)", len(hidden), err)
+	}
+	again, err := sub.Fetch(ctx, 10, 4*lease)
+	if err != nil || len(again) != 1 || string(again[0].Body()) != "a" || again[0].Attempt() != 2 {
+		t.Fatalf("Expected a on its second attempt after the lease expired, got %d (%v)", len(again), err)
+	}
+}
+
+func TestPostgresConsumer_RetryIsHiddenUntilDue(t *testing.T) {
+	_, repo := setupQueueDB(t)
+	enqueue(t, repo, "a")
+
+	sub, err := (&postgresSubscriber{repository: repo, lease: postgresLease}).Subscribe(context.Background(), "q")
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	ctx := context.Background()
+	deliveries, err := sub.Fetch(ctx, 10, time.Millisecond)
+	if err != nil || len(deliveries) != 1 {
+		t.Fatalf("Expected a delivery, got %d (%v)", len(deliveries), err)
+	}
+	delay := 100 * time.Millisecond
+	retriedAt := time.Now()
+	if err := deliveries[0].Retry(ctx, delay, errors.New("transient failure")); err != nil {
+		t.Fatalf("Retry failed: %v", err)
+	}
+
+	retried, err := sub.Fetch(ctx, 10, 5*delay)
+	if err != nil || len(retried) != 1 {
+		t.Fatalf("Expected the retried message, got %d (%v)", len(retried), err)
+	}
+	if elapsed := time.Since(retriedAt); elapsed < delay {
+		t.Errorf("Expected the retry after %v, got it after %v", delay, elapsed)
+	}
+}
+
+func TestPostgresConsumer_ConcurrentLeasesDoNotOverlap(t *testing.T) {
+	_, repo := setupQueueDB(t)
+	const messages = 20
+	for i := 0; i < messages; i++ {
+		enqueue(t, repo, fmt.Sprint(i))
+	}
+
+	subscriber := &postgresSubscriber{repository: repo, lease: postgresLease}
+	var mu sync.Mutex
+	seen := map[string]int{}
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sub, err := subscriber.Subscribe(context.Background(), "q")
+			if err != nil {
+				t.Errorf("Subscribe failed: %v", err)
+				return
+			}
+			for {
+				deliveries, err := sub.Fetch(context.Background(), 3, time.Millisecond)
+				if err != nil {
+					t.Errorf("Fetch failed: %v", err)
+					return
+				}
+				if len(deliveries) == 0 {
+					return
+				}
+				mu.Lock()
+				for _, d := range deliveries {
+					seen[string(d.Body())]++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(seen) != messages {
+		t.Errorf("Expected all %d messages leased, got %d", messages, len(seen))
+	}
+	for body, n := range seen {
+		if n != 1 {
+			t.Errorf("Expected message %s leased once, got %d", body, n)
+		}
+	}
+}
@@ -2,96 +2,266 @@ package consumer
 
 import (
 	"context"
-	"crypto/tls"
-	"crypto/x509"
+	"errors"
 	"fmt"
 	"log"
-	"os"
 	"runtime"
-	"strings"
+	"sync"
+	"time"
 
+	"factorial-cal-services/pkg/rabbitmq"
 	"factorial-cal-services/pkg/utils/patterns"
 
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
-// RabbitMQConsumer implements Consumer for RabbitMQ
-type RabbitMQConsumer struct {
-	conn      *amqp.Connection
-	channel   *amqp.Channel
-	semaphore *patterns.Semaphore
+const (
+	// resubscribeDelay is the pause before re-registering a consumer whose channel failed
+	resubscribeDelay = time.Second
+	// consumePrefetch is the number of unacked messages a Consume subscription holds
+	consumePrefetch = 100
+	// DefaultBatchWindow is how long ConsumeBatch waits for a batch to fill up before flushing it
+	DefaultBatchWindow = time.Second
+)
+
+// amqpChannel is the part of an amqpChannel the consumer subscribes and republishes on
+type amqpChannel interface {
+	Qos(prefetchCount, prefetchSize int, global bool) error
+	Confirm(noWait bool) error
+	QueueDeclare(name string, durable, autoDelete, exclusive, noWait bool, args amqp.Table) (amqp.Queue, error)
+	ExchangeDeclare(name, kind string, durable, autoDelete, internal, noWait bool, args amqp.Table) error
+	QueueBind(name, key, exchange string, noWait bool, args amqp.Table) error
+	Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error)
+	PublishWithDeferredConfirmWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) (*amqp.DeferredConfirmation, error)
+	IsClosed() bool
+	Close() error
 }
 
-// NewRabbitMQConsumer creates a new RabbitMQ consumer
-func NewRabbitMQConsumer(amqpURL string) (*RabbitMQConsumer, error) {
-	var conn *amqp.Connection
-	var err error
-
-	// Check if TLS is enabled by looking for RABBITMQ_CA
-	rootCA := os.Getenv("RABBITMQ_CA")
-	if rootCA != "" {
-		// Replace literal \n with actual newlines
-		rootCA = strings.ReplaceAll(rootCA, "\\n", "\n")
-		fmt.Println("rootCA", rootCA)
-
-		caCertPool := x509.NewCertPool()
-		if !caCertPool.AppendCertsFromPEM([]byte(rootCA)) {
-			return nil, fmt.Errorf("failed to append root CA cert")
-		}
+// amqpConnection is the part of the connection manager the consumer opens channels from
+type amqpConnection interface {
+	WaitConnected(ctx context.Context) error
+	Channel() (amqpChannel, error)
+}
 
-		tlsConfig := &tls.Config{
-			RootCAs:            caCertPool,
-			InsecureSkipVerify: false, // keep secure!
-		}
+// managerConnection adapts a *rabbitmq.ConnectionManager to amqpConnection
+type managerConnection struct {
+	*rabbitmq.ConnectionManager
+}
 
-		conn, err = amqp.DialTLS(amqpURL, tlsConfig)
-	} else {
-		// Use plain connection for local development
-		conn, err = amqp.Dial(amqpURL)
+func (m managerConnection) Channel() (amqpChannel, error) {
+	ch, err := m.ConnectionManager.Channel()
+	if err != nil {
+		return nil, err
 	}
+	return ch, nil
+}
 
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+// RabbitMQConsumer implements Consumer for RabbitMQ
+type RabbitMQConsumer struct {
+	conn        amqpConnection
+	semaphore   *patterns.Semaphore
+	retryPolicy RetryPolicy
+	batchWindow time.Duration
+
+	mu       sync.Mutex
+	channels map[amqpChannel]struct{}
+}
+
+// NewRabbitMQConsumer creates a new RabbitMQ consumer on the shared connection,
+// retrying failed messages with retryPolicy and flushing partial batches after batchWindow
+func NewRabbitMQConsumer(manager *rabbitmq.ConnectionManager, retryPolicy RetryPolicy, batchWindow time.Duration) (*RabbitMQConsumer, error) {
+	return newRabbitMQConsumer(managerConnection{manager}, retryPolicy, batchWindow), nil
+}
+
+func newRabbitMQConsumer(conn amqpConnection, retryPolicy RetryPolicy, batchWindow time.Duration) *RabbitMQConsumer {
+	if batchWindow <= 0 {
+		batchWindow = DefaultBatchWindow
+	}
+	return &RabbitMQConsumer{
+		conn:        conn,
+		semaphore:   patterns.NewSemaphore(runtime.NumCPU() * 4),
+		retryPolicy: retryPolicy,
+		batchWindow: batchWindow,
+		channels:    make(map[amqpChannel]struct{}),
 	}
+}
 
-	ch, err := conn.Channel()
+// openChannel opens a channel holding up to prefetch unacked messages; deliveries are
+// received and failed messages are republished on it
+func (c *RabbitMQConsumer) openChannel(prefetch int) (amqpChannel, error) {
+	ch, err := c.conn.Channel()
 	if err != nil {
-		conn.Close()
 		return nil, fmt.Errorf("failed to open channel: %w", err)
 	}
 
-	// Set QoS to allow prefetching for batch processing
-	// Increase prefetch count to batch size for better throughput
 	err = ch.Qos(
-		100,   // prefetch count (allow multiple messages for batching)
-		0,     // prefetch size
-		false, // global
+		prefetch, // prefetch count
+		0,        // prefetch size
+		false,    // global
 	)
 	if err != nil {
 		ch.Close()
-		conn.Close()
 		return nil, fmt.Errorf("failed to set QoS: %w", err)
 	}
 
-	return &RabbitMQConsumer{
-		conn:      conn,
-		channel:   ch,
-		semaphore: patterns.NewSemaphore(runtime.NumCPU() * 4),
-	}, nil
+	// Confirm republished retries so a failed message is only acked once the broker has its copy
+	if err := ch.Confirm(false); err != nil {
+		ch.Close()
+		return nil, fmt.Errorf("failed to enable publisher confirms: %w", err)
+	}
+
+	c.mu.Lock()
+	c.channels[ch] = struct{}{}
+	c.mu.Unlock()
+	return ch, nil
 }
 
-// Consume starts consuming messages from the specified queue
+// releaseChannel closes a channel whose subscription ended
+func (c *RabbitMQConsumer) releaseChannel(ch amqpChannel) {
+	c.mu.Lock()
+	delete(c.channels, ch)
+	c.mu.Unlock()
+	if !ch.IsClosed() {
+		ch.Close()
+	}
+}
+
+// Consume starts consuming messages from the specified queue, handling them concurrently.
+// Once ctx is done it stops accepting deliveries, waits for the messages in flight and returns;
+// prefetched messages that were not handled are redelivered by the broker.
 func (c *RabbitMQConsumer) Consume(ctx context.Context, queueName string, handler MessageHandler) error {
+	return c.run(ctx, queueName, consumePrefetch, func(ch amqpChannel, msgs <-chan amqp.Delivery) {
+		// Messages in flight are finished even when ctx is cancelled
+		handlerCtx := context.WithoutCancel(ctx)
+		defer c.semaphore.Wait()
+
+		log.Printf("Started %v consuming from queue: %s", runtime.NumCPU(), queueName)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-msgs:
+				if !ok {
+					return
+				}
+				err := c.semaphore.SubmitContext(ctx, func() error {
+					c.handleMessageAndAck(handlerCtx, ch, queueName, msg, handler)
+					return nil
+				})
+				if err != nil {
+					// Shutting down, msg is redelivered once the channel is closed
+					return
+				}
+			}
+		}
+	})
+}
+
+// ConsumeBatch starts consuming messages from the specified queue in batches of up to
+// batchSize, flushing a partial batch once the batch window has elapsed since its first
+// message. Call it several times to handle batches concurrently; every call owns its channel.
+// Once ctx is done the batch in flight is finished and a partial batch is left for redelivery.
+func (c *RabbitMQConsumer) ConsumeBatch(ctx context.Context, queueName string, batchSize int, handler BatchMessageHandler) error {
+	if batchSize <= 0 {
+		return fmt.Errorf("batch size must be positive (got %d)", batchSize)
+	}
+	return c.run(ctx, queueName, batchSize, func(ch amqpChannel, msgs <-chan amqp.Delivery) {
+		log.Printf("Started consuming batches of up to %d messages from queue: %s", batchSize, queueName)
+		c.collectBatches(ctx, ch, queueName, msgs, batchSize, handler)
+	})
+}
+
+// collectBatches groups deliveries into batches and handles them one at a time, so the
+// multiple ack of a batch never covers a message of another batch
+func (c *RabbitMQConsumer) collectBatches(ctx context.Context, ch amqpChannel, queueName string, msgs <-chan amqp.Delivery, batchSize int, handler BatchMessageHandler) {
+	handlerCtx := context.WithoutCancel(ctx)
+	batch := make([]amqp.Delivery, 0, batchSize)
+	window := time.NewTimer(c.batchWindow)
+	window.Stop()
+	defer window.Stop()
+
+	flush := func() {
+		window.Stop()
+		if len(batch) == 0 {
+			return
+		}
+		c.handleBatchAndAck(handlerCtx, ch, queueName, batch, handler)
+		batch = make([]amqp.Delivery, 0, batchSize)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			// Unacked messages are redelivered once the channel is closed
+			return
+		case msg, ok := <-msgs:
+			if !ok {
+				return
+			}
+			batch = append(batch, msg)
+			if len(batch) == 1 {
+				window.Reset(c.batchWindow)
+			}
+			if len(batch) >= batchSize {
+				flush()
+			}
+		case <-window.C:
+			flush()
+		}
+	}
+}
+
+// run keeps a subscription to the queue alive and passes its deliveries to process. When
+// the channel or the connection drops, it waits for the connection manager to reconnect,
+// re-declares the queues and registers the consumer again.
+func (c *RabbitMQConsumer) run(ctx context.Context, queueName string, prefetch int, process func(ch amqpChannel, msgs <-chan amqp.Delivery)) error {
+	for first := true; ; first = false {
+		if err := c.conn.WaitConnected(ctx); err != nil {
+			if errors.Is(err, rabbitmq.ErrClosed) || ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		ch, msgs, err := c.subscribe(queueName, prefetch)
+		if err != nil {
+			// A broken setup is reported at startup, later failures are retried
+			if first && !errors.Is(err, rabbitmq.ErrNotConnected) {
+				return err
+			}
+			log.Printf("Failed to register consumer on queue %s, retrying: %v", queueName, err)
+			time.Sleep(resubscribeDelay)
+			continue
+		}
+
+		process(ch, msgs)
+		c.releaseChannel(ch)
+
+		if ctx.Err() != nil {
+			return nil
+		}
+		log.Printf("Consumer channel of queue %s closed, re-registering", queueName)
+	}
+}
+
+// subscribe opens a channel, declares the queues and registers the consumer on it
+func (c *RabbitMQConsumer) subscribe(queueName string, prefetch int) (amqpChannel, <-chan amqp.Delivery, error) {
+	ch, err := c.openChannel(prefetch)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// Setup queue
-	if err := c.setupQueues(queueName); err != nil {
-		return err
+	if err := c.setupQueues(ch, queueName); err != nil {
+		c.releaseChannel(ch)
+		return nil, nil, err
 	}
 	autoAck := false          // false: Manual acknowledgment (ack after processing), true: Auto-ack on delivery
 	exclusiveConsume := false // false: Multiple consumers can share the queue (load balancing), true: Only this consumer can consume from the queue
 	noLocal := false          // false: Can receive messages published on the same connection, true: Prevents receiving messages published on the same connection
 	nowait := false           // false: Wait for server confirmation, true: Fire-and-forget (no response)
 
-	msgs, err := c.channel.Consume(
+	msgs, err := ch.Consume(
 		queueName,
 		"",
 		autoAck,
@@ -101,31 +271,23 @@ func (c *RabbitMQConsumer) Consume(ctx context.Context, queueName string, handle
 		nil,
 	)
 	if err != nil {
-		return fmt.Errorf("failed to register consumer: %w", err)
+		c.releaseChannel(ch)
+		return nil, nil, fmt.Errorf("failed to register consumer: %w", err)
 	}
-	// TODO: Fix bug and add graceful shutdown
-	log.Printf("Started %v consuming from queue: %s", runtime.NumCPU(), queueName)
-	for msg := range msgs {
-		c.semaphore.Submit(func() error {
-			c.handleMessageAndAck(ctx, msg, handler)
-			return nil
-		})
-	}
-
-	return nil
+	return ch, msgs, nil
 }
 
-// Close closes the RabbitMQ connection and channel
+// Close closes the RabbitMQ channels; the shared connection is closed by its manager
 func (c *RabbitMQConsumer) Close() error {
-	if c.channel != nil {
-		if err := c.channel.Close(); err != nil {
-			return err
-		}
-	}
-	if c.conn != nil {
-		if err := c.conn.Close(); err != nil {
-			return err
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var errs []error
+	for ch := range c.channels {
+		if !ch.IsClosed() {
+			errs = append(errs, ch.Close())
 		}
+		delete(c.channels, ch)
 	}
-	return nil
+	return errors.Join(errs...)
 }
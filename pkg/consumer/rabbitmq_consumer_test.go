@@ -0,0 +1,173 @@
+package consumer
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// fakeAMQPChannel records the queues declared and consumed on it; closing deliveries
+// simulates the broker closing the channel
+type fakeAMQPChannel struct {
+	deliveries chan amqp.Delivery
+
+	mu       sync.Mutex
+	declared []string
+	consumed []string
+	closed   bool
+}
+
+func (ch *fakeAMQPChannel) Qos(prefetchCount, prefetchSize int, global bool) error {
+	return nil
+}
+
+func (ch *fakeAMQPChannel) Confirm(noWait bool) error {
+	return nil
+}
+
+func (ch *fakeAMQPChannel) QueueDeclare(name string, durable, autoDelete, exclusive, noWait bool, args amqp.Table) (amqp.Queue, error) {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	ch.declared = append(ch.declared, name)
+	return amqp.Queue{Name: name}, nil
+}
+
+func (ch *fakeAMQPChannel) ExchangeDeclare(name, kind string, durable, autoDelete, internal, noWait bool, args amqp.Table) error {
+	return nil
+}
+
+func (ch *fakeAMQPChannel) QueueBind(name, key, exchange string, noWait bool, args amqp.Table) error {
+	return nil
+}
+
+func (ch *fakeAMQPChannel) Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error) {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	ch.consumed = append(ch.consumed, queue)
+	return ch.deliveries, nil
+}
+
+func (ch *fakeAMQPChannel) PublishWithDeferredConfirmWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) (*amqp.DeferredConfirmation, error) {
+	return nil, amqp.ErrClosed
+}
+
+func (ch *fakeAMQPChannel) IsClosed() bool {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	return ch.closed
+}
+
+func (ch *fakeAMQPChannel) Close() error {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	ch.closed = true
+	return nil
+}
+
+// fakeAMQPConnection is always connected and hands out a new channel per subscription
+type fakeAMQPConnection struct {
+	mu       sync.Mutex
+	channels []*fakeAMQPChannel
+}
+
+func (c *fakeAMQPConnection) WaitConnected(ctx context.Context) error {
+	return nil
+}
+
+func (c *fakeAMQPConnection) Channel() (amqpChannel, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := &fakeAMQPChannel{deliveries: make(chan amqp.Delivery, 1)}
+	c.channels = append(c.channels, ch)
+	return ch, nil
+}
+
+// channel returns the i-th channel opened, waiting for it to register its consumer
+func (c *fakeAMQPConnection) channel(t *testing.T, i int) *fakeAMQPChannel {
+	t.Helper()
+	var ch *fakeAMQPChannel
+	waitFor(t, func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if len(c.channels) <= i {
+			return false
+		}
+		ch = c.channels[i]
+		ch.mu.Lock()
+		defer ch.mu.Unlock()
+		return len(ch.consumed) == 1
+	})
+	return ch
+}
+
+// fakeAcknowledger records how a delivery was settled
+type fakeAcknowledger struct {
+	acked chan uint64
+}
+
+func (a *fakeAcknowledger) Ack(tag uint64, multiple bool) error {
+	a.acked <- tag
+	return nil
+}
+
+func (a *fakeAcknowledger) Nack(tag uint64, multiple, requeue bool) error {
+	return nil
+}
+
+func (a *fakeAcknowledger) Reject(tag uint64, requeue bool) error {
+	return nil
+}
+
+func TestRabbitMQConsumer_ResubscribesAfterChannelClose(t *testing.T) {
+	conn := &fakeAMQPConnection{}
+	c := newRabbitMQConsumer(conn, NewRetryPolicy(3, time.Millisecond, time.Millisecond), time.Millisecond)
+	handled := make(chan string, 1)
+	handler := func(ctx context.Context, payload []byte) error {
+		handled <- string(payload)
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- c.Consume(ctx, "q", handler) }()
+
+	first := conn.channel(t, 0)
+	close(first.deliveries)
+
+	// The consumer opens a new channel, declares the queues again and registers on it
+	second := conn.channel(t, 1)
+	if !first.IsClosed() {
+		t.Error("Expected the closed channel to be released")
+	}
+	second.mu.Lock()
+	declared, consumed := second.declared, second.consumed
+	second.mu.Unlock()
+	if len(declared) == 0 || declared[0] != "q" || consumed[0] != "q" {
+		t.Errorf("Expected q declared and consumed again, got declared %v, consumed %v", declared, consumed)
+	}
+
+	acknowledger := &fakeAcknowledger{acked: make(chan uint64, 1)}
+	second.deliveries <- amqp.Delivery{Acknowledger: acknowledger, DeliveryTag: 7, Body: []byte("after")}
+	select {
+	case body := <-handled:
+		if body != "after" {
+			t.Errorf("Expected after handled, got %q", body)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the message on the new channel")
+	}
+	if tag := <-acknowledger.acked; tag != 7 {
+		t.Errorf("Expected delivery 7 acked, got %d", tag)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+	if !second.IsClosed() {
+		t.Error("Expected the channel to be closed once Consume returned")
+	}
+}
@@ -2,9 +2,11 @@ package consumer
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 
+	"factorial-cal-services/pkg/domain"
 	"factorial-cal-services/pkg/dto"
 	"factorial-cal-services/pkg/repository"
 	"factorial-cal-services/pkg/service"
@@ -13,12 +15,12 @@ import (
 )
 
 // handleMessageAndAck handles a single message with panic recovery
-func (c *RabbitMQConsumer) handleMessageAndAck(ctx context.Context, msg amqp.Delivery, handler MessageHandler) {
+func (c *RabbitMQConsumer) handleMessageAndAck(ctx context.Context, ch amqpChannel, queueName string, msg amqp.Delivery, handler MessageHandler) {
 	defer func() {
 		if r := recover(); r != nil {
 			log.Printf("PANIC recovered while processing message: %v", r)
 			log.Printf("Message body: %s", string(msg.Body))
-			msg.Nack(false, false) // Reject and don't requeue
+			c.retryOrDeadLetter(ctx, ch, queueName, msg, fmt.Errorf("panic: %v", r))
 		}
 	}()
 
@@ -26,13 +28,62 @@ func (c *RabbitMQConsumer) handleMessageAndAck(ctx context.Context, msg amqp.Del
 
 	if err := handler(ctx, msg.Body); err != nil {
 		log.Printf("Error processing message: %v", err)
-		msg.Nack(false, false) // Reject and don't requeue
+		c.retryOrDeadLetter(ctx, ch, queueName, msg, err)
 	} else {
 		msg.Ack(false)
 		log.Printf("Message processed successfully: message_id: %s, body: %s", msg.MessageId, string(msg.Body))
 	}
 }
 
+// handleBatchAndAck handles a batch with panic recovery. A handled batch is acked at once
+// with a multiple ack; on a *BatchError only the failed messages are retried.
+func (c *RabbitMQConsumer) handleBatchAndAck(ctx context.Context, ch amqpChannel, queueName string, batch []amqp.Delivery, handler BatchMessageHandler) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("PANIC recovered while processing batch of %d messages: %v", len(batch), r)
+			for _, msg := range batch {
+				c.retryOrDeadLetter(ctx, ch, queueName, msg, fmt.Errorf("panic: %v", r))
+			}
+		}
+	}()
+
+	payloads := make([][]byte, len(batch))
+	for i, msg := range batch {
+		payloads[i] = msg.Body
+	}
+	log.Printf("Received batch of %d messages", len(batch))
+
+	err := handler(ctx, payloads)
+	if err == nil {
+		// Acks every message of the batch, earlier batches of the channel are already settled
+		if err := batch[len(batch)-1].Ack(true); err != nil {
+			log.Printf("Error acking batch of %d messages: %v", len(batch), err)
+			return
+		}
+		log.Printf("Batch of %d messages processed successfully", len(batch))
+		return
+	}
+
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		log.Printf("Error processing batch of %d messages: %v", len(batch), err)
+		for _, msg := range batch {
+			c.retryOrDeadLetter(ctx, ch, queueName, msg, err)
+		}
+		return
+	}
+
+	log.Printf("Error processing batch: %v", batchErr)
+	for i, msg := range batch {
+		if cause := batchErr.Failed[i]; cause != nil {
+			log.Printf("Error processing message %s of batch: %v", msg.MessageId, cause)
+			c.retryOrDeadLetter(ctx, ch, queueName, msg, cause)
+		} else {
+			msg.Ack(false)
+		}
+	}
+}
+
 // FactorialMessageHandler handles factorial calculation messages
 type FactorialMessageHandler struct {
 	factorialService      service.FactorialService
@@ -52,7 +103,35 @@ func NewFactorialMessageHandler(
 	maxRequestRepo repository.MaxRequestRepository,
 	currentCalculatedRepo repository.CurrentCalculatedRepository,
 ) MessageHandler {
-	handler := &FactorialMessageHandler{
+	return newFactorialMessageHandler(
+		factorialService, redisService, storage, repository, maxRequestRepo, currentCalculatedRepo,
+	).HandleRequestCalculateFactorial
+}
+
+// NewFactorialBatchMessageHandler creates a factorial message handler that collapses
+// a batch into a single max request number update
+func NewFactorialBatchMessageHandler(
+	factorialService service.FactorialService,
+	redisService service.RedisService,
+	storage service.StorageService,
+	repository repository.FactorialRepository,
+	maxRequestRepo repository.MaxRequestRepository,
+	currentCalculatedRepo repository.CurrentCalculatedRepository,
+) BatchMessageHandler {
+	return newFactorialMessageHandler(
+		factorialService, redisService, storage, repository, maxRequestRepo, currentCalculatedRepo,
+	).HandleRequestCalculateFactorialBatch
+}
+
+func newFactorialMessageHandler(
+	factorialService service.FactorialService,
+	redisService service.RedisService,
+	storage service.StorageService,
+	repository repository.FactorialRepository,
+	maxRequestRepo repository.MaxRequestRepository,
+	currentCalculatedRepo repository.CurrentCalculatedRepository,
+) *FactorialMessageHandler {
+	return &FactorialMessageHandler{
 		factorialService:      factorialService,
 		redisService:          redisService,
 		storage:               storage,
@@ -60,23 +139,133 @@ func NewFactorialMessageHandler(
 		maxRequestRepo:        maxRequestRepo,
 		currentCalculatedRepo: currentCalculatedRepo,
 	}
-	return handler.HandleRequestCalculateFactorial
 }
 
+// HandleRequestCalculateFactorial raises the max request number to the number or range of a message.
+// Enveloped and version 0 messages are both accepted; a message that cannot be decoded fails permanently.
 func (h *FactorialMessageHandler) HandleRequestCalculateFactorial(ctx context.Context, body []byte) error {
-	var message dto.FactorialMessage
+	envelope, err := dto.DecodeMessageEnvelope(body)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrPermanent, err)
+	}
+	log.Printf("Handling %s message: message_id: %s, schema_version: %d, request_id: %s, client_id: %s, priority: %d, traceparent: %s",
+		envelope.Type, envelope.MessageID, envelope.SchemaVersion, envelope.RequestID, envelope.ClientID, envelope.Priority, envelope.TraceParent)
 
-	if err := message.Unmarshal(body); err != nil {
-		return fmt.Errorf("failed to parse message: %w", err)
+	if envelope.Type == dto.MessageTypeCalculateRange {
+		return h.handleRequestCalculateRange(envelope)
+	}
+
+	message, err := envelope.FactorialMessage()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrPermanent, err)
 	}
 
 	log.Printf("Update max request number for number: %v", message.Number)
 
 	// Check if already calculated
-	rowAffected, err := h.maxRequestRepo.SetMaxNumberIfGreater(message.Number)
-	if rowAffected == 0 || err != nil {
-		return fmt.Errorf("max number %v is not greater than the current max number: %v - %v", message.Number, rowAffected, err)
+	rowAffected, err := h.setMaxNumberIfGreater(envelope.Priority, message.Number)
+	if err != nil {
+		return fmt.Errorf("failed to update max number to %v: %w", message.Number, err)
+	}
+	if rowAffected == 0 {
+		// Already covered by the calculator walk, nothing to retry
+		log.Printf("max number %v is not greater than the current max number", message.Number)
+	}
+
+	return nil
+}
+
+// handleRequestCalculateRange raises the max request number once for a whole range;
+// the calculator walks every number up to it, so [From, To] is covered
+func (h *FactorialMessageHandler) handleRequestCalculateRange(envelope *dto.MessageEnvelope) error {
+	message, err := envelope.FactorialRangeMessage()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrPermanent, err)
+	}
+
+	log.Printf("Update max request number for batch %s: [%v, %v]", message.BatchID, message.From, message.To)
+
+	rowAffected, err := h.setMaxNumberIfGreater(envelope.Priority, message.To)
+	if err != nil {
+		return fmt.Errorf("failed to update max number to %v for batch %s: %w", message.To, message.BatchID, err)
+	}
+	if rowAffected == 0 {
+		// Already covered by the calculator walk, nothing to retry
+		log.Printf("max number %v of batch %s is not greater than the current max number", message.To, message.BatchID)
+	}
+
+	return nil
+}
+
+// HandleRequestCalculateFactorialBatch raises the max request number of each priority once, to the
+// highest number requested with it by the batch. Messages that cannot be parsed fail permanently on
+// their own; a failed update fails every other message of its priority.
+func (h *FactorialMessageHandler) HandleRequestCalculateFactorialBatch(ctx context.Context, payloads [][]byte) error {
+	failed := make(map[int]error)
+	maxNumbers := make(map[int]int64)
+	priorities := make([]int, len(payloads))
+	for i, body := range payloads {
+		number, priority, err := requestedMaxNumber(body)
+		if err != nil {
+			failed[i] = fmt.Errorf("%w: %w", ErrPermanent, err)
+			continue
+		}
+		priorities[i] = priority
+		if maxNumber, ok := maxNumbers[priority]; !ok || number > maxNumber {
+			maxNumbers[priority] = number
+		}
+	}
+
+	for priority, maxNumber := range maxNumbers {
+		log.Printf("Update max request number of priority %d for batch of %d messages: %v", priority, len(payloads)-len(failed), maxNumber)
+
+		rowAffected, err := h.setMaxNumberIfGreater(priority, maxNumber)
+		if err != nil {
+			err = fmt.Errorf("failed to update max number of priority %d to %v: %w", priority, maxNumber, err)
+			for i := range payloads {
+				if _, ok := failed[i]; !ok && priorities[i] == priority {
+					failed[i] = err
+				}
+			}
+		} else if rowAffected == 0 {
+			// Already covered by the calculator walk, nothing to retry
+			log.Printf("max number %v is not greater than the current max number of priority %d", maxNumber, priority)
+		}
 	}
 
+	if len(failed) > 0 {
+		return &BatchError{Failed: failed}
+	}
 	return nil
 }
+
+// setMaxNumberIfGreater raises the max request number of a priority; the calculator serves
+// the high priority max number before the rest of its range
+func (h *FactorialMessageHandler) setMaxNumberIfGreater(priority int, number int64) (int64, error) {
+	if priority >= domain.PriorityHigh {
+		return h.maxRequestRepo.SetPriorityMaxNumberIfGreater(number)
+	}
+	return h.maxRequestRepo.SetMaxNumberIfGreater(number)
+}
+
+// requestedMaxNumber returns the highest number requested by a single or range message and its priority
+func requestedMaxNumber(body []byte) (int64, int, error) {
+	envelope, err := dto.DecodeMessageEnvelope(body)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if envelope.Type == dto.MessageTypeCalculateRange {
+		message, err := envelope.FactorialRangeMessage()
+		if err != nil {
+			return 0, 0, err
+		}
+		return message.To, envelope.Priority, nil
+	}
+
+	message, err := envelope.FactorialMessage()
+	if err != nil {
+		return 0, 0, err
+	}
+	return message.Number, envelope.Priority, nil
+}
@@ -0,0 +1,208 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"factorial-cal-services/pkg/domain"
+	"factorial-cal-services/pkg/dto"
+)
+
+// mockMaxRequestRepository records every max number update
+type mockMaxRequestRepository struct {
+	maxNumber         int64
+	priorityMaxNumber int64
+	updates           []int64
+	priorityUpdates   []int64
+	setError          error
+}
+
+func (m *mockMaxRequestRepository) GetMaxNumber() (int64, error) {
+	return m.maxNumber, nil
+}
+
+func (m *mockMaxRequestRepository) UpdateMaxNumber(maxNumber int64) error {
+	m.maxNumber = maxNumber
+	return nil
+}
+
+func (m *mockMaxRequestRepository) SetMaxNumberIfGreater(maxNumber int64) (int64, error) {
+	m.updates = append(m.updates, maxNumber)
+	if m.setError != nil {
+		return 0, m.setError
+	}
+	if maxNumber <= m.maxNumber {
+		return 0, nil
+	}
+	m.maxNumber = maxNumber
+	return 1, nil
+}
+
+func (m *mockMaxRequestRepository) GetPriorityMaxNumber() (int64, error) {
+	return m.priorityMaxNumber, nil
+}
+
+func (m *mockMaxRequestRepository) SetPriorityMaxNumberIfGreater(maxNumber int64) (int64, error) {
+	m.priorityUpdates = append(m.priorityUpdates, maxNumber)
+	if m.setError != nil {
+		return 0, m.setError
+	}
+	if maxNumber <= m.priorityMaxNumber {
+		return 0, nil
+	}
+	m.priorityMaxNumber = maxNumber
+	return 1, nil
+}
+
+func TestHandleRequestCalculateFactorialBatch(t *testing.T) {
+	repo := &mockMaxRequestRepository{}
+	handler := NewFactorialBatchMessageHandler(nil, nil, nil, nil, repo, nil)
+
+	payloads := [][]byte{
+		[]byte(`{"number": 10}`),
+		[]byte(`{"batch_id": "b-1", "from": 5, "to": 42}`),
+		[]byte(`{"number": 7}`),
+	}
+	if err := handler(context.Background(), payloads); err != nil {
+		t.Fatalf("Expected batch to succeed, got %v", err)
+	}
+	if len(repo.updates) != 1 || repo.updates[0] != 42 {
+		t.Errorf("Expected a single update to 42, got %v", repo.updates)
+	}
+}
+
+func TestHandleRequestCalculateFactorialBatch_PartialFailure(t *testing.T) {
+	repo := &mockMaxRequestRepository{}
+	handler := NewFactorialBatchMessageHandler(nil, nil, nil, nil, repo, nil)
+
+	payloads := [][]byte{
+		[]byte(`{"number": 10}`),
+		[]byte(`not json`),
+		[]byte(`{"number": 12}`),
+	}
+	err := handler(context.Background(), payloads)
+
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("Expected a BatchError, got %v", err)
+	}
+	if len(batchErr.Failed) != 1 || !errors.Is(batchErr.Failed[1], ErrPermanent) {
+		t.Errorf("Expected only message 1 to fail permanently, got %v", batchErr.Failed)
+	}
+	if len(repo.updates) != 1 || repo.updates[0] != 12 {
+		t.Errorf("Expected a single update to 12, got %v", repo.updates)
+	}
+}
+
+func TestHandleRequestCalculateFactorialBatch_UpdateFailure(t *testing.T) {
+	repo := &mockMaxRequestRepository{setError: errors.New("database error")}
+	handler := NewFactorialBatchMessageHandler(nil, nil, nil, nil, repo, nil)
+
+	payloads := [][]byte{
+		[]byte(`{"number": 10}`),
+		[]byte(`not json`),
+	}
+	err := handler(context.Background(), payloads)
+
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("Expected a BatchError, got %v", err)
+	}
+	if len(batchErr.Failed) != 2 {
+		t.Fatalf("Expected every message to fail, got %v", batchErr.Failed)
+	}
+	if errors.Is(batchErr.Failed[0], ErrPermanent) {
+		t.Error("Expected a failed update to be retried")
+	}
+	if !errors.Is(batchErr.Failed[1], ErrPermanent) {
+		t.Error("Expected an unparsable message to fail permanently")
+	}
+}
+
+func TestHandleRequestCalculateFactorial_Envelope(t *testing.T) {
+	envelope, err := dto.NewMessageEnvelope("msg-1", &dto.FactorialMessage{Number: 30})
+	if err != nil {
+		t.Fatalf("NewMessageEnvelope failed: %v", err)
+	}
+	envelope.RequestID = "req-1"
+	enveloped, err := envelope.Bytes()
+	if err != nil {
+		t.Fatalf("Failed to encode envelope: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		body      string
+		update    int64
+		permanent bool
+	}{
+		{name: "enveloped message", body: string(enveloped), update: 30},
+		{name: "version 0 message", body: `{"number": 20}`, update: 20},
+		{name: "version 0 range message", body: `{"batch_id": "b-1", "from": 5, "to": 40}`, update: 40},
+		{name: "unknown envelope field", body: `{"message_id":"m","schema_version":1,"type":"factorial.calculate","submitted_at":"2025-01-01T00:00:00Z","priority":0,"payload":{"number":1},"extra":1}`, permanent: true},
+		{name: "unknown payload field", body: `{"message_id":"m","schema_version":1,"type":"factorial.calculate","submitted_at":"2025-01-01T00:00:00Z","priority":0,"payload":{"number":1,"extra":1}}`, permanent: true},
+		{name: "payload of another type", body: `{"message_id":"m","schema_version":1,"type":"factorial.calculate_range","submitted_at":"2025-01-01T00:00:00Z","priority":0,"payload":{"number":1}}`, permanent: true},
+		{name: "unsupported version", body: `{"message_id":"m","schema_version":2,"type":"factorial.calculate","payload":{"number":1}}`, permanent: true},
+		{name: "missing message ID", body: `{"schema_version":1,"type":"factorial.calculate","payload":{"number":1}}`, permanent: true},
+		{name: "trailing data", body: string(enveloped) + `{}`, permanent: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockMaxRequestRepository{}
+			handler := NewFactorialMessageHandler(nil, nil, nil, nil, repo, nil)
+
+			err := handler(context.Background(), []byte(tt.body))
+			if tt.permanent {
+				if !errors.Is(err, ErrPermanent) {
+					t.Fatalf("Expected a permanent error, got %v", err)
+				}
+				if len(repo.updates) != 0 {
+					t.Errorf("Expected no update, got %v", repo.updates)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected message to be handled, got %v", err)
+			}
+			if len(repo.updates) != 1 || repo.updates[0] != tt.update {
+				t.Errorf("Expected a single update to %d, got %v", tt.update, repo.updates)
+			}
+		})
+	}
+}
+
+func TestHandleRequestCalculateFactorialBatch_Priorities(t *testing.T) {
+	priorityMessage := func(number int64) []byte {
+		envelope, err := dto.NewMessageEnvelope("msg", &dto.FactorialMessage{Number: number})
+		if err != nil {
+			t.Fatalf("NewMessageEnvelope failed: %v", err)
+		}
+		envelope.Priority = domain.PriorityHigh
+		body, err := envelope.Bytes()
+		if err != nil {
+			t.Fatalf("Failed to encode envelope: %v", err)
+		}
+		return body
+	}
+
+	repo := &mockMaxRequestRepository{}
+	handler := NewFactorialBatchMessageHandler(nil, nil, nil, nil, repo, nil)
+
+	payloads := [][]byte{
+		[]byte(`{"number": 5000}`),
+		priorityMessage(12),
+		priorityMessage(30),
+		[]byte(`{"number": 100}`),
+	}
+	if err := handler(context.Background(), payloads); err != nil {
+		t.Fatalf("Expected batch to succeed, got %v", err)
+	}
+	if len(repo.updates) != 1 || repo.updates[0] != 5000 {
+		t.Errorf("Expected a single normal update to 5000, got %v", repo.updates)
+	}
+	if len(repo.priorityUpdates) != 1 || repo.priorityUpdates[0] != 30 {
+		t.Errorf("Expected a single priority update to 30, got %v", repo.priorityUpdates)
+	}
+}
@@ -2,11 +2,31 @@ package consumer
 
 import (
 	"fmt"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
 )
 
-// setupQueues declares the main queue
-func (c *RabbitMQConsumer) setupQueues(queueName string) error {
-	_, err := c.channel.QueueDeclare(
+// retryExchangeName returns the exchange failed messages of queueName are republished to
+func retryExchangeName(queueName string) string {
+	return queueName + ".retry"
+}
+
+// retryQueueName returns the delay queue holding messages of queueName for delay
+func retryQueueName(queueName string, delay time.Duration) string {
+	return fmt.Sprintf("%s.retry.%d", queueName, delay.Milliseconds())
+}
+
+// deadLetterQueueName returns the queue messages of queueName end in once retries are exhausted
+func deadLetterQueueName(queueName string) string {
+	return queueName + ".dlq"
+}
+
+// setupQueues declares the main queue, the retry exchange with one TTL delay queue per
+// retry delay, and the dead-letter queue. Expired messages of a delay queue are
+// dead-lettered back to the main queue through the default exchange.
+func (c *RabbitMQConsumer) setupQueues(ch amqpChannel, queueName string) error {
+	_, err := ch.QueueDeclare(
 		queueName, // name
 		true,      // durable
 		false,     // delete when unused
@@ -17,5 +37,60 @@ func (c *RabbitMQConsumer) setupQueues(queueName string) error {
 	if err != nil {
 		return fmt.Errorf("failed to declare queue: %w", err)
 	}
+
+	err = ch.ExchangeDeclare(
+		retryExchangeName(queueName), // name
+		amqp.ExchangeDirect,          // kind
+		true,                         // durable
+		false,                        // auto-deleted
+		false,                        // internal
+		false,                        // no-wait
+		nil,                          // no arguments
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare retry exchange: %w", err)
+	}
+
+	for _, delay := range c.retryPolicy.Delays() {
+		name := retryQueueName(queueName, delay)
+		_, err = ch.QueueDeclare(
+			name,  // name
+			true,  // durable
+			false, // delete when unused
+			false, // exclusive
+			false, // no-wait
+			amqp.Table{
+				"x-message-ttl":             delay.Milliseconds(),
+				"x-dead-letter-exchange":    "",
+				"x-dead-letter-routing-key": queueName,
+			},
+		)
+		if err != nil {
+			return fmt.Errorf("failed to declare retry queue %s: %w", name, err)
+		}
+
+		err = ch.QueueBind(
+			name,                         // queue name
+			name,                         // routing key
+			retryExchangeName(queueName), // exchange
+			false,                        // no-wait
+			nil,                          // no arguments
+		)
+		if err != nil {
+			return fmt.Errorf("failed to bind retry queue %s: %w", name, err)
+		}
+	}
+
+	_, err = ch.QueueDeclare(
+		deadLetterQueueName(queueName), // name
+		true,                           // durable
+		false,                          // delete when unused
+		false,                          // exclusive
+		false,                          // no-wait
+		nil,                            // no arguments
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare dead-letter queue: %w", err)
+	}
 	return nil
 }
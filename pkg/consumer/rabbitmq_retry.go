@@ -0,0 +1,90 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const (
+	// headerAttempt counts how many times a message has failed
+	headerAttempt = "x-retry-attempt"
+	// headerLastError carries the error of the last failed attempt
+	headerLastError = "x-last-error"
+	// maxLastErrorLength keeps the error header well below the broker frame size
+	maxLastErrorLength = 1024
+)
+
+// retryOrDeadLetter republishes a failed message to the delay queue of its next attempt,
+// or to the dead-letter queue once the retry policy gives up, and acks the original.
+// The original is requeued when the republish is not confirmed so it is never lost.
+func (c *RabbitMQConsumer) retryOrDeadLetter(ctx context.Context, ch amqpChannel, queueName string, msg amqp.Delivery, cause error) {
+	attempt := attemptOf(msg.Headers) + 1
+
+	headers := amqp.Table{}
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	headers[headerAttempt] = int64(attempt)
+	headers[headerLastError] = truncate(cause.Error(), maxLastErrorLength)
+
+	exchange, routingKey := "", deadLetterQueueName(queueName)
+	if c.retryPolicy.ShouldRetry(attempt, cause) {
+		exchange, routingKey = retryExchangeName(queueName), retryQueueName(queueName, c.retryPolicy.Delay(attempt))
+	}
+
+	err := publishConfirmed(ctx, ch, exchange, routingKey, amqp.Publishing{
+		DeliveryMode: amqp.Persistent,
+		ContentType:  msg.ContentType,
+		MessageId:    msg.MessageId,
+		Headers:      headers,
+		Body:         msg.Body,
+	})
+	if err != nil {
+		log.Printf("Error republishing message %s to %s, requeueing: %v", msg.MessageId, routingKey, err)
+		msg.Nack(false, true)
+		return
+	}
+
+	log.Printf("Message %s failed attempt %d, republished to %s", msg.MessageId, attempt, routingKey)
+	msg.Ack(false)
+}
+
+// publishConfirmed publishes a message on ch and waits for the broker to confirm it
+func publishConfirmed(ctx context.Context, ch amqpChannel, exchange, routingKey string, msg amqp.Publishing) error {
+	confirmation, err := ch.PublishWithDeferredConfirmWithContext(ctx, exchange, routingKey, false, false, msg)
+	if err != nil {
+		return fmt.Errorf("failed to publish message: %w", err)
+	}
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to wait for publish confirmation: %w", err)
+	}
+	if !acked {
+		return fmt.Errorf("message was nacked by the broker")
+	}
+	return nil
+}
+
+// attemptOf returns the number of failed attempts recorded in the message headers
+func attemptOf(headers amqp.Table) int {
+	switch v := headers[headerAttempt].(type) {
+	case int:
+		return v
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	default:
+		return 0
+	}
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
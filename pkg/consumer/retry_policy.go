@@ -0,0 +1,71 @@
+package consumer
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	DefaultMaxAttempts = 5
+	DefaultBackoff     = 2 * time.Second
+	DefaultMaxBackoff  = time.Minute
+)
+
+// ErrPermanent marks handler errors that retrying cannot fix (e.g. malformed payloads);
+// such messages are dead-lettered on the first failure
+var ErrPermanent = errors.New("permanent failure")
+
+// RetryPolicy decides how often and how late a failed message is redelivered
+type RetryPolicy struct {
+	// MaxAttempts is the number of deliveries before a message is dead-lettered
+	MaxAttempts int
+	// Backoff is the delay before the first retry, doubled on each further retry
+	Backoff time.Duration
+	// MaxBackoff caps the delay between retries
+	MaxBackoff time.Duration
+}
+
+// NewRetryPolicy creates a retry policy, falling back to the defaults for non-positive values
+func NewRetryPolicy(maxAttempts int, backoff, maxBackoff time.Duration) RetryPolicy {
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+	if backoff <= 0 {
+		backoff = DefaultBackoff
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultMaxBackoff
+	}
+	return RetryPolicy{
+		MaxAttempts: maxAttempts,
+		Backoff:     backoff,
+		MaxBackoff:  max(maxBackoff, backoff),
+	}
+}
+
+// Delay returns the delay before redelivering a message that failed attempt times
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	delay := p.Backoff
+	for i := 1; i < attempt && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, p.MaxBackoff)
+}
+
+// Delays returns the distinct retry delays in increasing order
+func (p RetryPolicy) Delays() []time.Duration {
+	delays := make([]time.Duration, 0, p.MaxAttempts)
+	for attempt := 1; attempt < p.MaxAttempts; attempt++ {
+		delay := p.Delay(attempt)
+		if len(delays) > 0 && delays[len(delays)-1] == delay {
+			continue
+		}
+		delays = append(delays, delay)
+	}
+	return delays
+}
+
+// ShouldRetry reports whether a message that failed attempt times with err is redelivered
+func (p RetryPolicy) ShouldRetry(attempt int, err error) bool {
+	return attempt < p.MaxAttempts && !errors.Is(err, ErrPermanent)
+}
@@ -0,0 +1,78 @@
+package consumer
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	policy := NewRetryPolicy(6, time.Second, 5*time.Second)
+
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, want := range expected {
+		if got := policy.Delay(i + 1); got != want {
+			t.Errorf("Delay(%d): expected %v, got %v", i+1, want, got)
+		}
+	}
+
+	// Capped delays share a single delay queue
+	delays := policy.Delays()
+	if fmt.Sprint(delays) != fmt.Sprint([]time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second}) {
+		t.Errorf("Unexpected distinct delays: %v", delays)
+	}
+}
+
+func TestRetryPolicy_ShouldRetry(t *testing.T) {
+	policy := NewRetryPolicy(3, time.Second, time.Minute)
+	transient := errors.New("connection refused")
+	permanent := fmt.Errorf("%w: failed to parse message: %w", ErrPermanent, errors.New("invalid character"))
+
+	tests := []struct {
+		name     string
+		attempt  int
+		err      error
+		expected bool
+	}{
+		{name: "first transient failure", attempt: 1, err: transient, expected: true},
+		{name: "second transient failure", attempt: 2, err: transient, expected: true},
+		{name: "attempts exhausted", attempt: 3, err: transient, expected: false},
+		{name: "permanent failure", attempt: 1, err: permanent, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.ShouldRetry(tt.attempt, tt.err); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestNewRetryPolicy_Defaults(t *testing.T) {
+	policy := NewRetryPolicy(0, 0, 0)
+	if policy.MaxAttempts != DefaultMaxAttempts || policy.Backoff != DefaultBackoff || policy.MaxBackoff != DefaultMaxBackoff {
+		t.Errorf("Expected default policy, got %+v", policy)
+	}
+}
+
+func TestAttemptOf(t *testing.T) {
+	tests := []struct {
+		headers  amqp.Table
+		expected int
+	}{
+		{headers: nil, expected: 0},
+		{headers: amqp.Table{headerAttempt: int32(2)}, expected: 2},
+		{headers: amqp.Table{headerAttempt: int64(3)}, expected: 3},
+		{headers: amqp.Table{headerAttempt: "4"}, expected: 0},
+	}
+
+	for _, tt := range tests {
+		if got := attemptOf(tt.headers); got != tt.expected {
+			t.Errorf("attemptOf(%v): expected %d, got %d", tt.headers, tt.expected, got)
+		}
+	}
+}
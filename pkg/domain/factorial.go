@@ -12,17 +12,47 @@ const (
 	StatusFailed      = "failed"
 )
 
-// FactorialCalculation represents a factorial calculation record
+// Encodings of stored factorial objects
+const (
+	EncodingText   = "text"
+	EncodingGzip   = "gzip"
+	EncodingZstd   = "zstd"
+	EncodingBinary = "binary"
+)
+
+// FactorialCalculation represents a factorial calculation record.
+// Derived rows have no object of their own and are rebuilt from CheckpointNumber.
 type FactorialCalculation struct {
-	ID        int64     `gorm:"primaryKey;autoIncrement" json:"id"`
-	Number    int64     `gorm:"type:bigint;not null;uniqueIndex" json:"number"`
-	Status    string    `gorm:"type:varchar(20);not null;index" json:"status"`
-	S3Key     string    `gorm:"type:varchar(512);not null" json:"s3_key"`
-	Checksum  string    `gorm:"type:varchar(64)" json:"checksum,omitempty"`
-	Size      int64     `gorm:"type:bigint;default:0" json:"size,omitempty"`
-	Bucket    string    `gorm:"type:varchar(255);not null" json:"bucket"`
-	CreatedAt time.Time `gorm:"autoCreateTime;index" json:"created_at"`
-	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	ID               int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	Number           int64     `gorm:"type:bigint;not null;uniqueIndex" json:"number"`
+	Status           string    `gorm:"type:varchar(20);not null;index" json:"status"`
+	S3Key            string    `gorm:"type:varchar(512);not null" json:"s3_key"`
+	Checksum         string    `gorm:"type:varchar(64)" json:"checksum,omitempty"`
+	Size             int64     `gorm:"type:bigint;default:0" json:"size,omitempty"`
+	Encoding         string    `gorm:"type:varchar(16);not null;default:'text'" json:"encoding"`
+	StoredSize       int64     `gorm:"type:bigint;not null;default:0" json:"stored_size,omitempty"`
+	Bucket           string    `gorm:"type:varchar(255);not null" json:"bucket"`
+	Derived          bool      `gorm:"not null;default:false" json:"derived"`
+	CheckpointNumber int64     `gorm:"type:bigint" json:"checkpoint_number,omitempty"`
+	CreatedAt        time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+	UpdatedAt        time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// StoredObject describes a factorial result written to storage
+type StoredObject struct {
+	Key      string
+	Bucket   string
+	Encoding string
+	// Size is the size of the encoded object in bytes
+	Size int64
+}
+
+// NearestCheckpoint returns the number of the materialized factorial this row is read from
+func (c *FactorialCalculation) NearestCheckpoint() int64 {
+	if c.Derived {
+		return c.CheckpointNumber
+	}
+	return c.Number
 }
 
 // TableName specifies the table name for GORM
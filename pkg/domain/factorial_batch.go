@@ -0,0 +1,18 @@
+package domain
+
+import "time"
+
+// FactorialBatch represents a contiguous range of factorials submitted in one request
+type FactorialBatch struct {
+	ID         int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	BatchID    string    `gorm:"type:varchar(64);not null;uniqueIndex" json:"batch_id"`
+	FromNumber int64     `gorm:"type:bigint;not null" json:"from_number"`
+	ToNumber   int64     `gorm:"type:bigint;not null" json:"to_number"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (FactorialBatch) TableName() string {
+	return "factorial_batches"
+}
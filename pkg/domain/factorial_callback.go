@@ -0,0 +1,45 @@
+package domain
+
+import "time"
+
+// Status constants for webhook callbacks
+const (
+	CallbackStatusPending   = "pending"
+	CallbackStatusDelivered = "delivered"
+	CallbackStatusFailed    = "failed"
+)
+
+// FactorialCallback represents a webhook to fire once a factorial is done
+type FactorialCallback struct {
+	ID            int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	CallbackID    string    `gorm:"type:varchar(64);not null;uniqueIndex" json:"callback_id"`
+	Number        int64     `gorm:"type:bigint;not null;index" json:"number"`
+	URL           string    `gorm:"type:varchar(2048);not null" json:"url"`
+	Status        string    `gorm:"type:varchar(20);not null;index" json:"status"`
+	Attempts      int       `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt time.Time `gorm:"not null;index" json:"next_attempt_at"`
+	LastError     string    `gorm:"type:text" json:"last_error,omitempty"`
+	CreatedAt     time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (FactorialCallback) TableName() string {
+	return "factorial_callbacks"
+}
+
+// FactorialCallbackDelivery represents a single delivery attempt of a callback
+type FactorialCallbackDelivery struct {
+	ID         int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	CallbackID string    `gorm:"type:varchar(64);not null;index" json:"callback_id"`
+	Attempt    int       `gorm:"not null" json:"attempt"`
+	StatusCode int       `gorm:"not null;default:0" json:"status_code"`
+	Error      string    `gorm:"type:text" json:"error,omitempty"`
+	DurationMs int64     `gorm:"type:bigint;not null;default:0" json:"duration_ms"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName specifies the table name for GORM
+func (FactorialCallbackDelivery) TableName() string {
+	return "factorial_callback_deliveries"
+}
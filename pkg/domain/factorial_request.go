@@ -2,10 +2,17 @@ package domain
 
 import "time"
 
-// FactorialMaxRequestNumber represents the maximum requested factorial number
+// Request priorities; high priority requests are calculated before normal ones
+const (
+	PriorityNormal = 0
+	PriorityHigh   = 1
+)
+
+// FactorialMaxRequestNumber represents the maximum requested factorial number of a priority
 type FactorialMaxRequestNumber struct {
 	ID        int64     `gorm:"primaryKey;autoIncrement" json:"id"`
 	MaxNumber int64     `gorm:"type:bigint;not null;index" json:"max_number"`
+	Priority  int       `gorm:"not null;default:0;uniqueIndex" json:"priority"`
 	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
 }
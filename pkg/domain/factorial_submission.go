@@ -0,0 +1,32 @@
+package domain
+
+import "time"
+
+// Status constants for submissions
+const (
+	SubmissionStatusPending   = "pending"
+	SubmissionStatusPublished = "published"
+	SubmissionStatusFailed    = "failed"
+)
+
+// FactorialSubmission records a request accepted by the API. It is committed in the same
+// transaction as its outbox message, and the outbox relay moves it from pending to published,
+// or to failed once the message could not be published.
+type FactorialSubmission struct {
+	ID           int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	SubmissionID string     `gorm:"type:varchar(64);not null;uniqueIndex" json:"submission_id"`
+	Number       *int64     `gorm:"type:bigint" json:"number,omitempty"`
+	BatchID      string     `gorm:"type:varchar(64);not null;default:''" json:"batch_id,omitempty"`
+	ClientID     string     `gorm:"type:varchar(128);not null;default:''" json:"client_id,omitempty"`
+	Priority     int        `gorm:"not null;default:0" json:"priority"`
+	Status       string     `gorm:"type:varchar(20);not null;index" json:"status"`
+	LastError    string     `gorm:"type:text" json:"last_error,omitempty"`
+	PublishedAt  *time.Time `json:"published_at,omitempty"`
+	CreatedAt    time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (FactorialSubmission) TableName() string {
+	return "factorial_submissions"
+}
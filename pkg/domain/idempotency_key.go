@@ -0,0 +1,21 @@
+package domain
+
+import "time"
+
+// IdempotencyKey records the response of a submission made with an Idempotency-Key header,
+// so a retry of the same request within the window gets the same response without republishing
+type IdempotencyKey struct {
+	ID             int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	ClientID       string    `gorm:"type:varchar(128);not null;default:'';uniqueIndex:idx_idempotency_keys_client_id_key,priority:1" json:"client_id"`
+	Key            string    `gorm:"column:idempotency_key;type:varchar(255);not null;uniqueIndex:idx_idempotency_keys_client_id_key,priority:2" json:"idempotency_key"`
+	RequestHash    string    `gorm:"type:varchar(64);not null" json:"request_hash"`
+	ResponseStatus int       `gorm:"not null" json:"response_status"`
+	ResponseBody   []byte    `gorm:"not null" json:"response_body"`
+	ExpiresAt      time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt      time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName specifies the table name for GORM
+func (IdempotencyKey) TableName() string {
+	return "idempotency_keys"
+}
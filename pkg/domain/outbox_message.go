@@ -0,0 +1,24 @@
+package domain
+
+import "time"
+
+// OutboxMessage represents a queue message written in the same transaction as the submission
+// it belongs to. The outbox relay publishes it and sets SentAt; a message that keeps failing
+// is retried at NextAttemptAt and parked with FailedAt once its attempts are exhausted.
+type OutboxMessage struct {
+	ID            int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	SubmissionID  string     `gorm:"type:varchar(64);not null;default:''" json:"submission_id"`
+	Queue         string     `gorm:"type:varchar(255);not null" json:"queue"`
+	Payload       []byte     `gorm:"not null" json:"payload"`
+	Attempts      int        `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt time.Time  `gorm:"not null" json:"next_attempt_at"`
+	LastError     string     `gorm:"type:text" json:"last_error,omitempty"`
+	SentAt        *time.Time `gorm:"index" json:"sent_at,omitempty"`
+	FailedAt      *time.Time `json:"failed_at,omitempty"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName specifies the table name for GORM
+func (OutboxMessage) TableName() string {
+	return "outbox_messages"
+}
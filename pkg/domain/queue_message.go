@@ -0,0 +1,20 @@
+package domain
+
+import "time"
+
+// QueueMessage represents a message of the Postgres-backed queue.
+// A consumer leases a message by moving AvailableAt past the lease; it is deleted once handled.
+type QueueMessage struct {
+	ID          int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	Queue       string    `gorm:"type:varchar(255);not null;index:idx_queue_messages_queue_available_at,priority:1" json:"queue"`
+	Payload     []byte    `gorm:"not null" json:"payload"`
+	Attempts    int       `gorm:"not null;default:0" json:"attempts"`
+	LastError   string    `gorm:"type:text" json:"last_error,omitempty"`
+	AvailableAt time.Time `gorm:"not null;index:idx_queue_messages_queue_available_at,priority:2" json:"available_at"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName specifies the table name for GORM
+func (QueueMessage) TableName() string {
+	return "queue_messages"
+}
@@ -0,0 +1,52 @@
+package domain
+
+import "time"
+
+// Status constants for scrub runs
+const (
+	ScrubStatusRunning   = "running"
+	ScrubStatusCompleted = "completed"
+	ScrubStatusAborted   = "aborted"
+)
+
+// Kinds of scrub findings
+const (
+	ScrubFindingMissing          = "missing"
+	ScrubFindingSizeMismatch     = "size_mismatch"
+	ScrubFindingChecksumMismatch = "checksum_mismatch"
+	ScrubFindingInconsistent     = "inconsistent"
+)
+
+// ScrubReport records a pass of the integrity scrubber over the stored factorials
+type ScrubReport struct {
+	ID           int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Status       string     `gorm:"type:varchar(20);not null;index" json:"status"`
+	Checked      int64      `gorm:"type:bigint;not null;default:0" json:"checked"`
+	BytesRead    int64      `gorm:"type:bigint;not null;default:0" json:"bytes_read"`
+	PairsChecked int64      `gorm:"type:bigint;not null;default:0" json:"pairs_checked"`
+	Findings     int64      `gorm:"type:bigint;not null;default:0" json:"findings"`
+	LastNumber   int64      `gorm:"type:bigint;not null;default:0" json:"last_number"`
+	Error        string     `gorm:"type:text" json:"error,omitempty"`
+	StartedAt    time.Time  `gorm:"not null" json:"started_at"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+}
+
+// TableName specifies the table name for GORM
+func (ScrubReport) TableName() string {
+	return "scrub_reports"
+}
+
+// ScrubFinding records a stored factorial that failed a check of a scrub run
+type ScrubFinding struct {
+	ID        int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	ReportID  int64     `gorm:"type:bigint;not null;index" json:"report_id"`
+	Number    int64     `gorm:"type:bigint;not null;index" json:"number"`
+	Kind      string    `gorm:"type:varchar(32);not null" json:"kind"`
+	Detail    string    `gorm:"type:text" json:"detail,omitempty"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName specifies the table name for GORM
+func (ScrubFinding) TableName() string {
+	return "scrub_findings"
+}
@@ -1,6 +1,10 @@
 package dto
 
-import "time"
+import (
+	"time"
+
+	"factorial-cal-services/pkg/domain"
+)
 
 // APIResponse represents the standard API response wrapper
 type APIResponse struct {
@@ -12,13 +16,73 @@ type APIResponse struct {
 
 // CalculateRequest represents the request to calculate a factorial
 type CalculateRequest struct {
-	Number int64 `json:"number" binding:"required"`
+	Number      int64  `json:"number" binding:"required"`
+	CallbackURL string `json:"callback_url,omitempty"`
+}
+
+// RangeRequest represents the request to calculate every factorial in [From, To]
+type RangeRequest struct {
+	From *int64 `json:"from" binding:"required"`
+	To   *int64 `json:"to" binding:"required"`
+}
+
+// RangeResponseData represents the data payload for range submit response
+type RangeResponseData struct {
+	BatchID      string `json:"batch_id"`
+	SubmissionID string `json:"submission_id,omitempty"`
+	From         int64  `json:"from"`
+	To           int64  `json:"to"`
+	Message      string `json:"message,omitempty"`
+}
+
+// RangeStatusResponseData represents the progress of a range batch
+type RangeStatusResponseData struct {
+	BatchID string `json:"batch_id"`
+	From    int64  `json:"from"`
+	To      int64  `json:"to"`
+	Total   int64  `json:"total"`
+	Done    int64  `json:"done"`
+	Status  string `json:"status"`
+}
+
+// BatchGetRequest represents the request to look up many factorial results at once
+type BatchGetRequest struct {
+	Numbers []int64 `json:"numbers" binding:"required"`
+}
+
+// BatchGetResult represents the lookup outcome of a single number
+type BatchGetResult struct {
+	Number int64 `json:"number"`
+	// Status is done, calculating, not_found or error
+	Status          string `json:"status"`
+	FactorialResult string `json:"factorial_result,omitempty"`
+	// Error is the reason the lookup failed when Status is error
+	Error string `json:"error,omitempty"`
+}
+
+// BatchGetResponseData represents the data payload for batch lookup response
+type BatchGetResponseData struct {
+	Results []BatchGetResult `json:"results"`
 }
 
 // CalculateResponseData represents the data payload for calculate response
 type CalculateResponseData struct {
-	Number  int64  `json:"number,omitempty"`
-	Message string `json:"message,omitempty"`
+	Number       int64  `json:"number,omitempty"`
+	SubmissionID string `json:"submission_id,omitempty"`
+	CallbackID   string `json:"callback_id,omitempty"`
+	Message      string `json:"message,omitempty"`
+}
+
+// SubmissionResponseData represents whether a submission was published to the queue
+type SubmissionResponseData struct {
+	SubmissionID string     `json:"submission_id"`
+	Number       *int64     `json:"number,omitempty"`
+	BatchID      string     `json:"batch_id,omitempty"`
+	Status       string     `json:"status"`
+	LastError    string     `json:"last_error,omitempty"`
+	PublishedAt  *time.Time `json:"published_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
 }
 
 // ResultResponseData represents the data payload for result response
@@ -29,14 +93,87 @@ type ResultResponseData struct {
 
 // MetadataResponseData represents the data payload for metadata response
 type MetadataResponseData struct {
-	ID        int64     `json:"id"`
-	Number    int64     `json:"number"`
-	S3Key     string    `json:"s3_key,omitempty"`
-	Checksum  string    `json:"checksum,omitempty"`
-	Status    string    `json:"status"`
-	Bucket    string    `json:"bucket"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID               int64         `json:"id"`
+	Number           int64         `json:"number"`
+	S3Key            string        `json:"s3_key,omitempty"`
+	Checksum         string        `json:"checksum,omitempty"`
+	Encoding         string        `json:"encoding,omitempty"`
+	StoredSize       int64         `json:"stored_size,omitempty"`
+	Status           string        `json:"status"`
+	Bucket           string        `json:"bucket"`
+	Derived          bool          `json:"derived"`
+	CheckpointNumber int64         `json:"checkpoint_number,omitempty"`
+	Progress         *ProgressData `json:"progress,omitempty"`
+	CreatedAt        time.Time     `json:"created_at"`
+	UpdatedAt        time.Time     `json:"updated_at"`
+}
+
+// ProgressData represents the calculator progress towards a number that is not done yet
+type ProgressData struct {
+	Number                int64      `json:"number"`
+	Current               int64      `json:"current"`
+	Target                int64      `json:"target"`
+	Remaining             int64      `json:"remaining"`
+	Queued                bool       `json:"queued"`
+	ThroughputPerSecond   float64    `json:"throughput_per_second"`
+	WindowSeconds         int64      `json:"window_seconds"`
+	EstimatedCompletionAt *time.Time `json:"estimated_completion_at,omitempty"`
+}
+
+// NewMetadataResponseData maps a calculation record to its metadata payload
+func NewMetadataResponseData(calc *domain.FactorialCalculation) MetadataResponseData {
+	return MetadataResponseData{
+		ID:               calc.ID,
+		Number:           calc.Number,
+		S3Key:            calc.S3Key,
+		Checksum:         calc.Checksum,
+		Encoding:         calc.Encoding,
+		StoredSize:       calc.StoredSize,
+		Status:           calc.Status,
+		CreatedAt:        calc.CreatedAt,
+		UpdatedAt:        calc.UpdatedAt,
+		Bucket:           calc.Bucket,
+		Derived:          calc.Derived,
+		CheckpointNumber: calc.CheckpointNumber,
+	}
+}
+
+// CallbackResponseData represents a webhook callback and its delivery log
+type CallbackResponseData struct {
+	CallbackID    string                 `json:"callback_id"`
+	Number        int64                  `json:"number"`
+	URL           string                 `json:"url"`
+	Status        string                 `json:"status"`
+	Attempts      int                    `json:"attempts"`
+	NextAttemptAt time.Time              `json:"next_attempt_at"`
+	LastError     string                 `json:"last_error,omitempty"`
+	Deliveries    []CallbackDeliveryData `json:"deliveries"`
+	CreatedAt     time.Time              `json:"created_at"`
+	UpdatedAt     time.Time              `json:"updated_at"`
+}
+
+// CallbackDeliveryData represents a single delivery attempt of a webhook callback
+type CallbackDeliveryData struct {
+	Attempt    int       `json:"attempt"`
+	StatusCode int       `json:"status_code"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// WebhookPayload represents the body POSTed to a callback URL once a factorial is done
+type WebhookPayload struct {
+	Event      string               `json:"event"`
+	CallbackID string               `json:"callback_id"`
+	Data       MetadataResponseData `json:"data"`
+}
+
+// ProgressEventData represents a progress event pushed while a factorial is calculated
+type ProgressEventData struct {
+	Number  int64  `json:"number"`
+	Current int64  `json:"current"`
+	Target  int64  `json:"target"`
+	Status  string `json:"status"`
 }
 
 // Legacy DTOs for backward compatibility (deprecated, use APIResponse wrapper)
@@ -1,18 +1,32 @@
 package dto
 
-import (
-	"encoding/json"
-	"fmt"
-)
+import "encoding/json"
 
+// FactorialMessage requests a single factorial; it is the payload of a factorial.calculate message
 type FactorialMessage struct {
-	Number int64 `json:"number"`
+	Number      int64  `json:"number"`
+	CallbackURL string `json:"callback_url,omitempty"`
 }
 
-func (m *FactorialMessage) Bytes() []byte {
-	return fmt.Appendf(nil, "{\"number\": %d}", m.Number)
+func (m *FactorialMessage) Unmarshal(data []byte) error {
+	return json.Unmarshal(data, m)
 }
 
-func (m *FactorialMessage) Unmarshal(data []byte) error {
+// FactorialRangeMessage requests every factorial in [From, To] as a single message
+type FactorialRangeMessage struct {
+	BatchID string `json:"batch_id"`
+	From    int64  `json:"from"`
+	To      int64  `json:"to"`
+}
+
+func (m *FactorialRangeMessage) Unmarshal(data []byte) error {
 	return json.Unmarshal(data, m)
 }
+
+// IsRangeMessage reports whether the payload carries a range request
+func IsRangeMessage(data []byte) bool {
+	var probe struct {
+		BatchID *string `json:"batch_id"`
+	}
+	return json.Unmarshal(data, &probe) == nil && probe.BatchID != nil
+}
@@ -0,0 +1,169 @@
+package dto
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"factorial-cal-services/pkg/domain"
+)
+
+const (
+	// MessageSchemaVersion is the envelope version written by this build.
+	// Version 0 is the bare payload published before the envelope existed.
+	MessageSchemaVersion = 1
+
+	MessageTypeCalculate      = "factorial.calculate"
+	MessageTypeCalculateRange = "factorial.calculate_range"
+)
+
+// priorityQueueSuffix names the lane of high priority messages
+const priorityQueueSuffix = ".priority"
+
+var errUnknownMessageType = errors.New("unknown message type")
+
+// MessageEnvelope wraps every queue message with the metadata needed to deduplicate,
+// trace and audit it. Payload holds a FactorialMessage or a FactorialRangeMessage depending on Type.
+type MessageEnvelope struct {
+	MessageID     string `json:"message_id"`
+	SchemaVersion int    `json:"schema_version"`
+	Type          string `json:"type"`
+	// RequestID is the ID of the HTTP request the message was submitted by
+	RequestID   string    `json:"request_id,omitempty"`
+	SubmittedAt time.Time `json:"submitted_at"`
+	ClientID    string    `json:"client_id,omitempty"`
+	// Priority is domain.PriorityNormal or domain.PriorityHigh
+	Priority int `json:"priority"`
+	// TraceParent and TraceState carry the W3C trace context of the submission
+	TraceParent string          `json:"traceparent,omitempty"`
+	TraceState  string          `json:"tracestate,omitempty"`
+	Payload     json.RawMessage `json:"payload"`
+}
+
+// NewMessageEnvelope wraps a *FactorialMessage or *FactorialRangeMessage in an envelope
+// of the current schema version
+func NewMessageEnvelope(messageID string, payload any) (*MessageEnvelope, error) {
+	var messageType string
+	switch payload.(type) {
+	case *FactorialMessage:
+		messageType = MessageTypeCalculate
+	case *FactorialRangeMessage:
+		messageType = MessageTypeCalculateRange
+	default:
+		return nil, fmt.Errorf("%w: %T", errUnknownMessageType, payload)
+	}
+
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode payload: %w", err)
+	}
+
+	return &MessageEnvelope{
+		MessageID:     messageID,
+		SchemaVersion: MessageSchemaVersion,
+		Type:          messageType,
+		SubmittedAt:   time.Now().UTC(),
+		Payload:       raw,
+	}, nil
+}
+
+// Bytes encodes the envelope as published to the queue
+func (e *MessageEnvelope) Bytes() ([]byte, error) {
+	return json.Marshal(e)
+}
+
+// PriorityQueueName returns the lane of queueName messages of priority are published to.
+// High priority messages have their own queue so they are not consumed behind normal ones.
+func PriorityQueueName(queueName string, priority int) string {
+	if priority >= domain.PriorityHigh {
+		return queueName + priorityQueueSuffix
+	}
+	return queueName
+}
+
+// DecodeMessageEnvelope decodes a queue message. Enveloped messages are decoded strictly:
+// unknown fields, trailing data, unsupported versions and missing required fields are rejected.
+// A version 0 message is returned in an envelope without metadata.
+func DecodeMessageEnvelope(data []byte) (*MessageEnvelope, error) {
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("failed to decode message: %w", err)
+	}
+	if _, ok := probe["schema_version"]; !ok {
+		messageType := MessageTypeCalculate
+		if IsRangeMessage(data) {
+			messageType = MessageTypeCalculateRange
+		}
+		return &MessageEnvelope{Type: messageType, Payload: json.RawMessage(data)}, nil
+	}
+
+	var envelope MessageEnvelope
+	if err := decodeStrict(data, &envelope); err != nil {
+		return nil, fmt.Errorf("failed to decode message envelope: %w", err)
+	}
+	if envelope.SchemaVersion != MessageSchemaVersion {
+		return nil, fmt.Errorf("unsupported message schema version %d", envelope.SchemaVersion)
+	}
+	if envelope.MessageID == "" {
+		return nil, errors.New("message envelope has no message_id")
+	}
+	if envelope.Type != MessageTypeCalculate && envelope.Type != MessageTypeCalculateRange {
+		return nil, fmt.Errorf("%w: %q", errUnknownMessageType, envelope.Type)
+	}
+	if len(envelope.Payload) == 0 {
+		return nil, errors.New("message envelope has no payload")
+	}
+	return &envelope, nil
+}
+
+// PeekMessageID returns the message ID of an enveloped message, or an empty string
+// for a version 0 or undecodable message
+func PeekMessageID(data []byte) string {
+	var probe struct {
+		MessageID string `json:"message_id"`
+	}
+	if json.Unmarshal(data, &probe) != nil {
+		return ""
+	}
+	return probe.MessageID
+}
+
+// FactorialMessage decodes the payload of a factorial.calculate message
+func (e *MessageEnvelope) FactorialMessage() (*FactorialMessage, error) {
+	if e.Type != MessageTypeCalculate {
+		return nil, fmt.Errorf("message of type %s is not a %s message", e.Type, MessageTypeCalculate)
+	}
+	var message FactorialMessage
+	if err := decodeStrict(e.Payload, &message); err != nil {
+		return nil, fmt.Errorf("failed to parse message: %w", err)
+	}
+	return &message, nil
+}
+
+// FactorialRangeMessage decodes the payload of a factorial.calculate_range message
+func (e *MessageEnvelope) FactorialRangeMessage() (*FactorialRangeMessage, error) {
+	if e.Type != MessageTypeCalculateRange {
+		return nil, fmt.Errorf("message of type %s is not a %s message", e.Type, MessageTypeCalculateRange)
+	}
+	var message FactorialRangeMessage
+	if err := decodeStrict(e.Payload, &message); err != nil {
+		return nil, fmt.Errorf("failed to parse range message: %w", err)
+	}
+	return &message, nil
+}
+
+// decodeStrict decodes a single JSON value, rejecting unknown fields and trailing data
+func decodeStrict(data []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return errors.New("unexpected data after message")
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"factorial-cal-services/pkg/dto"
+	"factorial-cal-services/pkg/utils/patterns"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// MaxBatchGetSize is the maximum amount of numbers accepted by one batch lookup
+	MaxBatchGetSize = 100
+	// batchGetConcurrency bounds the lookups running at the same time for one request
+	batchGetConcurrency = 8
+)
+
+// BatchGetResults godoc
+// @Summary      Get many factorial results
+// @Description  Look up the factorial result of many numbers in one call. Each number is resolved via cache, database and storage and reported as done, calculating or not_found, or as error with the reason when its lookup failed.
+// @Tags         factorial
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.BatchGetRequest true "Batch Lookup Request"
+// @Success      200  {object}  dto.APIResponse{data=dto.BatchGetResponseData} "Results retrieved successfully"
+// @Failure      400  {object}  dto.APIResponse{data=dto.ErrorResponse} "Invalid request - empty, too large or invalid number"
+// @Router       /factorial/batch-get [post]
+// @Example      200 {"code":200,"status":"ok","message":"done","data":{"results":[{"number":5,"status":"done","factorial_result":"120"},{"number":9000,"status":"calculating"},{"number":9999,"status":"not_found"},{"number":42,"status":"error","error":"failed to retrieve result from storage"}]}}
+// @Example      400 {"code":400,"status":"fail","message":"batch size exceeds maximum of 100","data":{"error":"fail","message":"batch size exceeds maximum of 100"}}
+func (h *FactorialHandler) BatchGetResults(c *gin.Context) {
+	var req dto.BatchGetRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		sendErrorResponse(c, http.StatusBadRequest, "fail", err.Error())
+		return
+	}
+
+	if len(req.Numbers) == 0 {
+		sendErrorResponse(c, http.StatusBadRequest, "fail", "numbers must not be empty")
+		return
+	}
+	if len(req.Numbers) > MaxBatchGetSize {
+		sendErrorResponse(c, http.StatusBadRequest, "fail", fmt.Sprintf("batch size exceeds maximum of %d", MaxBatchGetSize))
+		return
+	}
+
+	for _, number := range req.Numbers {
+		if _, err := h.factorialService.ValidateNumber(fmt.Sprintf("%d", number)); err != nil {
+			sendErrorResponse(c, http.StatusBadRequest, "fail", fmt.Sprintf("number %d: %v", number, err))
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	results := make([]dto.BatchGetResult, len(req.Numbers))
+	semaphore := patterns.NewSemaphore(batchGetConcurrency)
+	for i, number := range req.Numbers {
+		semaphore.Submit(func() error {
+			status, result, err := h.lookupResult(ctx, number)
+			results[i] = dto.BatchGetResult{
+				Number:          number,
+				Status:          status,
+				FactorialResult: result,
+			}
+			if err != nil {
+				results[i].Status = resultError
+				results[i].Error = err.Error()
+			}
+			return nil
+		})
+	}
+	semaphore.Wait()
+
+	sendAPIResponse(c, http.StatusOK, "ok", "done", dto.BatchGetResponseData{
+		Results: results,
+	})
+}
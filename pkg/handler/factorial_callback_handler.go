@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"factorial-cal-services/pkg/domain"
+	"factorial-cal-services/pkg/dto"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// newCallback builds a pending callback fired once number is done, identified by a new public ID
+func newCallback(number int64, callbackURL string) (*domain.FactorialCallback, error) {
+	callbackID, err := newPublicID()
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.FactorialCallback{
+		CallbackID:    callbackID,
+		Number:        number,
+		URL:           callbackURL,
+		Status:        domain.CallbackStatusPending,
+		NextAttemptAt: time.Now(),
+	}, nil
+}
+
+// GetCallback godoc
+// @Summary      Get webhook callback delivery log
+// @Description  Get the state of a webhook callback and every delivery attempt made so far
+// @Tags         factorial
+// @Produce      json
+// @Param        callback_id path string true "Callback ID"
+// @Success      200  {object}  dto.APIResponse{data=dto.CallbackResponseData} "Callback retrieved successfully"
+// @Failure      404  {object}  dto.APIResponse{data=dto.ErrorResponse} "Callback not found"
+// @Failure      500  {object}  dto.APIResponse{data=dto.ErrorResponse} "Internal server error - database failure"
+// @Router       /factorial/callbacks/{callback_id} [get]
+// @Example      200 {"code":200,"status":"ok","message":"delivered","data":{"callback_id":"9f86d081884c7d659a2feaa0c55ad015","number":10,"url":"https://example.com/hooks/factorial","status":"delivered","attempts":2,"next_attempt_at":"2025-01-01T00:00:02Z","deliveries":[{"attempt":1,"status_code":503,"error":"unexpected status code 503","duration_ms":41,"created_at":"2025-01-01T00:00:00Z"},{"attempt":2,"status_code":200,"duration_ms":37,"created_at":"2025-01-01T00:00:02Z"}],"created_at":"2025-01-01T00:00:00Z","updated_at":"2025-01-01T00:00:02Z"}}
+// @Example      404 {"code":404,"status":"fail","message":"callback not found","data":{"error":"fail","message":"callback not found"}}
+func (h *FactorialHandler) GetCallback(c *gin.Context) {
+	callbackID := c.Param("callback_id")
+
+	callback, err := h.callbackRepo.FindByCallbackID(callbackID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		sendErrorResponse(c, http.StatusNotFound, "fail", "callback not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Error finding callback: %v", err)
+		sendErrorResponse(c, http.StatusInternalServerError, "fail", "Failed to retrieve callback")
+		return
+	}
+
+	deliveries, err := h.callbackRepo.ListDeliveries(callbackID)
+	if err != nil {
+		log.Printf("Error listing callback deliveries: %v", err)
+		sendErrorResponse(c, http.StatusInternalServerError, "fail", "Failed to retrieve callback")
+		return
+	}
+
+	data := dto.CallbackResponseData{
+		CallbackID:    callback.CallbackID,
+		Number:        callback.Number,
+		URL:           callback.URL,
+		Status:        callback.Status,
+		Attempts:      callback.Attempts,
+		NextAttemptAt: callback.NextAttemptAt,
+		LastError:     callback.LastError,
+		Deliveries:    make([]dto.CallbackDeliveryData, 0, len(deliveries)),
+		CreatedAt:     callback.CreatedAt,
+		UpdatedAt:     callback.UpdatedAt,
+	}
+	for _, delivery := range deliveries {
+		data.Deliveries = append(data.Deliveries, dto.CallbackDeliveryData{
+			Attempt:    delivery.Attempt,
+			StatusCode: delivery.StatusCode,
+			Error:      delivery.Error,
+			DurationMs: delivery.DurationMs,
+			CreatedAt:  delivery.CreatedAt,
+		})
+	}
+
+	sendAPIResponse(c, http.StatusOK, "ok", callback.Status, data)
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"factorial-cal-services/pkg/domain"
+	"factorial-cal-services/pkg/dto"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// sseEventInterval is how often the calculator progress is checked for a stream
+	sseEventInterval = time.Second
+	// sseMaxDuration closes streams of numbers that never complete (e.g. never submitted)
+	sseMaxDuration = 30 * time.Minute
+)
+
+// StreamEvents godoc
+// @Summary      Stream factorial calculation progress
+// @Description  Push Server-Sent Events while the calculator advances: "progress" events carry the calculator position and the target number, a final "done" event carries the calculation metadata.
+// @Tags         factorial
+// @Produce      text/event-stream
+// @Param        number path string true "Number"
+// @Success      200  {object}  dto.ProgressEventData "progress event payload (the final done event carries dto.MetadataResponseData)"
+// @Failure      400  {object}  dto.APIResponse{data=dto.ErrorResponse} "Invalid number format"
+// @Router       /factorial/{number}/events [get]
+func (h *FactorialHandler) StreamEvents(c *gin.Context) {
+	number := c.Param("number")
+
+	// Validate number format
+	numberInt, err := h.factorialService.ValidateNumber(number)
+	if err != nil {
+		sendErrorResponse(c, http.StatusBadRequest, "fail", err.Error())
+		return
+	}
+
+	// The request context is also cancelled when the server shuts down; clients reconnect
+	ctx, cancel := context.WithTimeout(c.Request.Context(), sseMaxDuration)
+	defer cancel()
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Disable proxy buffering
+
+	ticker := time.NewTicker(sseEventInterval)
+	defer ticker.Stop()
+
+	lastCurrent := int64(-1)
+	c.Stream(func(w io.Writer) bool {
+		if h.pushProgress(c, numberInt, &lastCurrent) {
+			return false
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+			return true
+		}
+	})
+}
+
+// pushProgress sends a progress event when the calculator moved since lastCurrent.
+// It returns true once the stream is finished (done or failed).
+func (h *FactorialHandler) pushProgress(c *gin.Context, number int64, lastCurrent *int64) bool {
+	calc, err := h.factCalRepo.FindByNumber(number)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("Error finding calculation: %v", err)
+		c.SSEvent("error", dto.ErrorResponse{Error: "fail", Message: "Failed to retrieve calculation"})
+		return true
+	}
+
+	if calc != nil && calc.Status == domain.StatusDone {
+		c.SSEvent(domain.StatusDone, dto.NewMetadataResponseData(calc))
+		return true
+	}
+
+	current, err := h.currentCalcRepo.GetCurrentNumber()
+	if err != nil {
+		log.Printf("Error getting current number: %v", err)
+		c.SSEvent("error", dto.ErrorResponse{Error: "fail", Message: "Failed to retrieve progress"})
+		return true
+	}
+
+	if current != *lastCurrent {
+		*lastCurrent = current
+		status := resultNotFound
+		if calc != nil {
+			status = calc.Status
+		}
+		c.SSEvent("progress", dto.ProgressEventData{
+			Number:  number,
+			Current: current,
+			Target:  number,
+			Status:  status,
+		})
+	}
+	return false
+}
@@ -2,27 +2,54 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"time"
 
 	"factorial-cal-services/pkg/domain"
 	"factorial-cal-services/pkg/dto"
-	"factorial-cal-services/pkg/producer"
 	"factorial-cal-services/pkg/repository"
 	"factorial-cal-services/pkg/service"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// resultNotFound is the lookup status of a number that was never submitted
+	resultNotFound = "not_found"
+	// resultError is the batch lookup status of a number whose lookup failed, the item's
+	// error field tells why
+	resultError = "error"
+	// maxResultWait caps how long GetResult blocks for the wait query parameter
+	maxResultWait = 60 * time.Second
+)
+
+var (
+	errFindCalculation = errors.New("failed to retrieve calculation")
+	errResolveResult   = errors.New("failed to retrieve result from storage")
 )
 
 // FactorialHandler handles factorial calculation HTTP requests
 type FactorialHandler struct {
-	factorialService service.FactorialService
-	redisService     service.RedisService
-	storage          service.StorageService
-	factCalRepo      repository.FactorialRepository
-	producer         producer.Producer
-	queueName        string
+	factorialService  service.FactorialService
+	redisService      service.RedisService
+	storage           service.StorageService
+	factCalRepo       repository.FactorialRepository
+	currentCalcRepo   repository.CurrentCalculatedRepository
+	batchRepo         repository.BatchRepository
+	callbackRepo      repository.CallbackRepository
+	notifier          service.CompletionNotifier
+	progressService   service.ProgressService
+	priorityPolicy    service.PriorityPolicy
+	outboxRepo        repository.OutboxRepository
+	submissionRepo    repository.SubmissionRepository
+	idempotencyRepo   repository.IdempotencyRepository
+	idempotencyWindow time.Duration
+	queueName         string
 }
 
 // NewFactorialHandler creates a new factorial handler
@@ -31,32 +58,57 @@ func NewFactorialHandler(
 	redisService service.RedisService,
 	storage service.StorageService,
 	repository repository.FactorialRepository,
-	producer producer.Producer,
+	currentCalcRepo repository.CurrentCalculatedRepository,
+	batchRepo repository.BatchRepository,
+	callbackRepo repository.CallbackRepository,
+	notifier service.CompletionNotifier,
+	progressService service.ProgressService,
+	priorityPolicy service.PriorityPolicy,
+	outboxRepo repository.OutboxRepository,
+	submissionRepo repository.SubmissionRepository,
+	idempotencyRepo repository.IdempotencyRepository,
+	idempotencyWindow time.Duration,
 	queueName string,
 ) *FactorialHandler {
 	return &FactorialHandler{
-		factorialService: factorialService,
-		redisService:     redisService,
-		storage:          storage,
-		factCalRepo:      repository,
-		producer:         producer,
-		queueName:        queueName,
+		factorialService:  factorialService,
+		redisService:      redisService,
+		storage:           storage,
+		factCalRepo:       repository,
+		currentCalcRepo:   currentCalcRepo,
+		batchRepo:         batchRepo,
+		callbackRepo:      callbackRepo,
+		notifier:          notifier,
+		progressService:   progressService,
+		priorityPolicy:    priorityPolicy,
+		outboxRepo:        outboxRepo,
+		submissionRepo:    submissionRepo,
+		idempotencyRepo:   idempotencyRepo,
+		idempotencyWindow: idempotencyWindow,
+		queueName:         queueName,
 	}
 }
 
 // SubmitCalculation godoc
 // @Summary      Submit factorial calculation
-// @Description  Submit a number for factorial calculation (async processing)
+// @Description  Submit a number for factorial calculation (async processing). The submission is committed with its queue message and published by the outbox relay; GET /factorial/submissions/{submission_id} reports whether it was published. When callback_url is set, an HMAC-signed POST with the result metadata is sent to it once the factorial is done.
 // @Tags         factorial
 // @Accept       json
 // @Produce      json
 // @Param        request body dto.CalculateRequest true "Calculation Request"
+// @Param        X-Request-ID header string false "Request ID recorded in the queue message; generated when missing or invalid"
+// @Param        X-Client-ID header string false "Client ID recorded in the queue message"
+// @Param        traceparent header string false "W3C trace context continued by the queue message"
+// @Param        Idempotency-Key header string false "Makes the submission safe to retry: a repeat within the idempotency window returns the original response with Idempotent-Replayed: true and publishes nothing"
 // @Success      200  {object}  dto.APIResponse{data=dto.CalculateResponseData} "Calculation submitted successfully"
-// @Failure      400  {object}  dto.APIResponse{data=dto.ErrorResponse} "Invalid request - number missing or invalid format"
-// @Failure      500  {object}  dto.APIResponse{data=dto.ErrorResponse} "Internal server error - database or queue failure"
+// @Failure      400  {object}  dto.APIResponse{data=dto.ErrorResponse} "Invalid request - number missing or invalid format, or invalid Idempotency-Key"
+// @Failure      422  {object}  dto.APIResponse{data=dto.ErrorResponse} "Idempotency-Key already used for a different request"
+// @Failure      500  {object}  dto.APIResponse{data=dto.ErrorResponse} "Internal server error - database failure"
 // @Router       /factorial [post]
-// @Example      200 {"code":200,"status":"ok","message":"submitted","data":{"number":10,"message":"submitted"}}
+// @Example      200 {"code":200,"status":"ok","message":"submitted","data":{"number":10,"submission_id":"5feceb66ffc86f38d952786c6d696c79","message":"submitted"}}
+// @Example      200 {"code":200,"status":"ok","message":"submitted","data":{"number":10,"submission_id":"5feceb66ffc86f38d952786c6d696c79","callback_id":"9f86d081884c7d659a2feaa0c55ad015","message":"submitted"}}
 // @Example      400 {"code":400,"status":"fail","message":"invalid number format","data":{"error":"fail","message":"invalid number format"}}
+// @Example      422 {"code":422,"status":"fail","message":"Idempotency-Key was already used for a different request","data":{"error":"fail","message":"Idempotency-Key was already used for a different request"}}
 // @Example      500 {"code":500,"status":"fail","message":"Failed to submit calculation","data":{"error":"fail","message":"Failed to submit calculation"}}
 func (h *FactorialHandler) SubmitCalculation(c *gin.Context) {
 	var req dto.FactorialMessage
@@ -73,30 +125,107 @@ func (h *FactorialHandler) SubmitCalculation(c *gin.Context) {
 		return
 	}
 
-	msg := dto.FactorialMessage{Number: req.Number}
-	err = h.producer.Publish(c.Request.Context(), h.queueName, msg.Bytes())
+	if req.CallbackURL != "" {
+		if err := validateCallbackURL(req.CallbackURL); err != nil {
+			sendErrorResponse(c, http.StatusBadRequest, "fail", err.Error())
+			return
+		}
+	}
+
+	// A repeated Idempotency-Key gets the original response and publishes nothing
+	idempotencyKey, err := idempotencyKeyOf(c)
 	if err != nil {
-		log.Printf("Error publishing message: %v", err)
+		sendErrorResponse(c, http.StatusBadRequest, "fail", err.Error())
+		return
+	}
+	clientID := clientIDOf(c)
+	hash := requestHash(req.Number, req.CallbackURL)
+	if idempotencyKey != "" && h.replayIdempotent(c, clientID, idempotencyKey, hash) {
+		return
+	}
+
+	envelope, err := newMessageEnvelope(c, &dto.FactorialMessage{Number: req.Number})
+	if err != nil {
+		log.Printf("Error building message: %v", err)
+		sendErrorResponse(c, http.StatusInternalServerError, "fail", "Failed to submit calculation")
+		return
+	}
+	envelope.Priority = h.priorityPolicy.Priority(req.Number)
+	payload, err := envelope.Bytes()
+	if err != nil {
+		log.Printf("Error encoding message: %v", err)
 		sendErrorResponse(c, http.StatusInternalServerError, "fail", "Failed to submit calculation")
 		return
 	}
 
-	// Return calculating status
-	sendAPIResponse(c, http.StatusOK, "ok", "submitted", dto.CalculateResponseData{
-		Number:  req.Number,
+	// The submission, the callback, the idempotency key and the queue message are committed
+	// together; the outbox relay publishes the message and marks the submission published
+	var records []any
+	var callbackID string
+	if req.CallbackURL != "" {
+		callback, err := newCallback(req.Number, req.CallbackURL)
+		if err != nil {
+			log.Printf("Error generating callback ID: %v", err)
+			sendErrorResponse(c, http.StatusInternalServerError, "fail", "Failed to submit calculation")
+			return
+		}
+		records = append(records, callback)
+		callbackID = callback.CallbackID
+	}
+
+	response := dto.APIResponse{
+		Code:    http.StatusOK,
+		Status:  "ok",
 		Message: "submitted",
-	})
+		Data: dto.CalculateResponseData{
+			Number:       req.Number,
+			SubmissionID: envelope.MessageID,
+			CallbackID:   callbackID,
+			Message:      "submitted",
+		},
+	}
+	if idempotencyKey != "" {
+		record, err := h.newIdempotencyKey(clientID, idempotencyKey, hash, response)
+		if err != nil {
+			log.Printf("Error encoding idempotent response: %v", err)
+			sendErrorResponse(c, http.StatusInternalServerError, "fail", "Failed to submit calculation")
+			return
+		}
+		records = append(records, record)
+	}
+
+	queueName := dto.PriorityQueueName(h.queueName, envelope.Priority)
+	submission := &domain.FactorialSubmission{
+		SubmissionID: envelope.MessageID,
+		Number:       &req.Number,
+		ClientID:     clientID,
+		Priority:     envelope.Priority,
+	}
+	err = h.outboxRepo.Enqueue(submission, &domain.OutboxMessage{Queue: queueName, Payload: payload}, records...)
+	if err != nil {
+		// A concurrent request with the same key may have committed first
+		if idempotencyKey != "" && h.replayIdempotent(c, clientID, idempotencyKey, hash) {
+			return
+		}
+		log.Printf("Error enqueuing message: %v", err)
+		sendErrorResponse(c, http.StatusInternalServerError, "fail", "Failed to submit calculation")
+		return
+	}
+
+	// Return calculating status
+	c.JSON(response.Code, response)
 }
 
 // GetResult godoc
 // @Summary      Get factorial result
-// @Description  Get the factorial calculation result for a number. Returns result if calculation is complete, or status if in progress or not found.
+// @Description  Get the factorial calculation result for a number. Returns result if calculation is complete, or status if in progress or not found. With wait, a calculation in progress blocks until it is done or the duration elapses.
 // @Tags         factorial
 // @Produce      json
 // @Param        number path string true "Number"
+// @Param        wait query string false "Maximum duration to wait for a calculation in progress, e.g. 30s (capped at 60s)"
 // @Success      200  {object}  dto.APIResponse{data=dto.ResultResponseData} "Result retrieved successfully - calculation completed"
 // @Success      200  {object}  dto.APIResponse{data=dto.CalculateResponseData} "Calculation in progress or not found"
-// @Failure      400  {object}  dto.APIResponse{data=dto.ErrorResponse} "Invalid number format"
+// @Failure      400  {object}  dto.APIResponse{data=dto.ErrorResponse} "Invalid number format or wait duration"
 // @Failure      500  {object}  dto.APIResponse{data=dto.ErrorResponse} "Internal server error - database or storage failure"
 // @Router       /factorial/{number} [get]
 // @Example      200 {"code":200,"status":"ok","message":"done","data":{"number":"10","factorial_result":"3628800"}}
@@ -114,63 +243,118 @@ func (h *FactorialHandler) GetResult(c *gin.Context) {
 		return
 	}
 
-	result, err := h.redisService.Get(c.Request.Context(), number)
+	wait, err := parseWait(c.Query("wait"))
 	if err != nil {
-		log.Printf("Redis error: %v", err)
-	} else if result != "" {
-		sendAPIResponse(c, http.StatusOK, "ok", "done", dto.ResultResponseData{
-			Number:          number,
-			FactorialResult: result,
-		})
+		sendErrorResponse(c, http.StatusBadRequest, "fail", err.Error())
 		return
 	}
 
-	// Not in cache or large number, check DB for S3 key
-	calc, err := h.factCalRepo.FindByNumber(numberInt)
+	status, result, err := h.lookupResult(c.Request.Context(), numberInt)
+	if err == nil && status == domain.StatusCalculating && wait > 0 {
+		status, result, err = h.waitForResult(c.Request.Context(), numberInt, wait)
+	}
+	if errors.Is(err, errResolveResult) {
+		sendErrorResponse(c, http.StatusInternalServerError, "fail", "Failed to retrieve result from storage")
+		return
+	}
 	if err != nil {
-		log.Printf("Error finding calculation: %v", err)
 		sendErrorResponse(c, http.StatusInternalServerError, "fail", "Failed to retrieve calculation")
 		return
 	}
 
-	if calc == nil {
-		sendAPIResponse(c, http.StatusOK, "fail", "not_found", dto.CalculateResponseData{})
+	if status != domain.StatusDone {
+		sendAPIResponse(c, http.StatusOK, "fail", status, dto.CalculateResponseData{})
 		return
 	}
 
+	sendAPIResponse(c, http.StatusOK, "ok", "done", dto.ResultResponseData{
+		Number:          number,
+		FactorialResult: result,
+	})
+}
+
+// lookupResult resolves a factorial via Redis, then the database, then storage.
+// It returns the lookup status (done, calculating or not_found) and the result when done.
+func (h *FactorialHandler) lookupResult(ctx context.Context, number int64) (string, string, error) {
+	key := strconv.FormatInt(number, 10)
+
+	result, err := h.redisService.Get(ctx, key)
+	if err != nil {
+		log.Printf("Redis error: %v", err)
+	} else if result != "" {
+		return domain.StatusDone, result, nil
+	}
+
+	// Not in cache or large number, check DB for S3 key
+	calc, err := h.factCalRepo.FindByNumber(number)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && calc == nil) {
+		return resultNotFound, "", nil
+	}
+	if err != nil {
+		log.Printf("Error finding calculation: %v", err)
+		return "", "", errFindCalculation
+	}
+
 	if calc.Status != domain.StatusDone {
-		sendAPIResponse(c, http.StatusOK, "fail", "calculating", dto.CalculateResponseData{})
-		return
+		return domain.StatusCalculating, "", nil
 	}
 
-	result, err = h.storage.DownloadFactorial(c.Request.Context(), calc.S3Key)
+	result, err = h.factorialService.ResolveFactorial(ctx, calc)
+	if errors.Is(err, service.ErrChecksumMismatch) {
+		// The corrupted result is marked failed and recalculated
+		return domain.StatusCalculating, "", nil
+	}
 	if err != nil {
 		log.Printf("Error downloading from S3: %v", err)
-		sendErrorResponse(c, http.StatusInternalServerError, "fail", "Failed to retrieve result from storage")
-		return
+		return "", "", errResolveResult
 	}
 
-	go h.redisService.Set(context.Background(), number, result)
+	go h.redisService.Set(context.Background(), key, result)
 
-	sendAPIResponse(c, http.StatusOK, "ok", "done", dto.ResultResponseData{
-		Number:          number,
-		FactorialResult: result,
-	})
+	return domain.StatusDone, result, nil
+}
+
+// waitForResult blocks until number is announced done or wait elapses, then looks it up again
+func (h *FactorialHandler) waitForResult(ctx context.Context, number int64, wait time.Duration) (string, string, error) {
+	waitCtx, cancel := context.WithTimeout(ctx, wait)
+	defer cancel()
+
+	done, release, err := h.notifier.Subscribe(waitCtx, number)
+	if err != nil {
+		log.Printf("Error subscribing to completion of %d: %v", number, err)
+		return domain.StatusCalculating, "", nil
+	}
+	defer release()
+
+	// The calculation may have completed before the subscription was in place
+	status, result, err := h.lookupResult(ctx, number)
+	if err != nil || status != domain.StatusCalculating {
+		return status, result, err
+	}
+
+	select {
+	case <-done:
+		return h.lookupResult(ctx, number)
+	case <-waitCtx.Done():
+		return domain.StatusCalculating, "", nil
+	}
 }
 
 // GetMetadata godoc
 // @Summary      Get factorial calculation metadata
-// @Description  Get the metadata of a factorial calculation (status, S3 key, checksum, etc.). Returns metadata if calculation exists, or status if not found.
+// @Description  Get the metadata of a factorial calculation (status, S3 key, checksum, etc.). For numbers not done yet, progress reports the calculator position, the queue target, the throughput over a sliding window and an estimated completion time.
 // @Tags         factorial
 // @Produce      json
 // @Param        number path string true "Number"
 // @Success      200  {object}  dto.APIResponse{data=dto.MetadataResponseData} "Metadata retrieved successfully - calculation found"
-// @Success      200  {object}  dto.APIResponse{data=dto.CalculateResponseData} "Calculation not found or in progress"
+// @Success      200  {object}  dto.APIResponse{data=dto.ProgressData} "Calculation not found - queued or needs to be submitted"
 // @Failure      400  {object}  dto.APIResponse{data=dto.ErrorResponse} "Invalid number format"
 // @Failure      500  {object}  dto.APIResponse{data=dto.ErrorResponse} "Internal server error - database failure"
 // @Router       /factorial/metadata/{number} [get]
 // @Example      200 {"code":200,"status":"ok","message":"done","data":{"id":1,"number":10,"s3_key":"factorials/10.txt","checksum":"abc123...","status":"done","bucket":"my-bucket","created_at":"2025-01-01T00:00:00Z","updated_at":"2025-01-01T00:00:00Z"}}
-// @Example      200 {"code":200,"status":"fail","message":"need_submit_calculation","data":{}}
+// @Example      200 {"code":200,"status":"ok","message":"calculating","data":{"id":2,"number":9000,"status":"calculating","bucket":"","derived":false,"progress":{"number":9000,"current":8200,"target":9000,"remaining":801,"queued":true,"throughput_per_second":12.5,"window_seconds":300,"estimated_completion_at":"2025-01-01T00:01:04Z"},"created_at":"2025-01-01T00:00:00Z","updated_at":"2025-01-01T00:00:00Z"}}
+// @Example      200 {"code":200,"status":"fail","message":"queued","data":{"number":8500,"current":8200,"target":9000,"remaining":301,"queued":true,"throughput_per_second":12.5,"window_seconds":300,"estimated_completion_at":"2025-01-01T00:00:24Z"}}
+// @Example      200 {"code":200,"status":"fail","message":"need_submit_calculation","data":{"number":9500,"current":8200,"target":9000,"remaining":1301,"queued":false,"throughput_per_second":12.5,"window_seconds":300}}
 // @Example      400 {"code":400,"status":"fail","message":"invalid number format","data":{"error":"fail","message":"invalid number format"}}
 // @Example      500 {"code":500,"status":"fail","message":"Failed to retrieve metadata","data":{"error":"fail","message":"Failed to retrieve metadata"}}
 func (h *FactorialHandler) GetMetadata(c *gin.Context) {
@@ -185,25 +369,34 @@ func (h *FactorialHandler) GetMetadata(c *gin.Context) {
 
 	// Query DB
 	calc, err := h.factCalRepo.FindByNumber(numberInt)
-	if err != nil {
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		log.Printf("Error finding calculation: %v", err)
 		sendErrorResponse(c, http.StatusInternalServerError, "fail", "Failed to retrieve metadata")
 		return
 	}
 
-	if calc == nil {
-		sendAPIResponse(c, http.StatusOK, "fail", "need_submit_calculation", dto.CalculateResponseData{})
+	if calc != nil && calc.Status == domain.StatusDone {
+		sendAPIResponse(c, http.StatusOK, "ok", "done", dto.NewMetadataResponseData(calc))
 		return
 	}
 
-	sendAPIResponse(c, http.StatusOK, "ok", "done", dto.MetadataResponseData{
-		ID:        calc.ID,
-		Number:    calc.Number,
-		S3Key:     calc.S3Key,
-		Checksum:  calc.Checksum,
-		Status:    calc.Status,
-		CreatedAt: calc.CreatedAt,
-		UpdatedAt: calc.UpdatedAt,
-		Bucket:    calc.Bucket,
-	})
+	progress, err := h.progressService.GetProgress(numberInt)
+	if err != nil {
+		log.Printf("Error getting progress: %v", err)
+		sendErrorResponse(c, http.StatusInternalServerError, "fail", "Failed to retrieve metadata")
+		return
+	}
+
+	if calc != nil {
+		data := dto.NewMetadataResponseData(calc)
+		data.Progress = toProgressData(progress)
+		sendAPIResponse(c, http.StatusOK, "ok", calc.Status, data)
+		return
+	}
+
+	message := "need_submit_calculation"
+	if progress.Queued() {
+		message = "queued"
+	}
+	sendAPIResponse(c, http.StatusOK, "fail", message, toProgressData(progress))
 }
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"factorial-cal-services/pkg/domain"
+	"factorial-cal-services/pkg/dto"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SubmitRange godoc
+// @Summary      Submit a range of factorial calculations
+// @Description  Submit every number in [from, to] for factorial calculation as a single batch (async processing)
+// @Tags         factorial
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.RangeRequest true "Range Request"
+// @Param        X-Request-ID header string false "Request ID recorded in the queue message; generated when missing or invalid"
+// @Param        X-Client-ID header string false "Client ID recorded in the queue message"
+// @Param        traceparent header string false "W3C trace context continued by the queue message"
+// @Success      200  {object}  dto.APIResponse{data=dto.RangeResponseData} "Range submitted successfully"
+// @Failure      400  {object}  dto.APIResponse{data=dto.ErrorResponse} "Invalid request - bounds missing, invalid or reversed"
+// @Failure      500  {object}  dto.APIResponse{data=dto.ErrorResponse} "Internal server error - database failure"
+// @Router       /factorial/range [post]
+// @Example      200 {"code":200,"status":"ok","message":"submitted","data":{"batch_id":"9f86d081884c7d659a2feaa0c55ad015","submission_id":"5feceb66ffc86f38d952786c6d696c79","from":5000,"to":6000,"message":"submitted"}}
+// @Example      400 {"code":400,"status":"fail","message":"from must not be greater than to","data":{"error":"fail","message":"from must not be greater than to"}}
+func (h *FactorialHandler) SubmitRange(c *gin.Context) {
+	var req dto.RangeRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		sendErrorResponse(c, http.StatusBadRequest, "fail", err.Error())
+		return
+	}
+
+	// Validate both bounds
+	from, err := h.factorialService.ValidateNumber(fmt.Sprintf("%d", *req.From))
+	if err != nil {
+		sendErrorResponse(c, http.StatusBadRequest, "fail", err.Error())
+		return
+	}
+	to, err := h.factorialService.ValidateNumber(fmt.Sprintf("%d", *req.To))
+	if err != nil {
+		sendErrorResponse(c, http.StatusBadRequest, "fail", err.Error())
+		return
+	}
+	if from > to {
+		sendErrorResponse(c, http.StatusBadRequest, "fail", "from must not be greater than to")
+		return
+	}
+
+	batchID, err := newPublicID()
+	if err != nil {
+		log.Printf("Error generating batch ID: %v", err)
+		sendErrorResponse(c, http.StatusInternalServerError, "fail", "Failed to submit range")
+		return
+	}
+
+	// The submission, the batch and the queue message are committed together; the outbox relay
+	// publishes the message and marks the submission published
+	envelope, err := newMessageEnvelope(c, &dto.FactorialRangeMessage{BatchID: batchID, From: from, To: to})
+	if err != nil {
+		log.Printf("Error building range message: %v", err)
+		sendErrorResponse(c, http.StatusInternalServerError, "fail", "Failed to submit range")
+		return
+	}
+	// A range is as expensive as its highest number
+	envelope.Priority = h.priorityPolicy.Priority(to)
+	payload, err := envelope.Bytes()
+	if err != nil {
+		log.Printf("Error encoding range message: %v", err)
+		sendErrorResponse(c, http.StatusInternalServerError, "fail", "Failed to submit range")
+		return
+	}
+
+	queueName := dto.PriorityQueueName(h.queueName, envelope.Priority)
+	submission := &domain.FactorialSubmission{
+		SubmissionID: envelope.MessageID,
+		BatchID:      batchID,
+		ClientID:     envelope.ClientID,
+		Priority:     envelope.Priority,
+	}
+	err = h.outboxRepo.Enqueue(submission, &domain.OutboxMessage{Queue: queueName, Payload: payload}, &domain.FactorialBatch{
+		BatchID:    batchID,
+		FromNumber: from,
+		ToNumber:   to,
+	})
+	if err != nil {
+		log.Printf("Error creating batch: %v", err)
+		sendErrorResponse(c, http.StatusInternalServerError, "fail", "Failed to submit range")
+		return
+	}
+
+	sendAPIResponse(c, http.StatusOK, "ok", "submitted", dto.RangeResponseData{
+		BatchID:      batchID,
+		SubmissionID: envelope.MessageID,
+		From:         from,
+		To:           to,
+		Message:      "submitted",
+	})
+}
+
+// GetRangeStatus godoc
+// @Summary      Get range batch progress
+// @Description  Get how many factorials of a submitted range are done
+// @Tags         factorial
+// @Produce      json
+// @Param        batch_id path string true "Batch ID"
+// @Success      200  {object}  dto.APIResponse{data=dto.RangeStatusResponseData} "Batch progress retrieved successfully"
+// @Failure      404  {object}  dto.APIResponse{data=dto.ErrorResponse} "Batch not found"
+// @Failure      500  {object}  dto.APIResponse{data=dto.ErrorResponse} "Internal server error - database failure"
+// @Router       /factorial/range/{batch_id} [get]
+// @Example      200 {"code":200,"status":"ok","message":"calculating","data":{"batch_id":"9f86d081884c7d659a2feaa0c55ad015","from":5000,"to":6000,"total":1001,"done":420,"status":"calculating"}}
+// @Example      404 {"code":404,"status":"fail","message":"batch not found","data":{"error":"fail","message":"batch not found"}}
+func (h *FactorialHandler) GetRangeStatus(c *gin.Context) {
+	batchID := c.Param("batch_id")
+
+	batch, err := h.batchRepo.FindByBatchID(batchID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		sendErrorResponse(c, http.StatusNotFound, "fail", "batch not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Error finding batch: %v", err)
+		sendErrorResponse(c, http.StatusInternalServerError, "fail", "Failed to retrieve batch")
+		return
+	}
+
+	done, err := h.factCalRepo.CountByStatusInRange(batch.FromNumber, batch.ToNumber, domain.StatusDone)
+	if err != nil {
+		log.Printf("Error counting batch progress: %v", err)
+		sendErrorResponse(c, http.StatusInternalServerError, "fail", "Failed to retrieve batch")
+		return
+	}
+
+	total := batch.ToNumber - batch.FromNumber + 1
+	status := domain.StatusCalculating
+	if done >= total {
+		status = domain.StatusDone
+	}
+
+	sendAPIResponse(c, http.StatusOK, "ok", status, dto.RangeStatusResponseData{
+		BatchID: batch.BatchID,
+		From:    batch.FromNumber,
+		To:      batch.ToNumber,
+		Total:   total,
+		Done:    done,
+		Status:  status,
+	})
+}
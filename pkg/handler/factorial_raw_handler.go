@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"factorial-cal-services/pkg/domain"
+	"factorial-cal-services/pkg/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetRawResult godoc
+// @Summary      Stream factorial result
+// @Description  Stream the factorial result as plain text directly from storage. The result is verified against its checksum while it is streamed. Supports Range requests and conditional requests with If-None-Match, using the stored checksum as ETag.
+// @Tags         factorial
+// @Produce      plain
+// @Param        number path string true "Number"
+// @Param        Range header string false "Byte range, e.g. bytes=0-1023"
+// @Param        If-None-Match header string false "Checksum returned in a previous ETag"
+// @Success      200  {string}  string "Full factorial result"
+// @Success      206  {string}  string "Requested byte range of the factorial result"
+// @Success      304  {string}  string "Not modified"
+// @Failure      400  {object}  dto.APIResponse{data=dto.ErrorResponse} "Invalid number format"
+// @Failure      404  {object}  dto.APIResponse{data=dto.ErrorResponse} "Calculation not found or in progress"
+// @Failure      416  {string}  string "Range not satisfiable"
+// @Failure      500  {object}  dto.APIResponse{data=dto.ErrorResponse} "Internal server error - database or storage failure"
+// @Router       /factorial/{number}/raw [get]
+func (h *FactorialHandler) GetRawResult(c *gin.Context) {
+	number := c.Param("number")
+
+	// Validate number format
+	numberInt, err := h.factorialService.ValidateNumber(number)
+	if err != nil {
+		sendErrorResponse(c, http.StatusBadRequest, "fail", err.Error())
+		return
+	}
+
+	calc, err := h.factCalRepo.FindByNumber(numberInt)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		sendErrorResponse(c, http.StatusNotFound, "fail", resultNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error finding calculation: %v", err)
+		sendErrorResponse(c, http.StatusInternalServerError, "fail", "Failed to retrieve calculation")
+		return
+	}
+
+	if calc.Status != domain.StatusDone {
+		sendErrorResponse(c, http.StatusNotFound, "fail", domain.StatusCalculating)
+		return
+	}
+
+	reader, err := h.factorialService.OpenResult(c.Request.Context(), calc)
+	if errors.Is(err, service.ErrChecksumMismatch) {
+		// The corrupted result is marked failed and recalculated
+		sendErrorResponse(c, http.StatusNotFound, "fail", domain.StatusCalculating)
+		return
+	}
+	if err != nil {
+		log.Printf("Error opening result from storage: %v", err)
+		sendErrorResponse(c, http.StatusInternalServerError, "fail", "Failed to retrieve result from storage")
+		return
+	}
+	defer reader.Close()
+
+	if calc.Checksum != "" {
+		c.Header("ETag", fmt.Sprintf("%q", calc.Checksum))
+	}
+	c.Header("Content-Type", "text/plain; charset=utf-8")
+	http.ServeContent(c.Writer, c.Request, "", calc.UpdatedAt, reader)
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"factorial-cal-services/pkg/dto"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetSubmission godoc
+// @Summary      Get submission status
+// @Description  Get whether a submitted calculation or range was published to the queue. Submissions are committed before they are published, so a queue outage shows here as pending with the last publish error, and as failed once the outbox relay gave up.
+// @Tags         factorial
+// @Produce      json
+// @Param        submission_id path string true "Submission ID"
+// @Success      200  {object}  dto.APIResponse{data=dto.SubmissionResponseData} "Submission retrieved successfully"
+// @Failure      404  {object}  dto.APIResponse{data=dto.ErrorResponse} "Submission not found"
+// @Failure      500  {object}  dto.APIResponse{data=dto.ErrorResponse} "Internal server error - database failure"
+// @Router       /factorial/submissions/{submission_id} [get]
+// @Example      200 {"code":200,"status":"ok","message":"published","data":{"submission_id":"5feceb66ffc86f38d952786c6d696c79","number":10,"status":"published","published_at":"2025-01-01T00:00:01Z","created_at":"2025-01-01T00:00:00Z","updated_at":"2025-01-01T00:00:01Z"}}
+// @Example      200 {"code":200,"status":"ok","message":"pending","data":{"submission_id":"5feceb66ffc86f38d952786c6d696c79","number":10,"status":"pending","last_error":"message is unroutable: factorial-cal-queue","created_at":"2025-01-01T00:00:00Z","updated_at":"2025-01-01T00:00:01Z"}}
+// @Example      404 {"code":404,"status":"fail","message":"submission not found","data":{"error":"fail","message":"submission not found"}}
+func (h *FactorialHandler) GetSubmission(c *gin.Context) {
+	submission, err := h.submissionRepo.FindBySubmissionID(c.Param("submission_id"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		sendErrorResponse(c, http.StatusNotFound, "fail", "submission not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Error finding submission: %v", err)
+		sendErrorResponse(c, http.StatusInternalServerError, "fail", "Failed to retrieve submission")
+		return
+	}
+
+	sendAPIResponse(c, http.StatusOK, "ok", submission.Status, dto.SubmissionResponseData{
+		SubmissionID: submission.SubmissionID,
+		Number:       submission.Number,
+		BatchID:      submission.BatchID,
+		Status:       submission.Status,
+		LastError:    submission.LastError,
+		PublishedAt:  submission.PublishedAt,
+		CreatedAt:    submission.CreatedAt,
+		UpdatedAt:    submission.UpdatedAt,
+	})
+}
@@ -1,7 +1,15 @@
 package handler
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
 	"factorial-cal-services/pkg/dto"
+	"factorial-cal-services/pkg/service"
 
 	"github.com/gin-gonic/gin"
 )
@@ -23,3 +31,61 @@ func sendErrorResponse(c *gin.Context, code int, status string, message string)
 		Message: message,
 	})
 }
+
+// newPublicID generates a random identifier clients can poll a batch or callback with
+func newPublicID() (string, error) {
+	return randomHex(16)
+}
+
+// randomHex returns n random bytes, hex encoded
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// maxCallbackURLLength matches the url column of factorial_callbacks
+const maxCallbackURLLength = 2048
+
+// validateCallbackURL checks that a callback URL is an absolute http(s) URL without credentials
+func validateCallbackURL(rawURL string) error {
+	if len(rawURL) > maxCallbackURLLength {
+		return errors.New("callback_url is too long")
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return errors.New("callback_url must be an absolute http or https URL")
+	}
+	if u.User != nil {
+		return errors.New("callback_url must not contain credentials")
+	}
+	return nil
+}
+
+// parseWait parses the wait query parameter, capped at maxResultWait; empty means no wait
+func parseWait(raw string) (time.Duration, error) {
+	if raw == "" {
+		return 0, nil
+	}
+	wait, err := time.ParseDuration(raw)
+	if err != nil || wait < 0 {
+		return 0, fmt.Errorf("invalid wait duration: %q", raw)
+	}
+	return min(wait, maxResultWait), nil
+}
+
+// toProgressData maps a calculation progress to its response payload
+func toProgressData(progress *service.CalculationProgress) *dto.ProgressData {
+	return &dto.ProgressData{
+		Number:                progress.Number,
+		Current:               progress.Current,
+		Target:                progress.Target,
+		Remaining:             progress.Remaining,
+		Queued:                progress.Queued(),
+		ThroughputPerSecond:   progress.Throughput,
+		WindowSeconds:         int64(progress.Window.Seconds()),
+		EstimatedCompletionAt: progress.EstimatedCompletionAt,
+	}
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"time"
+
+	"factorial-cal-services/pkg/domain"
+	"factorial-cal-services/pkg/dto"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// IdempotencyKeyHeader makes a submission safe to retry: repeats within the window get the original response
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set on responses replayed for a repeated Idempotency-Key
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// idempotencyKeyPattern allows visible ASCII keys up to the length of the idempotency_key column
+var idempotencyKeyPattern = regexp.MustCompile(`^[\x21-\x7E]{1,255}$`)
+
+// idempotencyKeyOf returns the Idempotency-Key header, empty when the request has none
+func idempotencyKeyOf(c *gin.Context) (string, error) {
+	key := c.GetHeader(IdempotencyKeyHeader)
+	if key != "" && !idempotencyKeyPattern.MatchString(key) {
+		return "", errors.New("idempotency key must be 1 to 255 visible ASCII characters")
+	}
+	return key, nil
+}
+
+// requestHash fingerprints the fields of a submission, so a key reused for another request is detected
+func requestHash(fields ...any) string {
+	sum := sha256.Sum256(fmt.Append(nil, fields...))
+	return hex.EncodeToString(sum[:])
+}
+
+// newIdempotencyKey records response as the response of the key; it is stored with the submission
+func (h *FactorialHandler) newIdempotencyKey(clientID, key, hash string, response dto.APIResponse) (*domain.IdempotencyKey, error) {
+	body, err := json.Marshal(response)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.IdempotencyKey{
+		ClientID:       clientID,
+		Key:            key,
+		RequestHash:    hash,
+		ResponseStatus: response.Code,
+		ResponseBody:   body,
+		ExpiresAt:      time.Now().Add(h.idempotencyWindow),
+	}, nil
+}
+
+// replayIdempotent writes the stored response of a key used within the window and reports
+// whether a response was written. An expired key is deleted so the request is processed again.
+func (h *FactorialHandler) replayIdempotent(c *gin.Context, clientID, key, hash string) bool {
+	record, err := h.idempotencyRepo.Find(clientID, key)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false
+	}
+	if err != nil {
+		log.Printf("Error finding idempotency key: %v", err)
+		sendErrorResponse(c, http.StatusInternalServerError, "fail", "Failed to submit calculation")
+		return true
+	}
+
+	if !record.ExpiresAt.After(time.Now()) {
+		if err := h.idempotencyRepo.Delete(record.ID); err != nil {
+			log.Printf("Error deleting expired idempotency key: %v", err)
+			sendErrorResponse(c, http.StatusInternalServerError, "fail", "Failed to submit calculation")
+			return true
+		}
+		return false
+	}
+
+	if record.RequestHash != hash {
+		sendErrorResponse(c, http.StatusUnprocessableEntity, "fail", "Idempotency-Key was already used for a different request")
+		return true
+	}
+
+	c.Header(IdempotentReplayedHeader, "true")
+	c.Data(record.ResponseStatus, "application/json; charset=utf-8", record.ResponseBody)
+	return true
+}
@@ -0,0 +1,271 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"factorial-cal-services/pkg/domain"
+	"factorial-cal-services/pkg/dto"
+	"factorial-cal-services/pkg/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// fakeFactorialService validates numbers; the submit handler needs nothing else
+type fakeFactorialService struct {
+	service.FactorialService
+}
+
+func (fakeFactorialService) ValidateNumber(number string) (int64, error) {
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || n < 0 || n > 10000 {
+		return 0, errors.New("number must be between 0 and 10000")
+	}
+	return n, nil
+}
+
+// fakeIdempotencyRepository keeps idempotency keys in memory, unique per client and key
+type fakeIdempotencyRepository struct {
+	keys    map[string]*domain.IdempotencyKey
+	nextID  int64
+	deleted []int64
+}
+
+func newFakeIdempotencyRepository() *fakeIdempotencyRepository {
+	return &fakeIdempotencyRepository{keys: make(map[string]*domain.IdempotencyKey)}
+}
+
+func (r *fakeIdempotencyRepository) create(record *domain.IdempotencyKey) error {
+	id := record.ClientID + "/" + record.Key
+	if _, ok := r.keys[id]; ok {
+		return errors.New(`duplicate key value violates unique constraint "idx_idempotency_keys_client_id_key"`)
+	}
+	r.nextID++
+	record.ID = r.nextID
+	r.keys[id] = record
+	return nil
+}
+
+func (r *fakeIdempotencyRepository) Find(clientID, key string) (*domain.IdempotencyKey, error) {
+	record, ok := r.keys[clientID+"/"+key]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *record
+	return &copied, nil
+}
+
+func (r *fakeIdempotencyRepository) Delete(id int64) error {
+	for k, record := range r.keys {
+		if record.ID == id {
+			delete(r.keys, k)
+			r.deleted = append(r.deleted, id)
+		}
+	}
+	return nil
+}
+
+func (r *fakeIdempotencyRepository) DeleteExpired(now time.Time) (int64, error) {
+	return 0, nil
+}
+
+// fakeOutboxRepository commits submissions in memory, inserting their idempotency keys into
+// the fake idempotency repository so a duplicate key rolls the submission back
+type fakeOutboxRepository struct {
+	idempotency *fakeIdempotencyRepository
+	messages    []*domain.OutboxMessage
+	// beforeCommit runs once inside the next Enqueue, like a concurrent request committing first
+	beforeCommit func()
+}
+
+func (r *fakeOutboxRepository) Enqueue(submission *domain.FactorialSubmission, message *domain.OutboxMessage, records ...any) error {
+	if r.beforeCommit != nil {
+		beforeCommit := r.beforeCommit
+		r.beforeCommit = nil
+		beforeCommit()
+	}
+	for _, record := range records {
+		if key, ok := record.(*domain.IdempotencyKey); ok {
+			if err := r.idempotency.create(key); err != nil {
+				return err
+			}
+		}
+	}
+	message.SubmissionID = submission.SubmissionID
+	r.messages = append(r.messages, message)
+	return nil
+}
+
+func (r *fakeOutboxRepository) RelayPending(limit, maxAttempts int, retryDelay func(attempts int) time.Duration, relay func(message *domain.OutboxMessage) error) (int, error) {
+	return 0, nil
+}
+
+func (r *fakeOutboxRepository) DeleteSentBefore(before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func newSubmitTestRouter() (*gin.Engine, *fakeOutboxRepository, *fakeIdempotencyRepository) {
+	gin.SetMode(gin.TestMode)
+	idempotencyRepo := newFakeIdempotencyRepository()
+	outboxRepo := &fakeOutboxRepository{idempotency: idempotencyRepo}
+	h := NewFactorialHandler(
+		fakeFactorialService{},
+		nil, nil, nil, nil, nil, nil, nil, nil,
+		service.NewPriorityPolicy(1000),
+		outboxRepo,
+		nil,
+		idempotencyRepo,
+		time.Hour,
+		"factorial-cal-queue",
+	)
+	r := gin.New()
+	r.POST("/factorial", h.SubmitCalculation)
+	return r, outboxRepo, idempotencyRepo
+}
+
+func submit(r *gin.Engine, body, idempotencyKey string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/factorial", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if idempotencyKey != "" {
+		req.Header.Set(IdempotencyKeyHeader, idempotencyKey)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// storedResponse is the response a concurrent submission committed with its key
+func storedResponse(t *testing.T, number int64) []byte {
+	t.Helper()
+	body, err := json.Marshal(dto.APIResponse{
+		Code:    http.StatusOK,
+		Status:  "ok",
+		Message: "submitted",
+		Data:    dto.CalculateResponseData{Number: number, SubmissionID: "concurrent", Message: "submitted"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to encode response: %v", err)
+	}
+	return body
+}
+
+func TestSubmitCalculation_IdempotencyKeyReplaysResponse(t *testing.T) {
+	r, outboxRepo, _ := newSubmitTestRouter()
+
+	first := submit(r, `{"number":10}`, "key-1")
+	if first.Code != http.StatusOK || first.Header().Get(IdempotentReplayedHeader) != "" {
+		t.Fatalf("Expected the first submission to be processed, got %d %s", first.Code, first.Body)
+	}
+	second := submit(r, `{"number":10}`, "key-1")
+	if second.Code != http.StatusOK || second.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Fatalf("Expected the retry to be replayed, got %d %v", second.Code, second.Header())
+	}
+	if second.Body.String() != first.Body.String() {
+		t.Errorf("Expected the original response %s, got %s", first.Body, second.Body)
+	}
+	if len(outboxRepo.messages) != 1 {
+		t.Errorf("Expected a single queue message, got %d", len(outboxRepo.messages))
+	}
+
+	// Without a key every submission is processed
+	submit(r, `{"number":10}`, "")
+	submit(r, `{"number":10}`, "")
+	if len(outboxRepo.messages) != 3 {
+		t.Errorf("Expected submissions without a key to be enqueued, got %d messages", len(outboxRepo.messages))
+	}
+}
+
+func TestSubmitCalculation_IdempotencyKeyOfAnotherRequest(t *testing.T) {
+	r, outboxRepo, _ := newSubmitTestRouter()
+
+	submit(r, `{"number":10}`, "key-1")
+	w := submit(r, `{"number":11}`, "key-1")
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for a key reused with another number, got %d %s", w.Code, w.Body)
+	}
+	w = submit(r, `{"number":10,"callback_url":"https://example.com/hook"}`, "key-1")
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for a key reused with another callback, got %d %s", w.Code, w.Body)
+	}
+	if len(outboxRepo.messages) != 1 {
+		t.Errorf("Expected only the first submission enqueued, got %d messages", len(outboxRepo.messages))
+	}
+
+	if w := submit(r, `{"number":10}`, "not a valid key"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid key, got %d", w.Code)
+	}
+}
+
+func TestSubmitCalculation_ExpiredIdempotencyKeyIsDeleted(t *testing.T) {
+	r, outboxRepo, idempotencyRepo := newSubmitTestRouter()
+	expired := &domain.IdempotencyKey{
+		Key:            "key-1",
+		RequestHash:    requestHash(int64(11), ""),
+		ResponseStatus: http.StatusOK,
+		ResponseBody:   storedResponse(t, 11),
+		ExpiresAt:      time.Now().Add(-time.Minute),
+	}
+	idempotencyRepo.create(expired)
+
+	// Past its window the key is free again, even for another request
+	w := submit(r, `{"number":10}`, "key-1")
+	if w.Code != http.StatusOK || w.Header().Get(IdempotentReplayedHeader) != "" {
+		t.Fatalf("Expected the submission to be processed, got %d %s", w.Code, w.Body)
+	}
+	if len(idempotencyRepo.deleted) != 1 || idempotencyRepo.deleted[0] != expired.ID {
+		t.Errorf("Expected the expired key to be deleted, got %v", idempotencyRepo.deleted)
+	}
+	if len(outboxRepo.messages) != 1 {
+		t.Errorf("Expected the submission enqueued, got %d messages", len(outboxRepo.messages))
+	}
+	record, err := idempotencyRepo.Find("", "key-1")
+	if err != nil || record.RequestHash != requestHash(int64(10), "") || !record.ExpiresAt.After(time.Now()) {
+		t.Errorf("Expected the key stored again for the new request, got %+v (%v)", record, err)
+	}
+}
+
+func TestSubmitCalculation_ConcurrentSameKeyReplaysWinner(t *testing.T) {
+	tests := []struct {
+		name       string
+		winner     int64
+		wantStatus int
+	}{
+		{name: "same request", winner: 10, wantStatus: http.StatusOK},
+		{name: "different request", winner: 11, wantStatus: http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, outboxRepo, idempotencyRepo := newSubmitTestRouter()
+			winnerBody := storedResponse(t, tt.winner)
+			// The concurrent request passed the lookup too, and commits its key first
+			outboxRepo.beforeCommit = func() {
+				idempotencyRepo.create(&domain.IdempotencyKey{
+					Key:            "key-1",
+					RequestHash:    requestHash(tt.winner, ""),
+					ResponseStatus: http.StatusOK,
+					ResponseBody:   winnerBody,
+					ExpiresAt:      time.Now().Add(time.Hour),
+				})
+			}
+
+			w := submit(r, `{"number":10}`, "key-1")
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected %d, got %d %s", tt.wantStatus, w.Code, w.Body)
+			}
+			if tt.wantStatus == http.StatusOK && (w.Body.String() != string(winnerBody) || w.Header().Get(IdempotentReplayedHeader) != "true") {
+				t.Errorf("Expected the response of the winner replayed, got %s %v", w.Body, w.Header())
+			}
+			if len(outboxRepo.messages) != 0 {
+				t.Errorf("Expected the losing submission rolled back, got %d messages", len(outboxRepo.messages))
+			}
+		})
+	}
+}
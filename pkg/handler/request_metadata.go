@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"regexp"
+	"strings"
+
+	"factorial-cal-services/pkg/dto"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// RequestIDHeader carries the request ID; a valid client value is kept, otherwise one is generated
+	RequestIDHeader = "X-Request-ID"
+	// ClientIDHeader identifies the calling client in queue messages
+	ClientIDHeader = "X-Client-ID"
+
+	traceParentHeader = "traceparent"
+	traceStateHeader  = "tracestate"
+
+	// requestIDKey is the gin context key the request ID is stored under
+	requestIDKey = "request_id"
+	// maxTraceStateLength bounds the tracestate copied into queue messages
+	maxTraceStateLength = 512
+)
+
+var (
+	// metadataTokenPattern restricts client supplied IDs so they are safe to log
+	metadataTokenPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+	// traceParentPattern matches a version 00 W3C traceparent
+	traceParentPattern = regexp.MustCompile(`^00-([0-9a-f]{32})-([0-9a-f]{16})-([0-9a-f]{2})$`)
+)
+
+// RequestIDMiddleware assigns every request an ID, echoed in the response header
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !metadataTokenPattern.MatchString(requestID) {
+			var err error
+			if requestID, err = newPublicID(); err != nil {
+				requestID = ""
+			}
+		}
+		c.Set(requestIDKey, requestID)
+		c.Header(RequestIDHeader, requestID)
+		c.Next()
+	}
+}
+
+// newMessageEnvelope wraps a queue payload with a new message ID and the request, client
+// and trace context of the request submitting it
+func newMessageEnvelope(c *gin.Context, payload any) (*dto.MessageEnvelope, error) {
+	messageID, err := newPublicID()
+	if err != nil {
+		return nil, err
+	}
+	envelope, err := dto.NewMessageEnvelope(messageID, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	envelope.RequestID = c.GetString(requestIDKey)
+	envelope.ClientID = clientIDOf(c)
+	envelope.TraceParent, envelope.TraceState, err = traceContext(c)
+	if err != nil {
+		return nil, err
+	}
+	return envelope, nil
+}
+
+// clientIDOf returns the client ID header, or an empty string when it is missing or invalid
+func clientIDOf(c *gin.Context) string {
+	if clientID := c.GetHeader(ClientIDHeader); metadataTokenPattern.MatchString(clientID) {
+		return clientID
+	}
+	return ""
+}
+
+// traceContext continues the W3C trace of the request with a new span ID, or starts a new
+// trace when the request carries no valid traceparent
+func traceContext(c *gin.Context) (string, string, error) {
+	spanID, err := randomHex(8)
+	if err != nil {
+		return "", "", err
+	}
+
+	match := traceParentPattern.FindStringSubmatch(c.GetHeader(traceParentHeader))
+	if match == nil || match[1] == strings.Repeat("0", 32) {
+		traceID, err := randomHex(16)
+		if err != nil {
+			return "", "", err
+		}
+		return "00-" + traceID + "-" + spanID + "-01", "", nil
+	}
+
+	traceState := c.GetHeader(traceStateHeader)
+	if len(traceState) > maxTraceStateLength || strings.ContainsFunc(traceState, func(r rune) bool { return r < ' ' || r > '~' }) {
+		traceState = ""
+	}
+	return "00-" + match[1] + "-" + spanID + "-" + match[3], traceState, nil
+}
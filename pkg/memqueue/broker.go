@@ -0,0 +1,112 @@
+package memqueue
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Message is a message held by the in-memory broker
+type Message struct {
+	Body []byte
+	// Attempts is the number of times the message has been fetched
+	Attempts  int
+	LastError string
+
+	availableAt time.Time
+}
+
+// Broker is an in-process queue broker for tests and single-binary local runs.
+// Messages do not survive a restart and fetched messages are owned by their consumer.
+type Broker struct {
+	mu     sync.Mutex
+	queues map[string][]*Message
+	// published is closed and replaced whenever a message is added
+	published chan struct{}
+}
+
+// NewBroker creates an empty in-memory broker
+func NewBroker() *Broker {
+	return &Broker{
+		queues:    make(map[string][]*Message),
+		published: make(chan struct{}),
+	}
+}
+
+// Publish appends a message to the queue
+func (b *Broker) Publish(queueName string, body []byte) {
+	b.PublishAfter(queueName, &Message{Body: body}, 0)
+}
+
+// PublishAfter appends a message to the queue that becomes available after delay
+func (b *Broker) PublishAfter(queueName string, msg *Message, delay time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	msg.availableAt = time.Now().Add(delay)
+	b.queues[queueName] = append(b.queues[queueName], msg)
+	close(b.published)
+	b.published = make(chan struct{})
+}
+
+// Fetch removes and returns up to max available messages of the queue, waiting up to wait
+// for the first one. It returns no messages when none became available in time.
+func (b *Broker) Fetch(ctx context.Context, queueName string, max int, wait time.Duration) ([]*Message, error) {
+	deadline := time.Now().Add(wait)
+	for {
+		msgs, published, next := b.take(queueName, max)
+		if len(msgs) > 0 {
+			return msgs, nil
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil, nil
+		}
+		if !next.IsZero() {
+			remaining = min(remaining, time.Until(next))
+		}
+
+		timer := time.NewTimer(remaining)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-published:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// take removes up to max available messages of the queue. When there are none, it returns
+// the channel closed on the next publish and the time the next delayed message is due.
+func (b *Broker) take(queueName string, max int) ([]*Message, <-chan struct{}, time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	var taken []*Message
+	var next time.Time
+	kept := b.queues[queueName][:0]
+	for _, msg := range b.queues[queueName] {
+		if len(taken) < max && !msg.availableAt.After(now) {
+			msg.Attempts++
+			taken = append(taken, msg)
+			continue
+		}
+		if msg.availableAt.After(now) && (next.IsZero() || msg.availableAt.Before(next)) {
+			next = msg.availableAt
+		}
+		kept = append(kept, msg)
+	}
+	b.queues[queueName] = kept
+	return taken, b.published, next
+}
+
+// Len returns the number of messages waiting in the queue, including delayed ones
+func (b *Broker) Len(queueName string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.queues[queueName])
+}
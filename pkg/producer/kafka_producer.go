@@ -0,0 +1,40 @@
+package producer
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaProducer implements Producer for Kafka, publishing every queue to the topic of the same name
+type KafkaProducer struct {
+	writer *kafka.Writer
+}
+
+// NewKafkaProducer creates a new Kafka producer on a shared writer
+func NewKafkaProducer(writer *kafka.Writer) *KafkaProducer {
+	return &KafkaProducer{writer: writer}
+}
+
+// Publish writes a message to the topic and waits for the acks the writer requires
+func (p *KafkaProducer) Publish(ctx context.Context, queueName string, payload []byte) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultPublishTimeout)
+		defer cancel()
+	}
+
+	if err := p.writer.WriteMessages(ctx, kafka.Message{Topic: queueName, Value: payload}); err != nil {
+		return fmt.Errorf("failed to publish message: %w", err)
+	}
+
+	log.Printf("Published message to queue %s: %s", queueName, string(payload))
+	return nil
+}
+
+// Close is a no-op; the shared writer is closed by its owner
+func (p *KafkaProducer) Close() error {
+	return nil
+}
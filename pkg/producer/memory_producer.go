@@ -0,0 +1,28 @@
+package producer
+
+import (
+	"context"
+
+	"factorial-cal-services/pkg/memqueue"
+)
+
+// MemoryProducer implements Producer for an in-memory broker
+type MemoryProducer struct {
+	broker *memqueue.Broker
+}
+
+// NewMemoryProducer creates a new producer of an in-memory broker
+func NewMemoryProducer(broker *memqueue.Broker) *MemoryProducer {
+	return &MemoryProducer{broker: broker}
+}
+
+// Publish appends a message to the queue
+func (p *MemoryProducer) Publish(ctx context.Context, queueName string, payload []byte) error {
+	p.broker.Publish(queueName, payload)
+	return nil
+}
+
+// Close is a no-op; the broker lives as long as the process
+func (p *MemoryProducer) Close() error {
+	return nil
+}
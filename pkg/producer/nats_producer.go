@@ -0,0 +1,76 @@
+package producer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// NATSProducer implements Producer for NATS JetStream
+type NATSProducer struct {
+	js            jetstream.JetStream
+	declareStream func(ctx context.Context, queueName string) error
+
+	mu       sync.Mutex
+	declared map[string]bool
+}
+
+// NewNATSProducer creates a new JetStream producer on a shared connection. declareStream
+// declares the stream of a queue the first time it is published to, so a message published
+// before any worker subscribed is kept rather than rejected.
+func NewNATSProducer(js jetstream.JetStream, declareStream func(ctx context.Context, queueName string) error) *NATSProducer {
+	return &NATSProducer{js: js, declareStream: declareStream, declared: make(map[string]bool)}
+}
+
+// declare declares the stream of queueName once
+func (p *NATSProducer) declare(ctx context.Context, queueName string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.declared[queueName] {
+		return nil
+	}
+	if err := p.declareStream(ctx, queueName); err != nil {
+		return err
+	}
+	p.declared[queueName] = true
+	return nil
+}
+
+// Publish sends a message to the queue subject and waits for the stream to acknowledge it,
+// failing with ErrUnroutable when no stream captures the subject
+func (p *NATSProducer) Publish(ctx context.Context, queueName string, payload []byte) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultPublishTimeout)
+		defer cancel()
+	}
+
+	if err := p.declare(ctx, queueName); err != nil {
+		return err
+	}
+
+	messageID, err := newMessageID(payload)
+	if err != nil {
+		return fmt.Errorf("failed to generate message ID: %w", err)
+	}
+
+	// The message ID lets the stream drop a duplicate of a publish retried after a lost ack
+	if _, err := p.js.Publish(ctx, queueName, payload, jetstream.WithMsgID(messageID)); err != nil {
+		if errors.Is(err, jetstream.ErrNoStreamResponse) {
+			return fmt.Errorf("%w: %s", ErrUnroutable, queueName)
+		}
+		return fmt.Errorf("failed to publish message: %w", err)
+	}
+
+	log.Printf("Published message to queue %s: %s", queueName, string(payload))
+	return nil
+}
+
+// Close is a no-op; the shared connection is closed by its owner
+func (p *NATSProducer) Close() error {
+	return nil
+}
@@ -0,0 +1,35 @@
+package producer
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"factorial-cal-services/pkg/domain"
+	"factorial-cal-services/pkg/repository"
+)
+
+// PostgresProducer implements Producer for the queue_messages table
+type PostgresProducer struct {
+	repository repository.QueueRepository
+}
+
+// NewPostgresProducer creates a new producer inserting into the queue_messages table
+func NewPostgresProducer(repository repository.QueueRepository) *PostgresProducer {
+	return &PostgresProducer{repository: repository}
+}
+
+// Publish inserts a message into the queue; it is durable once the insert is committed
+func (p *PostgresProducer) Publish(ctx context.Context, queueName string, payload []byte) error {
+	if err := p.repository.Enqueue(&domain.QueueMessage{Queue: queueName, Payload: payload}); err != nil {
+		return fmt.Errorf("failed to publish message: %w", err)
+	}
+
+	log.Printf("Published message to queue %s: %s", queueName, string(payload))
+	return nil
+}
+
+// Close is a no-op; the database is closed by its owner
+func (p *PostgresProducer) Close() error {
+	return nil
+}
@@ -2,101 +2,239 @@ package producer
 
 import (
 	"context"
-	"crypto/tls"
-	"crypto/x509"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"log"
-	"os"
-	"strings"
+	"sync"
+	"time"
+
+	"factorial-cal-services/pkg/dto"
+	"factorial-cal-services/pkg/rabbitmq"
 
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
-// RabbitMQProducer implements Producer for RabbitMQ
-type RabbitMQProducer struct {
-	conn    *amqp.Connection
-	channel *amqp.Channel
+// DefaultPublishTimeout bounds the wait for a broker confirmation when ctx has no deadline
+const DefaultPublishTimeout = 5 * time.Second
+
+// returnsBufferSize leaves room for returns of publishes that gave up waiting, so the
+// connection reader never blocks on a return nobody reads before the next publish
+const returnsBufferSize = 16
+
+var (
+	// ErrPublishNacked is returned when the broker refuses to take responsibility for a message
+	ErrPublishNacked = errors.New("message was nacked by the broker")
+	// ErrUnroutable is returned when no queue is bound to the routing key of a message
+	ErrUnroutable = errors.New("message is unroutable")
+)
+
+// publishConfirmation is the pending broker confirmation of a publish
+type publishConfirmation interface {
+	WaitContext(ctx context.Context) (bool, error)
 }
 
-// NewRabbitMQProducer creates a new RabbitMQ producer
-func NewRabbitMQProducer(amqpURL string) (*RabbitMQProducer, error) {
-	var conn *amqp.Connection
-	var err error
+// confirmChannel is the part of a confirm-mode channel the producer publishes on
+type confirmChannel interface {
+	declare(queueName string) error
+	publish(ctx context.Context, queueName string, msg amqp.Publishing) (publishConfirmation, error)
+	IsClosed() bool
+	Close() error
+}
 
-	// Check if TLS is enabled by looking for RABBITMQ_CA
-	rootCA := os.Getenv("RABBITMQ_CA")
-	if rootCA != "" {
-		// Replace literal \n with actual newlines
-		rootCA = strings.ReplaceAll(rootCA, "\\n", "\n")
+// amqpConfirmChannel adapts an *amqp.Channel to confirmChannel
+type amqpConfirmChannel struct {
+	*amqp.Channel
+}
 
-		caCertPool := x509.NewCertPool()
-		if !caCertPool.AppendCertsFromPEM([]byte(rootCA)) {
-			return nil, fmt.Errorf("failed to append root CA cert")
-		}
+// declare declares the queue with the arguments the consumer declares it with
+func (c amqpConfirmChannel) declare(queueName string) error {
+	_, err := c.QueueDeclare(
+		queueName, // name
+		true,      // durable
+		false,     // delete when unused
+		false,     // exclusive
+		false,     // no-wait
+		nil,       // no arguments
+	)
+	return err
+}
 
-		tlsConfig := &tls.Config{
-			RootCAs:            caCertPool,
-			InsecureSkipVerify: false, // keep secure!
-		}
+func (c amqpConfirmChannel) publish(ctx context.Context, queueName string, msg amqp.Publishing) (publishConfirmation, error) {
+	confirmation, err := c.PublishWithDeferredConfirmWithContext(
+		ctx,
+		"",        // exchange
+		queueName, // routing key
+		true,      // mandatory: return the message if no queue is bound
+		false,     // immediate
+		msg,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return confirmation, nil
+}
 
-		conn, err = amqp.DialTLS(amqpURL, tlsConfig)
-	} else {
-		// Use plain connection for local development
-		conn, err = amqp.Dial(amqpURL)
+// RabbitMQProducer implements Producer for RabbitMQ.
+// The channel is in confirm mode and publishes are serialized. The broker sends the return
+// of an unroutable message before its ack; returns are matched to the pending publish by
+// message ID, so a late return of a publish that gave up waiting is never taken for another.
+// Every queue is declared before its first publish, so a message published before any
+// worker declared the queue is kept rather than returned as unroutable.
+type RabbitMQProducer struct {
+	openChannel func() (confirmChannel, <-chan amqp.Return, error)
+	channel     confirmChannel
+	returns     <-chan amqp.Return
+	declared    map[string]bool
+	mu          sync.Mutex
+}
+
+// NewRabbitMQProducer creates a new RabbitMQ producer on the shared connection
+func NewRabbitMQProducer(manager *rabbitmq.ConnectionManager) (*RabbitMQProducer, error) {
+	p := &RabbitMQProducer{
+		openChannel: func() (confirmChannel, <-chan amqp.Return, error) {
+			return openConfirmChannel(manager)
+		},
 	}
+	if err := p.reopen(); err != nil {
+		return nil, err
+	}
+
+	fmt.Println("Connected to RabbitMQ successfully!")
+
+	return p, nil
+}
 
+// openConfirmChannel opens a confirm-mode channel on the shared connection
+func openConfirmChannel(manager *rabbitmq.ConnectionManager) (confirmChannel, <-chan amqp.Return, error) {
+	ch, err := manager.Channel()
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+		return nil, nil, fmt.Errorf("failed to open channel: %w", err)
 	}
 
-	ch, err := conn.Channel()
-	if err != nil {
-		conn.Close()
-		return nil, fmt.Errorf("failed to open channel: %w", err)
+	if err := ch.Confirm(false); err != nil {
+		ch.Close()
+		return nil, nil, fmt.Errorf("failed to enable publisher confirms: %w", err)
 	}
 
-	fmt.Println("Connected to RabbitMQ successfully!")
+	returns := ch.NotifyReturn(make(chan amqp.Return, returnsBufferSize))
+	return amqpConfirmChannel{ch}, returns, nil
+}
 
-	return &RabbitMQProducer{
-		conn:    conn,
-		channel: ch,
-	}, nil
+// reopen opens a new channel, replacing one lost with the connection
+func (p *RabbitMQProducer) reopen() error {
+	ch, returns, err := p.openChannel()
+	if err != nil {
+		return err
+	}
+	p.channel = ch
+	p.returns = returns
+	p.declared = make(map[string]bool)
+	return nil
 }
 
-// Publish sends a message to the specified queue
+// Publish sends a persistent message to the specified queue and waits until the broker
+// confirms it, failing when it is nacked, unroutable or not confirmed before ctx is done
 func (p *RabbitMQProducer) Publish(ctx context.Context, queueName string, payload []byte) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultPublishTimeout)
+		defer cancel()
+	}
+
+	messageID, err := newMessageID(payload)
+	if err != nil {
+		return fmt.Errorf("failed to generate message ID: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.channel == nil || p.channel.IsClosed() {
+		if err := p.reopen(); err != nil {
+			return err
+		}
+	}
+
+	if !p.declared[queueName] {
+		if err := p.channel.declare(queueName); err != nil {
+			return fmt.Errorf("failed to declare queue %s: %w", queueName, err)
+		}
+		p.declared[queueName] = true
+	}
+
+	// Discard returns left over by publishes that gave up waiting for their confirmation
+	p.takeReturn("")
+
 	// Publish message
-	err := p.channel.PublishWithContext(
-		ctx,
-		"",        // exchange
-		queueName, // routing key
-		false,     // mandatory
-		false,     // immediate
-		amqp.Publishing{
-			DeliveryMode: amqp.Persistent,
-			ContentType:  "application/json",
-			Body:         payload,
-		},
-	)
+	confirmation, err := p.channel.publish(ctx, queueName, amqp.Publishing{
+		DeliveryMode: amqp.Persistent,
+		ContentType:  "application/json",
+		MessageId:    messageID,
+		Body:         payload,
+	})
 	if err != nil {
 		return fmt.Errorf("failed to publish message: %w", err)
 	}
 
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to wait for publish confirmation: %w", err)
+	}
+	if !acked {
+		return ErrPublishNacked
+	}
+
+	// The broker sends the return before the ack, so it is already buffered if there is one
+	if ret, ok := p.takeReturn(messageID); ok {
+		return fmt.Errorf("%w: %s (%d %s)", ErrUnroutable, queueName, ret.ReplyCode, ret.ReplyText)
+	}
+
 	log.Printf("Published message to queue %s: %s", queueName, string(payload))
 	return nil
 }
 
-// Close closes the RabbitMQ connection and channel
-func (p *RabbitMQProducer) Close() error {
-	if p.channel != nil {
-		if err := p.channel.Close(); err != nil {
-			return err
+// takeReturn drains the buffered returns and reports the one of messageID, if any.
+// Returns of other messages belong to publishes that already gave up and are dropped.
+func (p *RabbitMQProducer) takeReturn(messageID string) (amqp.Return, bool) {
+	var found amqp.Return
+	ok := false
+	for {
+		select {
+		case ret, open := <-p.returns:
+			if !open {
+				return found, ok
+			}
+			if messageID != "" && ret.MessageId == messageID {
+				found, ok = ret, true
+			}
+		default:
+			return found, ok
 		}
 	}
-	if p.conn != nil {
-		if err := p.conn.Close(); err != nil {
-			return err
-		}
+}
+
+// newMessageID returns the envelope message ID of payload, so every publish of a message
+// carries the same ID, or generates a random one for a payload without an envelope
+func newMessageID(payload []byte) (string, error) {
+	if messageID := dto.PeekMessageID(payload); messageID != "" {
+		return messageID, nil
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Close closes the RabbitMQ channel; the shared connection is closed by its manager
+func (p *RabbitMQProducer) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.channel != nil && !p.channel.IsClosed() {
+		return p.channel.Close()
 	}
 	return nil
 }
@@ -0,0 +1,171 @@
+package producer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// fakeConfirmation is a broker confirmation that is settled when acked is sent a value
+type fakeConfirmation struct {
+	acked chan bool
+}
+
+func (c *fakeConfirmation) WaitContext(ctx context.Context) (bool, error) {
+	select {
+	case acked := <-c.acked:
+		return acked, nil
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
+// fakeBroker scripts how each publish is answered, in the order the broker would:
+// returns first, then the confirmation
+type fakeBroker func(msg amqp.Publishing, returns chan<- amqp.Return, confirmation *fakeConfirmation)
+
+// fakeConfirmChannel answers publishes with the scripted brokers, one per publish
+type fakeConfirmChannel struct {
+	returns   chan amqp.Return
+	brokers   []fakeBroker
+	published []amqp.Publishing
+	declared  []string
+}
+
+func (c *fakeConfirmChannel) declare(queueName string) error {
+	c.declared = append(c.declared, queueName)
+	return nil
+}
+
+func (c *fakeConfirmChannel) publish(ctx context.Context, queueName string, msg amqp.Publishing) (publishConfirmation, error) {
+	confirmation := &fakeConfirmation{acked: make(chan bool, 1)}
+	c.brokers[len(c.published)](msg, c.returns, confirmation)
+	c.published = append(c.published, msg)
+	return confirmation, nil
+}
+
+func (c *fakeConfirmChannel) IsClosed() bool {
+	return false
+}
+
+func (c *fakeConfirmChannel) Close() error {
+	return nil
+}
+
+func newFakeProducer(brokers ...fakeBroker) (*RabbitMQProducer, *fakeConfirmChannel) {
+	ch := &fakeConfirmChannel{
+		returns: make(chan amqp.Return, returnsBufferSize),
+		brokers: brokers,
+	}
+	p := &RabbitMQProducer{
+		openChannel: func() (confirmChannel, <-chan amqp.Return, error) {
+			return ch, ch.returns, nil
+		},
+	}
+	p.reopen()
+	return p, ch
+}
+
+func ack(msg amqp.Publishing, returns chan<- amqp.Return, confirmation *fakeConfirmation) {
+	confirmation.acked <- true
+}
+
+func nack(msg amqp.Publishing, returns chan<- amqp.Return, confirmation *fakeConfirmation) {
+	confirmation.acked <- false
+}
+
+func returnAndAck(msg amqp.Publishing, returns chan<- amqp.Return, confirmation *fakeConfirmation) {
+	returns <- amqp.Return{MessageId: msg.MessageId, ReplyCode: amqp.NoRoute, ReplyText: "NO_ROUTE"}
+	confirmation.acked <- true
+}
+
+// neverConfirm leaves the publish unconfirmed, as a broker that stopped answering
+func neverConfirm(msg amqp.Publishing, returns chan<- amqp.Return, confirmation *fakeConfirmation) {}
+
+func TestRabbitMQProducer_Publish(t *testing.T) {
+	tests := []struct {
+		name    string
+		broker  fakeBroker
+		wantErr error
+	}{
+		{name: "acked", broker: ack},
+		{name: "nacked", broker: nack, wantErr: ErrPublishNacked},
+		{name: "unroutable", broker: returnAndAck, wantErr: ErrUnroutable},
+		{name: "not confirmed in time", broker: neverConfirm, wantErr: context.DeadlineExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, ch := newFakeProducer(tt.broker)
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			err := p.Publish(ctx, "factorial-cal-queue", []byte(`{"number":"5"}`))
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if len(ch.published) != 1 || ch.published[0].DeliveryMode != amqp.Persistent || ch.published[0].MessageId == "" {
+				t.Errorf("Expected one persistent message with an ID, got %+v", ch.published)
+			}
+		})
+	}
+}
+
+func TestRabbitMQProducer_Publish_LateReturnOfAnotherPublish(t *testing.T) {
+	var abandoned amqp.Publishing
+	p, _ := newFakeProducer(
+		// The first publish gives up before its return and confirmation arrive
+		func(msg amqp.Publishing, returns chan<- amqp.Return, confirmation *fakeConfirmation) {
+			abandoned = msg
+		},
+		// Its return arrives while the next publish waits, ahead of that publish's ack
+		func(msg amqp.Publishing, returns chan<- amqp.Return, confirmation *fakeConfirmation) {
+			returns <- amqp.Return{MessageId: abandoned.MessageId, ReplyCode: amqp.NoRoute, ReplyText: "NO_ROUTE"}
+			confirmation.acked <- true
+		},
+		// A later unroutable publish still gets its own return
+		returnAndAck,
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := p.Publish(ctx, "q", []byte("first")); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected the first publish to time out, got %v", err)
+	}
+	if err := p.Publish(context.Background(), "q", []byte("second")); err != nil {
+		t.Errorf("Expected the late return of another publish to be ignored, got %v", err)
+	}
+	if err := p.Publish(context.Background(), "q", []byte("third")); !errors.Is(err, ErrUnroutable) {
+		t.Errorf("Expected ErrUnroutable, got %v", err)
+	}
+}
+
+func TestRabbitMQProducer_Publish_DrainsStaleReturns(t *testing.T) {
+	p, ch := newFakeProducer(ack)
+	// Returns of publishes that gave up, more than fit a single slot
+	for i := 0; i < 3; i++ {
+		ch.returns <- amqp.Return{MessageId: "abandoned", ReplyCode: amqp.NoRoute}
+	}
+
+	if err := p.Publish(context.Background(), "q", []byte("payload")); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	if len(ch.returns) != 0 {
+		t.Errorf("Expected stale returns to be drained, %d left", len(ch.returns))
+	}
+}
+
+func TestRabbitMQProducer_Publish_DeclaresQueueOnce(t *testing.T) {
+	p, ch := newFakeProducer(ack, ack, ack)
+	for _, queueName := range []string{"q", "q", "q.priority"} {
+		if err := p.Publish(context.Background(), queueName, []byte("payload")); err != nil {
+			t.Fatalf("Publish failed: %v", err)
+		}
+	}
+	if len(ch.declared) != 2 || ch.declared[0] != "q" || ch.declared[1] != "q.priority" {
+		t.Errorf("Expected q and q.priority declared once each, got %v", ch.declared)
+	}
+}
@@ -0,0 +1,57 @@
+package queue
+
+import (
+	"fmt"
+	"time"
+
+	"factorial-cal-services/pkg/config"
+	"factorial-cal-services/pkg/consumer"
+	"factorial-cal-services/pkg/producer"
+
+	"gorm.io/gorm"
+)
+
+// Queue backends selectable with QUEUE_TYPE
+const (
+	TypeRabbitMQ = "rabbitmq"
+	TypeNATS     = "nats"
+	TypeKafka    = "kafka"
+	TypePostgres = "postgres"
+	TypeMemory   = "memory"
+)
+
+// ConsumerGroup is the NATS durable consumer and the Kafka consumer group workers share
+const ConsumerGroup = "factorial-cal-workers"
+
+// Backend is a connection to a queue backend shared by its producers and consumers
+type Backend interface {
+	NewProducer() (producer.Producer, error)
+	NewConsumer(retryPolicy consumer.RetryPolicy, batchWindow time.Duration) (consumer.Consumer, error)
+	// Healthy returns nil while the backend is reachable, or the reason it is not
+	Healthy() error
+	Close() error
+}
+
+// NewBackend connects to the queue backend selected by cfg.QUEUE_TYPE. The Postgres backend
+// stores messages in database. The memory backend is shared by everything in the process and
+// reaches no other process, so it is meant for tests and cmd/standalone, which runs the API,
+// worker and calculator together.
+func NewBackend(cfg *config.Config, database *gorm.DB) (Backend, error) {
+	switch cfg.QUEUE_TYPE {
+	case TypeRabbitMQ, "":
+		return newRabbitMQBackend(cfg)
+	case TypeNATS:
+		return newNATSBackend(cfg)
+	case TypeKafka:
+		return newKafkaBackend(cfg), nil
+	case TypePostgres:
+		if database == nil {
+			return nil, fmt.Errorf("queue type %s requires a database", TypePostgres)
+		}
+		return newPostgresBackend(database), nil
+	case TypeMemory:
+		return newMemoryBackend(), nil
+	default:
+		return nil, fmt.Errorf("unknown queue type: %s", cfg.QUEUE_TYPE)
+	}
+}
@@ -0,0 +1,59 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"factorial-cal-services/pkg/config"
+	"factorial-cal-services/pkg/consumer"
+)
+
+func TestNewBackend_Memory(t *testing.T) {
+	backend, err := NewBackend(&config.Config{QUEUE_TYPE: TypeMemory}, nil)
+	if err != nil {
+		t.Fatalf("NewBackend failed: %v", err)
+	}
+	defer backend.Close()
+
+	p, err := backend.NewProducer()
+	if err != nil {
+		t.Fatalf("NewProducer failed: %v", err)
+	}
+	// A backend created separately shares the process broker
+	other, _ := NewBackend(&config.Config{QUEUE_TYPE: TypeMemory}, nil)
+	c, err := other.NewConsumer(consumer.NewRetryPolicy(1, time.Millisecond, time.Millisecond), time.Millisecond)
+	if err != nil {
+		t.Fatalf("NewConsumer failed: %v", err)
+	}
+
+	if err := p.Publish(context.Background(), "backend-test", []byte("42")); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	received := make(chan string, 1)
+	go c.Consume(ctx, "backend-test", func(ctx context.Context, payload []byte) error {
+		received <- string(payload)
+		return nil
+	})
+
+	select {
+	case payload := <-received:
+		if payload != "42" {
+			t.Errorf("Expected payload 42, got %s", payload)
+		}
+	case <-ctx.Done():
+		t.Fatal("Expected the published message to be consumed")
+	}
+}
+
+func TestNewBackend_Errors(t *testing.T) {
+	if _, err := NewBackend(&config.Config{QUEUE_TYPE: "sqs"}, nil); err == nil {
+		t.Error("Expected an error for an unknown queue type")
+	}
+	if _, err := NewBackend(&config.Config{QUEUE_TYPE: TypePostgres}, nil); err == nil {
+		t.Error("Expected an error for the postgres queue without a database")
+	}
+}
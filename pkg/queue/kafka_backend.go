@@ -0,0 +1,72 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"factorial-cal-services/pkg/config"
+	"factorial-cal-services/pkg/consumer"
+	"factorial-cal-services/pkg/producer"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// kafkaHealthTimeout bounds the broker dial of a health check
+const kafkaHealthTimeout = 2 * time.Second
+
+type kafkaBackend struct {
+	brokers []string
+	writer  *kafka.Writer
+}
+
+// newKafkaBackend creates the writer shared by producers and the retries of consumers.
+// Kafka clients connect lazily, so an unreachable cluster surfaces on first use.
+func newKafkaBackend(cfg *config.Config) *kafkaBackend {
+	var brokers []string
+	for _, broker := range strings.Split(cfg.KAFKA_BROKERS, ",") {
+		if broker = strings.TrimSpace(broker); broker != "" {
+			brokers = append(brokers, broker)
+		}
+	}
+
+	return &kafkaBackend{
+		brokers: brokers,
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(brokers...),
+			Balancer:               &kafka.Hash{},
+			RequiredAcks:           kafka.RequireAll,
+			AllowAutoTopicCreation: true,
+		},
+	}
+}
+
+func (b *kafkaBackend) NewProducer() (producer.Producer, error) {
+	return producer.NewKafkaProducer(b.writer), nil
+}
+
+func (b *kafkaBackend) NewConsumer(retryPolicy consumer.RetryPolicy, batchWindow time.Duration) (consumer.Consumer, error) {
+	return consumer.NewKafkaConsumer(b.brokers, ConsumerGroup, b.writer, retryPolicy, batchWindow), nil
+}
+
+// Healthy reports whether any broker accepts a connection
+func (b *kafkaBackend) Healthy() error {
+	ctx, cancel := context.WithTimeout(context.Background(), kafkaHealthTimeout)
+	defer cancel()
+
+	var errs []error
+	for _, broker := range b.brokers {
+		conn, err := kafka.DialContext(ctx, "tcp", broker)
+		if err == nil {
+			return conn.Close()
+		}
+		errs = append(errs, err)
+	}
+	return fmt.Errorf("no kafka broker is reachable: %w", errors.Join(errs...))
+}
+
+func (b *kafkaBackend) Close() error {
+	return b.writer.Close()
+}
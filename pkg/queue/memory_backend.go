@@ -0,0 +1,37 @@
+package queue
+
+import (
+	"time"
+
+	"factorial-cal-services/pkg/consumer"
+	"factorial-cal-services/pkg/memqueue"
+	"factorial-cal-services/pkg/producer"
+)
+
+// processBroker is shared by every memory backend of the process, so producers and
+// consumers created separately in one test still exchange messages
+var processBroker = memqueue.NewBroker()
+
+type memoryBackend struct {
+	broker *memqueue.Broker
+}
+
+func newMemoryBackend() *memoryBackend {
+	return &memoryBackend{broker: processBroker}
+}
+
+func (b *memoryBackend) NewProducer() (producer.Producer, error) {
+	return producer.NewMemoryProducer(b.broker), nil
+}
+
+func (b *memoryBackend) NewConsumer(retryPolicy consumer.RetryPolicy, batchWindow time.Duration) (consumer.Consumer, error) {
+	return consumer.NewMemoryConsumer(b.broker, retryPolicy, batchWindow), nil
+}
+
+func (b *memoryBackend) Healthy() error {
+	return nil
+}
+
+func (b *memoryBackend) Close() error {
+	return nil
+}
@@ -0,0 +1,60 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"factorial-cal-services/pkg/config"
+	"factorial-cal-services/pkg/consumer"
+	"factorial-cal-services/pkg/producer"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+type natsBackend struct {
+	conn *nats.Conn
+	js   jetstream.JetStream
+}
+
+// newNATSBackend connects to NATS, reconnecting forever when the connection drops
+func newNATSBackend(cfg *config.Config) (*natsBackend, error) {
+	conn, err := nats.Connect(cfg.NATS_URL,
+		nats.Name("factorial-cal-services"),
+		nats.MaxReconnects(-1),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create JetStream context: %w", err)
+	}
+	return &natsBackend{conn: conn, js: js}, nil
+}
+
+func (b *natsBackend) NewProducer() (producer.Producer, error) {
+	return producer.NewNATSProducer(b.js, func(ctx context.Context, queueName string) error {
+		_, err := consumer.DeclareNATSStream(ctx, b.js, queueName)
+		return err
+	}), nil
+}
+
+func (b *natsBackend) NewConsumer(retryPolicy consumer.RetryPolicy, batchWindow time.Duration) (consumer.Consumer, error) {
+	return consumer.NewNATSConsumer(b.js, ConsumerGroup, retryPolicy, batchWindow), nil
+}
+
+func (b *natsBackend) Healthy() error {
+	if status := b.conn.Status(); status != nats.CONNECTED {
+		return fmt.Errorf("nats is not connected: %s", status)
+	}
+	return nil
+}
+
+func (b *natsBackend) Close() error {
+	b.conn.Close()
+	return nil
+}
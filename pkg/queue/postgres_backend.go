@@ -0,0 +1,37 @@
+package queue
+
+import (
+	"time"
+
+	"factorial-cal-services/pkg/consumer"
+	"factorial-cal-services/pkg/producer"
+	"factorial-cal-services/pkg/repository"
+
+	"gorm.io/gorm"
+)
+
+type postgresBackend struct {
+	repository repository.QueueRepository
+}
+
+// newPostgresBackend stores messages in the queue_messages table of database
+func newPostgresBackend(database *gorm.DB) *postgresBackend {
+	return &postgresBackend{repository: repository.NewQueueRepository(database)}
+}
+
+func (b *postgresBackend) NewProducer() (producer.Producer, error) {
+	return producer.NewPostgresProducer(b.repository), nil
+}
+
+func (b *postgresBackend) NewConsumer(retryPolicy consumer.RetryPolicy, batchWindow time.Duration) (consumer.Consumer, error) {
+	return consumer.NewPostgresConsumer(b.repository, retryPolicy, batchWindow), nil
+}
+
+func (b *postgresBackend) Healthy() error {
+	return b.repository.Ping()
+}
+
+// Close is a no-op; the database is closed by its owner
+func (b *postgresBackend) Close() error {
+	return nil
+}
@@ -0,0 +1,39 @@
+package queue
+
+import (
+	"time"
+
+	"factorial-cal-services/pkg/config"
+	"factorial-cal-services/pkg/consumer"
+	"factorial-cal-services/pkg/producer"
+	"factorial-cal-services/pkg/rabbitmq"
+)
+
+type rabbitMQBackend struct {
+	manager *rabbitmq.ConnectionManager
+}
+
+// newRabbitMQBackend connects to RabbitMQ, re-establishing the connection when it drops
+func newRabbitMQBackend(cfg *config.Config) (*rabbitMQBackend, error) {
+	manager, err := rabbitmq.NewConnectionManager(cfg.RabbitMQURL())
+	if err != nil {
+		return nil, err
+	}
+	return &rabbitMQBackend{manager: manager}, nil
+}
+
+func (b *rabbitMQBackend) NewProducer() (producer.Producer, error) {
+	return producer.NewRabbitMQProducer(b.manager)
+}
+
+func (b *rabbitMQBackend) NewConsumer(retryPolicy consumer.RetryPolicy, batchWindow time.Duration) (consumer.Consumer, error) {
+	return consumer.NewRabbitMQConsumer(b.manager, retryPolicy, batchWindow)
+}
+
+func (b *rabbitMQBackend) Healthy() error {
+	return b.manager.Healthy()
+}
+
+func (b *rabbitMQBackend) Close() error {
+	return b.manager.Close()
+}
@@ -0,0 +1,275 @@
+package rabbitmq
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const (
+	// minReconnectDelay is the base of the exponential reconnection backoff
+	minReconnectDelay = 500 * time.Millisecond
+	// maxReconnectDelay caps the reconnection backoff
+	maxReconnectDelay = 30 * time.Second
+)
+
+var (
+	// ErrNotConnected is returned while the connection is down and being re-established
+	ErrNotConnected = errors.New("rabbitmq is not connected")
+	// ErrClosed is returned once the connection manager has been closed
+	ErrClosed = errors.New("rabbitmq connection manager is closed")
+)
+
+// connection is the part of an *amqp.Connection the manager watches and opens channels on
+type connection interface {
+	NotifyClose(receiver chan *amqp.Error) chan *amqp.Error
+	Channel() (*amqp.Channel, error)
+	IsClosed() bool
+	Close() error
+}
+
+// ConnectionManager owns a RabbitMQ connection shared by producers and consumers.
+// It watches the connection and re-dials with jittered exponential backoff when it drops;
+// users open fresh channels from it after a reconnection.
+type ConnectionManager struct {
+	dial func() (connection, error)
+
+	mu      sync.RWMutex
+	conn    connection
+	lastErr error
+	// ready is closed while connected and replaced by an open channel when disconnected
+	ready  chan struct{}
+	closed chan struct{}
+}
+
+// NewConnectionManager dials RabbitMQ once, failing fast on a bad configuration,
+// and keeps the connection alive in the background
+func NewConnectionManager(amqpURL string) (*ConnectionManager, error) {
+	tlsConfig, err := tlsConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return newConnectionManager(func() (connection, error) {
+		conn, err := dial(amqpURL, tlsConfig)
+		if err != nil {
+			return nil, err
+		}
+		return conn, nil
+	})
+}
+
+// newConnectionManager connects with dial and keeps the connection alive in the background
+func newConnectionManager(dial func() (connection, error)) (*ConnectionManager, error) {
+	m := &ConnectionManager{
+		dial:   dial,
+		ready:  make(chan struct{}),
+		closed: make(chan struct{}),
+	}
+
+	conn, err := m.dial()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+	}
+	m.setConnection(conn)
+	go m.watch(conn)
+
+	return m, nil
+}
+
+// tlsConfigFromEnv returns the TLS configuration when RABBITMQ_CA is set, nil otherwise
+func tlsConfigFromEnv() (*tls.Config, error) {
+	rootCA := os.Getenv("RABBITMQ_CA")
+	if rootCA == "" {
+		// Use plain connection for local development
+		return nil, nil
+	}
+
+	// Replace literal \n with actual newlines
+	rootCA = strings.ReplaceAll(rootCA, "\\n", "\n")
+
+	caCertPool := x509.NewCertPool()
+	if !caCertPool.AppendCertsFromPEM([]byte(rootCA)) {
+		return nil, fmt.Errorf("failed to append root CA cert")
+	}
+
+	return &tls.Config{
+		RootCAs:            caCertPool,
+		InsecureSkipVerify: false, // keep secure!
+	}, nil
+}
+
+// dial connects to amqpURL, over TLS when tlsConfig is set
+func dial(amqpURL string, tlsConfig *tls.Config) (*amqp.Connection, error) {
+	if tlsConfig != nil {
+		return amqp.DialTLS(amqpURL, tlsConfig)
+	}
+	return amqp.Dial(amqpURL)
+}
+
+// watch waits for the connection to drop and reconnects until the manager is closed
+func (m *ConnectionManager) watch(conn connection) {
+	for {
+		closeErr, ok := <-conn.NotifyClose(make(chan *amqp.Error, 1))
+		select {
+		case <-m.closed:
+			// Closed on purpose
+			return
+		default:
+		}
+
+		// A connection that dropped before NotifyClose was registered reports no error
+		var reason error = ErrNotConnected
+		if ok && closeErr != nil {
+			reason = closeErr
+		}
+		log.Printf("RabbitMQ connection lost: %v", reason)
+		m.setDisconnected(reason)
+
+		conn = m.reconnect()
+		if conn == nil {
+			return
+		}
+		log.Println("RabbitMQ connection re-established")
+	}
+}
+
+// reconnect dials until it succeeds or the manager is closed, in which case it returns nil
+func (m *ConnectionManager) reconnect() connection {
+	for attempt := 0; ; attempt++ {
+		select {
+		case <-m.closed:
+			return nil
+		case <-time.After(reconnectDelay(attempt)):
+		}
+
+		conn, err := m.dial()
+		if err != nil {
+			log.Printf("RabbitMQ reconnection attempt %d failed: %v", attempt+1, err)
+			m.setDisconnected(err)
+			continue
+		}
+
+		select {
+		case <-m.closed:
+			conn.Close()
+			return nil
+		default:
+		}
+		m.setConnection(conn)
+		return conn
+	}
+}
+
+// reconnectDelay returns a full-jitter exponential delay so restarted brokers are not stampeded
+func reconnectDelay(attempt int) time.Duration {
+	delay := minReconnectDelay
+	for i := 0; i < attempt && delay < maxReconnectDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, maxReconnectDelay)
+	return delay/2 + rand.N(delay/2+1)
+}
+
+func (m *ConnectionManager) setConnection(conn connection) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.conn = conn
+	m.lastErr = nil
+	close(m.ready)
+}
+
+func (m *ConnectionManager) setDisconnected(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.conn != nil {
+		m.conn = nil
+		m.ready = make(chan struct{})
+	}
+	m.lastErr = err
+}
+
+// Channel opens a new channel on the current connection
+func (m *ConnectionManager) Channel() (*amqp.Channel, error) {
+	m.mu.RLock()
+	conn := m.conn
+	m.mu.RUnlock()
+
+	select {
+	case <-m.closed:
+		return nil, ErrClosed
+	default:
+	}
+	if conn == nil || conn.IsClosed() {
+		return nil, ErrNotConnected
+	}
+	return conn.Channel()
+}
+
+// WaitConnected blocks until the connection is up, ctx is done or the manager is closed
+func (m *ConnectionManager) WaitConnected(ctx context.Context) error {
+	m.mu.RLock()
+	ready := m.ready
+	m.mu.RUnlock()
+
+	// A closed manager may still hold the ready channel of its last connection
+	select {
+	case <-m.closed:
+		return ErrClosed
+	default:
+	}
+	select {
+	case <-ready:
+		return nil
+	case <-m.closed:
+		return ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Healthy returns nil while connected, or the reason the connection is down
+func (m *ConnectionManager) Healthy() error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	select {
+	case <-m.closed:
+		return ErrClosed
+	default:
+	}
+	if m.conn == nil || m.conn.IsClosed() {
+		if m.lastErr != nil {
+			return fmt.Errorf("%w: %v", ErrNotConnected, m.lastErr)
+		}
+		return ErrNotConnected
+	}
+	return nil
+}
+
+// Close stops reconnecting and closes the connection
+func (m *ConnectionManager) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	select {
+	case <-m.closed:
+		return nil
+	default:
+	}
+	close(m.closed)
+
+	if m.conn != nil && !m.conn.IsClosed() {
+		return m.conn.Close()
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"factorial-cal-services/pkg/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// batchRepository implements BatchRepository interface
+type batchRepository struct {
+	db *gorm.DB
+}
+
+// BatchRepository defines the interface for range batch operations
+type BatchRepository interface {
+	Create(batch *domain.FactorialBatch) error
+	FindByBatchID(batchID string) (*domain.FactorialBatch, error)
+}
+
+// NewBatchRepository creates a new batch repository
+func NewBatchRepository(db *gorm.DB) BatchRepository {
+	return &batchRepository{
+		db: db,
+	}
+}
+
+// Create inserts a new batch record
+func (r *batchRepository) Create(batch *domain.FactorialBatch) error {
+	return r.db.Create(batch).Error
+}
+
+// FindByBatchID retrieves a batch by its public ID
+func (r *batchRepository) FindByBatchID(batchID string) (*domain.FactorialBatch, error) {
+	var batch domain.FactorialBatch
+
+	db := r.db.Session(&gorm.Session{
+		Logger: logger.Discard, // Disable print error when not found
+	})
+	result := db.Where("batch_id = ?", batchID).First(&batch)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &batch, nil
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"time"
+
+	"factorial-cal-services/pkg/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// callbackRepository implements CallbackRepository interface
+type callbackRepository struct {
+	db *gorm.DB
+}
+
+// CallbackRepository defines the interface for webhook callback operations
+type CallbackRepository interface {
+	Create(callback *domain.FactorialCallback) error
+	FindByCallbackID(callbackID string) (*domain.FactorialCallback, error)
+	FindDue(now time.Time, limit int) ([]domain.FactorialCallback, error)
+	RecordDelivery(callback *domain.FactorialCallback, delivery *domain.FactorialCallbackDelivery) error
+	ListDeliveries(callbackID string) ([]domain.FactorialCallbackDelivery, error)
+}
+
+// NewCallbackRepository creates a new callback repository
+func NewCallbackRepository(db *gorm.DB) CallbackRepository {
+	return &callbackRepository{
+		db: db,
+	}
+}
+
+// Create inserts a new callback record
+func (r *callbackRepository) Create(callback *domain.FactorialCallback) error {
+	return r.db.Create(callback).Error
+}
+
+// FindByCallbackID retrieves a callback by its public ID
+func (r *callbackRepository) FindByCallbackID(callbackID string) (*domain.FactorialCallback, error) {
+	var callback domain.FactorialCallback
+
+	db := r.db.Session(&gorm.Session{
+		Logger: logger.Discard, // Disable print error when not found
+	})
+	result := db.Where("callback_id = ?", callbackID).First(&callback)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &callback, nil
+}
+
+// FindDue retrieves pending callbacks whose factorial is done and whose next attempt is due
+func (r *callbackRepository) FindDue(now time.Time, limit int) ([]domain.FactorialCallback, error) {
+	var callbacks []domain.FactorialCallback
+	result := r.db.Model(&domain.FactorialCallback{}).
+		Select("factorial_callbacks.*").
+		Joins("JOIN factorial_calculations ON factorial_calculations.number = factorial_callbacks.number").
+		Where("factorial_callbacks.status = ? AND factorial_callbacks.next_attempt_at <= ? AND factorial_calculations.status = ?",
+			domain.CallbackStatusPending, now, domain.StatusDone).
+		Order("factorial_callbacks.next_attempt_at").
+		Limit(limit).
+		Find(&callbacks)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return callbacks, nil
+}
+
+// RecordDelivery atomically appends a delivery attempt to the log and updates the callback state
+func (r *callbackRepository) RecordDelivery(callback *domain.FactorialCallback, delivery *domain.FactorialCallbackDelivery) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(delivery).Error; err != nil {
+			return err
+		}
+
+		result := tx.Model(&domain.FactorialCallback{}).
+			Where("callback_id = ?", callback.CallbackID).
+			Updates(map[string]any{
+				"status":          callback.Status,
+				"attempts":        callback.Attempts,
+				"next_attempt_at": callback.NextAttemptAt,
+				"last_error":      callback.LastError,
+			})
+
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		return nil
+	})
+}
+
+// ListDeliveries retrieves the delivery log of a callback, oldest attempt first
+func (r *callbackRepository) ListDeliveries(callbackID string) ([]domain.FactorialCallbackDelivery, error) {
+	var deliveries []domain.FactorialCallbackDelivery
+	result := r.db.Where("callback_id = ?", callbackID).
+		Order("attempt").
+		Find(&deliveries)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return deliveries, nil
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"factorial-cal-services/pkg/domain"
 
@@ -19,8 +20,15 @@ type factorialRepository struct {
 type FactorialRepository interface {
 	Create(calc *domain.FactorialCalculation) error
 	FindByNumber(number int64) (*domain.FactorialCalculation, error)
+	FindLastMaterializedBefore(number int64) (*domain.FactorialCalculation, error)
+	FindByStatus(status string, limit int) ([]domain.FactorialCalculation, error)
+	FindMaterializedAfter(number int64, limit int) ([]domain.FactorialCalculation, error)
 	UpdateStatus(number string, status string) error
-	UpdateWithCurrentNumber(number int64, s3Key string, checksum string, size int64, status string, bucket string) error
+	UpdateWithCurrentNumber(number int64, object *domain.StoredObject, checksum string, size int64, status string) error
+	UpdateResult(number int64, object *domain.StoredObject, checksum string, size int64, status string) error
+	UpdateDerivedWithCurrentNumber(number int64, checkpointNumber int64, checksum string, size int64, status string) error
+	CountByStatusInRange(from int64, to int64, status string) (int64, error)
+	CountByStatusUpdatedSince(status string, since time.Time) (int64, error)
 }
 
 // NewFactorialRepository creates a new factorial repository
@@ -51,6 +59,83 @@ func (r *factorialRepository) FindByNumber(number int64) (*domain.FactorialCalcu
 	return &calc, nil
 }
 
+// FindLastMaterializedBefore retrieves the highest done factorial below number that has an object of its own
+func (r *factorialRepository) FindLastMaterializedBefore(number int64) (*domain.FactorialCalculation, error) {
+	var calc domain.FactorialCalculation
+
+	db := r.db.Session(&gorm.Session{
+		Logger: logger.Discard, // Disable print error when not found
+	})
+	result := db.Where("number < ? AND status = ? AND derived = ? AND s3_key <> ''", number, domain.StatusDone, false).
+		Order("number DESC").
+		First(&calc)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &calc, nil
+}
+
+// FindByStatus retrieves up to limit calculations with the given status, lowest number first
+func (r *factorialRepository) FindByStatus(status string, limit int) ([]domain.FactorialCalculation, error) {
+	var calcs []domain.FactorialCalculation
+	result := r.db.Where("status = ?", status).
+		Order("number").
+		Limit(limit).
+		Find(&calcs)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return calcs, nil
+}
+
+// FindMaterializedAfter retrieves up to limit done factorials above number that have an object
+// of their own, lowest number first
+func (r *factorialRepository) FindMaterializedAfter(number int64, limit int) ([]domain.FactorialCalculation, error) {
+	var calcs []domain.FactorialCalculation
+	result := r.db.Where("number > ? AND status = ? AND derived = ? AND s3_key <> ''", number, domain.StatusDone, false).
+		Order("number").
+		Limit(limit).
+		Find(&calcs)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return calcs, nil
+}
+
+// CountByStatusInRange counts calculations in [from, to] with the given status
+func (r *factorialRepository) CountByStatusInRange(from int64, to int64, status string) (int64, error) {
+	var count int64
+	result := r.db.Model(&domain.FactorialCalculation{}).
+		Where("number BETWEEN ? AND ? AND status = ?", from, to, status).
+		Count(&count)
+
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return count, nil
+}
+
+// CountByStatusUpdatedSince counts calculations with the given status last updated at or after since
+func (r *factorialRepository) CountByStatusUpdatedSince(status string, since time.Time) (int64, error) {
+	var count int64
+	result := r.db.Model(&domain.FactorialCalculation{}).
+		Where("status = ? AND updated_at >= ?", status, since).
+		Count(&count)
+
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return count, nil
+}
+
 // UpdateStatus updates the status of a factorial calculation
 func (r *factorialRepository) UpdateStatus(number string, status string) error {
 	result := r.db.Model(&domain.FactorialCalculation{}).
@@ -68,27 +153,82 @@ func (r *factorialRepository) UpdateStatus(number string, status string) error {
 	return nil
 }
 
+// UpdateResult updates factorial metadata without moving the current calculated number
+func (r *factorialRepository) UpdateResult(
+	number int64,
+	object *domain.StoredObject,
+	checksum string,
+	size int64,
+	status string,
+) error {
+	result := r.db.Model(&domain.FactorialCalculation{}).
+		Where("number = ?", number).
+		Updates(storedObjectFields(number, object, checksum, size, status))
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
 // UpdateWithCurrentNumber atomically updates factorial metadata and current calculated number
 func (r *factorialRepository) UpdateWithCurrentNumber(
 	number int64,
-	s3Key string,
+	object *domain.StoredObject,
+	checksum string,
+	size int64,
+	status string,
+) error {
+	return r.updateWithCurrentNumber(number, storedObjectFields(number, object, checksum, size, status))
+}
+
+// storedObjectFields returns the columns of a factorial materialized as object
+func storedObjectFields(number int64, object *domain.StoredObject, checksum string, size int64, status string) map[string]any {
+	return map[string]any{
+		"s3_key":            object.Key,
+		"checksum":          checksum,
+		"size":              size,
+		"encoding":          object.Encoding,
+		"stored_size":       object.Size,
+		"status":            status,
+		"bucket":            object.Bucket,
+		"derived":           false,
+		"checkpoint_number": number,
+	}
+}
+
+// UpdateDerivedWithCurrentNumber atomically marks a factorial as derivable from a checkpoint
+// (no object of its own) and updates current calculated number
+func (r *factorialRepository) UpdateDerivedWithCurrentNumber(
+	number int64,
+	checkpointNumber int64,
 	checksum string,
 	size int64,
 	status string,
-	bucket string,
 ) error {
+	return r.updateWithCurrentNumber(number, map[string]any{
+		"s3_key":            "",
+		"checksum":          checksum,
+		"size":              size,
+		"stored_size":       0,
+		"status":            status,
+		"derived":           true,
+		"checkpoint_number": checkpointNumber,
+	})
+}
+
+func (r *factorialRepository) updateWithCurrentNumber(number int64, fields map[string]any) error {
 	// Use transaction to ensure atomicity
 	return r.db.Transaction(func(tx *gorm.DB) error {
 		// Update factorial calculation metadata
 		result := tx.Model(&domain.FactorialCalculation{}).
 			Where("number = ?", number).
-			Updates(map[string]any{
-				"s3_key":   s3Key,
-				"checksum": checksum,
-				"size":     size,
-				"status":   status,
-				"bucket":   bucket,
-			})
+			Updates(fields)
 
 		if result.Error != nil {
 			return result.Error
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"time"
+
+	"factorial-cal-services/pkg/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// idempotencyRepository implements IdempotencyRepository interface
+type idempotencyRepository struct {
+	db *gorm.DB
+}
+
+// IdempotencyRepository defines the interface for idempotency key operations.
+// Keys are created together with the outbox message of their submission, see OutboxRepository.Enqueue.
+type IdempotencyRepository interface {
+	Find(clientID, key string) (*domain.IdempotencyKey, error)
+	Delete(id int64) error
+	DeleteExpired(now time.Time) (int64, error)
+}
+
+// NewIdempotencyRepository creates a new idempotency repository
+func NewIdempotencyRepository(db *gorm.DB) IdempotencyRepository {
+	return &idempotencyRepository{
+		db: db,
+	}
+}
+
+// Find retrieves the key of a client, expired or not
+func (r *idempotencyRepository) Find(clientID, key string) (*domain.IdempotencyKey, error) {
+	var record domain.IdempotencyKey
+
+	db := r.db.Session(&gorm.Session{
+		Logger: logger.Discard, // Disable print error when not found
+	})
+	result := db.Where("client_id = ? AND idempotency_key = ?", clientID, key).First(&record)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &record, nil
+}
+
+// Delete removes a key so it can be reused
+func (r *idempotencyRepository) Delete(id int64) error {
+	return r.db.Delete(&domain.IdempotencyKey{}, id).Error
+}
+
+// DeleteExpired removes every key that expired before now and returns how many were removed
+func (r *idempotencyRepository) DeleteExpired(now time.Time) (int64, error) {
+	result := r.db.Where("expires_at <= ?", now).Delete(&domain.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
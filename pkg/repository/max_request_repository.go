@@ -19,6 +19,8 @@ type MaxRequestRepository interface {
 	GetMaxNumber() (int64, error)
 	UpdateMaxNumber(maxNumber int64) error
 	SetMaxNumberIfGreater(maxNumber int64) (int64, error)
+	GetPriorityMaxNumber() (int64, error)
+	SetPriorityMaxNumberIfGreater(maxNumber int64) (int64, error)
 }
 
 // NewMaxRequestRepository creates a new max request repository
@@ -28,7 +30,7 @@ func NewMaxRequestRepository(db *gorm.DB) MaxRequestRepository {
 	}
 }
 
-// GetMaxNumber retrieves the current maximum requested number
+// GetMaxNumber retrieves the current maximum requested number of every priority
 func (r *maxRequestRepository) GetMaxNumber() (int64, error) {
 	var maxReq domain.FactorialMaxRequestNumber
 	result := r.db.Order("max_number DESC").First(&maxReq)
@@ -50,7 +52,7 @@ func (r *maxRequestRepository) UpdateMaxNumber(maxNumber int64) error {
 	db := r.db.Session(&gorm.Session{
 		Logger: logger.Discard, // Disable print error when not found
 	})
-	result := db.First(&existing)
+	result := db.Where("priority = ?", domain.PriorityNormal).First(&existing)
 
 	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 		// Create new record
@@ -68,10 +70,37 @@ func (r *maxRequestRepository) UpdateMaxNumber(maxNumber int64) error {
 	return r.db.Model(&existing).Update("max_number", maxNumber).Error
 }
 
-// SetMaxNumberIfGreater updates the max number only if the new value is greater
+// SetMaxNumberIfGreater updates the max number of normal requests only if the new value is greater
 func (r *maxRequestRepository) SetMaxNumberIfGreater(maxNumber int64) (int64, error) {
+	return r.setMaxNumberIfGreater(domain.PriorityNormal, maxNumber)
+}
+
+// GetPriorityMaxNumber retrieves the maximum number requested with high priority
+func (r *maxRequestRepository) GetPriorityMaxNumber() (int64, error) {
+	var maxReq domain.FactorialMaxRequestNumber
+	db := r.db.Session(&gorm.Session{
+		Logger: logger.Discard, // Disable print error when not found
+	})
+	result := db.Where("priority = ?", domain.PriorityHigh).First(&maxReq)
+
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return 0, nil // Return "0" if no record exists
+		}
+		return 0, result.Error
+	}
+
+	return maxReq.MaxNumber, nil
+}
+
+// SetPriorityMaxNumberIfGreater updates the max number of high priority requests only if the new value is greater
+func (r *maxRequestRepository) SetPriorityMaxNumberIfGreater(maxNumber int64) (int64, error) {
+	return r.setMaxNumberIfGreater(domain.PriorityHigh, maxNumber)
+}
+
+func (r *maxRequestRepository) setMaxNumberIfGreater(priority int, maxNumber int64) (int64, error) {
 	query := r.db.Model(&domain.FactorialMaxRequestNumber{}).
-		Where("max_number < ?", maxNumber).
+		Where("priority = ? AND max_number < ?", priority, maxNumber).
 		Update("max_number", maxNumber)
 	rowAffected, err := query.RowsAffected, query.Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
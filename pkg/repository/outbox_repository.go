@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"time"
+
+	"factorial-cal-services/pkg/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// outboxRepository implements OutboxRepository interface
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+// OutboxRepository defines the interface for the transactional outbox
+type OutboxRepository interface {
+	Enqueue(submission *domain.FactorialSubmission, message *domain.OutboxMessage, records ...any) error
+	RelayPending(limit, maxAttempts int, retryDelay func(attempts int) time.Duration, relay func(message *domain.OutboxMessage) error) (int, error)
+	DeleteSentBefore(before time.Time) (int64, error)
+}
+
+// NewOutboxRepository creates a new outbox repository
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{
+		db: db,
+	}
+}
+
+// Enqueue inserts the pending submission, its records and its message in one transaction, so
+// the message is relayed if and only if the submission and the records it belongs to were committed
+func (r *outboxRepository) Enqueue(submission *domain.FactorialSubmission, message *domain.OutboxMessage, records ...any) error {
+	submission.Status = domain.SubmissionStatusPending
+	message.SubmissionID = submission.SubmissionID
+	if message.NextAttemptAt.IsZero() {
+		message.NextAttemptAt = time.Now()
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(submission).Error; err != nil {
+			return err
+		}
+		for _, record := range records {
+			if err := tx.Create(record).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(message).Error
+	})
+}
+
+// RelayPending locks up to limit due messages in insertion order, skipping rows locked by
+// concurrent relays, and calls relay for each. Relayed messages are marked sent and their
+// submissions published. The first failure is recorded on its message and submission and
+// stops the run, so an unreachable broker is not waited for once per message. The failed
+// message is due again after retryDelay of its attempts, so it does not hold up the messages
+// behind it, and once it failed maxAttempts times it is parked as failed with its submission.
+// It returns the number of messages marked sent and the relay failure, if any.
+func (r *outboxRepository) RelayPending(limit, maxAttempts int, retryDelay func(attempts int) time.Duration, relay func(message *domain.OutboxMessage) error) (int, error) {
+	sent := 0
+	var relayErr error
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var messages []domain.OutboxMessage
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("sent_at IS NULL AND failed_at IS NULL AND next_attempt_at <= ?", time.Now()).
+			Order("id").
+			Limit(limit).
+			Find(&messages)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		for i := range messages {
+			message := &messages[i]
+			if relayErr = relay(message); relayErr != nil {
+				return recordFailure(tx, message, relayErr, maxAttempts, retryDelay)
+			}
+
+			now := time.Now()
+			err := tx.Model(&domain.OutboxMessage{}).
+				Where("id = ?", message.ID).
+				Updates(map[string]any{
+					"attempts":   gorm.Expr("attempts + 1"),
+					"last_error": "",
+					"sent_at":    now,
+				}).Error
+			if err != nil {
+				return err
+			}
+			err = updateSubmission(tx, message.SubmissionID, map[string]any{
+				"status":       domain.SubmissionStatusPublished,
+				"last_error":   "",
+				"published_at": now,
+			})
+			if err != nil {
+				return err
+			}
+			sent++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return sent, relayErr
+}
+
+// recordFailure schedules the next attempt of a message that failed to publish, or parks it
+// and fails its submission once it failed maxAttempts times
+func recordFailure(tx *gorm.DB, message *domain.OutboxMessage, relayErr error, maxAttempts int, retryDelay func(attempts int) time.Duration) error {
+	now := time.Now()
+	attempts := message.Attempts + 1
+	updates := map[string]any{
+		"attempts":        attempts,
+		"last_error":      relayErr.Error(),
+		"next_attempt_at": now.Add(retryDelay(attempts)),
+	}
+	submissionUpdates := map[string]any{"last_error": relayErr.Error()}
+	if attempts >= maxAttempts {
+		updates["failed_at"] = now
+		submissionUpdates["status"] = domain.SubmissionStatusFailed
+	}
+
+	err := tx.Model(&domain.OutboxMessage{}).
+		Where("id = ?", message.ID).
+		Updates(updates).Error
+	if err != nil {
+		return err
+	}
+	return updateSubmission(tx, message.SubmissionID, submissionUpdates)
+}
+
+// DeleteSentBefore removes the messages sent before the given time and returns how many were
+// removed. Parked messages are kept for inspection.
+func (r *outboxRepository) DeleteSentBefore(before time.Time) (int64, error) {
+	result := r.db.Where("sent_at IS NOT NULL AND sent_at < ?", before).Delete(&domain.OutboxMessage{})
+	return result.RowsAffected, result.Error
+}
+
+// updateSubmission updates the submission a message belongs to, if it has one
+func updateSubmission(tx *gorm.DB, submissionID string, updates map[string]any) error {
+	if submissionID == "" {
+		return nil
+	}
+	return tx.Model(&domain.FactorialSubmission{}).
+		Where("submission_id = ?", submissionID).
+		Updates(updates).Error
+}
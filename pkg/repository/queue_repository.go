@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"time"
+
+	"factorial-cal-services/pkg/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// queueRepository implements QueueRepository interface
+type queueRepository struct {
+	db *gorm.DB
+}
+
+// QueueRepository defines the interface for the Postgres-backed queue
+type QueueRepository interface {
+	Enqueue(message *domain.QueueMessage) error
+	Lease(queue string, limit int, lease time.Duration) ([]domain.QueueMessage, error)
+	Delete(id int64) error
+	Reschedule(id int64, availableAt time.Time, lastError string) error
+	Move(id int64, queue string, lastError string) error
+	Ping() error
+}
+
+// NewQueueRepository creates a new queue repository
+func NewQueueRepository(db *gorm.DB) QueueRepository {
+	return &queueRepository{
+		db: db,
+	}
+}
+
+// Enqueue inserts a message that is available immediately unless AvailableAt is set
+func (r *queueRepository) Enqueue(message *domain.QueueMessage) error {
+	if message.AvailableAt.IsZero() {
+		message.AvailableAt = time.Now()
+	}
+	return r.db.Create(message).Error
+}
+
+// Lease locks up to limit available messages of the queue, skipping rows locked by concurrent
+// consumers, and hides them for lease. A message that is not deleted or rescheduled before the
+// lease expires is delivered again.
+func (r *queueRepository) Lease(queue string, limit int, lease time.Duration) ([]domain.QueueMessage, error) {
+	var messages []domain.QueueMessage
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("queue = ? AND available_at <= ?", queue, now).
+			Order("id").
+			Limit(limit).
+			Find(&messages)
+		if result.Error != nil || len(messages) == 0 {
+			return result.Error
+		}
+
+		ids := make([]int64, len(messages))
+		for i := range messages {
+			ids[i] = messages[i].ID
+			messages[i].Attempts++
+			messages[i].AvailableAt = now.Add(lease)
+		}
+		return tx.Model(&domain.QueueMessage{}).
+			Where("id IN ?", ids).
+			Updates(map[string]any{
+				"attempts":     gorm.Expr("attempts + 1"),
+				"available_at": now.Add(lease),
+			}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+// Delete removes a handled message
+func (r *queueRepository) Delete(id int64) error {
+	return r.db.Delete(&domain.QueueMessage{}, id).Error
+}
+
+// Reschedule makes a leased message available again at availableAt
+func (r *queueRepository) Reschedule(id int64, availableAt time.Time, lastError string) error {
+	return r.db.Model(&domain.QueueMessage{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"available_at": availableAt,
+			"last_error":   lastError,
+		}).Error
+}
+
+// Move transfers a leased message to another queue, keeping its attempts
+func (r *queueRepository) Move(id int64, queue string, lastError string) error {
+	return r.db.Model(&domain.QueueMessage{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"queue":        queue,
+			"available_at": time.Now(),
+			"last_error":   lastError,
+		}).Error
+}
+
+// Ping checks the database connection
+func (r *queueRepository) Ping() error {
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Ping()
+}
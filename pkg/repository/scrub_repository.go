@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"factorial-cal-services/pkg/domain"
+
+	"gorm.io/gorm"
+)
+
+// scrubRepository implements ScrubRepository interface
+type scrubRepository struct {
+	db *gorm.DB
+}
+
+// ScrubRepository defines the interface for scrub report operations
+type ScrubRepository interface {
+	CreateReport(report *domain.ScrubReport) error
+	UpdateReport(report *domain.ScrubReport) error
+	AddFinding(finding *domain.ScrubFinding) error
+}
+
+// NewScrubRepository creates a new scrub repository
+func NewScrubRepository(db *gorm.DB) ScrubRepository {
+	return &scrubRepository{
+		db: db,
+	}
+}
+
+// CreateReport creates the report of a scrub run
+func (r *scrubRepository) CreateReport(report *domain.ScrubReport) error {
+	return r.db.Create(report).Error
+}
+
+// UpdateReport saves the progress of a scrub run
+func (r *scrubRepository) UpdateReport(report *domain.ScrubReport) error {
+	return r.db.Save(report).Error
+}
+
+// AddFinding records a stored factorial failing a check
+func (r *scrubRepository) AddFinding(finding *domain.ScrubFinding) error {
+	return r.db.Create(finding).Error
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"factorial-cal-services/pkg/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// submissionRepository implements SubmissionRepository interface
+type submissionRepository struct {
+	db *gorm.DB
+}
+
+// SubmissionRepository defines the interface for submission lookups.
+// Submissions are created together with their outbox message, see OutboxRepository.Enqueue.
+type SubmissionRepository interface {
+	FindBySubmissionID(submissionID string) (*domain.FactorialSubmission, error)
+}
+
+// NewSubmissionRepository creates a new submission repository
+func NewSubmissionRepository(db *gorm.DB) SubmissionRepository {
+	return &submissionRepository{
+		db: db,
+	}
+}
+
+// FindBySubmissionID retrieves a submission by its public ID
+func (r *submissionRepository) FindBySubmissionID(submissionID string) (*domain.FactorialSubmission, error) {
+	var submission domain.FactorialSubmission
+
+	db := r.db.Session(&gorm.Session{
+		Logger: logger.Discard, // Disable print error when not found
+	})
+	result := db.Where("submission_id = ?", submissionID).First(&submission)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &submission, nil
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"fmt"
+)
+
+const (
+	CheckpointPolicyEvery     = "every"
+	CheckpointPolicyGeometric = "geometric"
+)
+
+// CheckpointPolicy decides which factorials are persisted to storage. Factorials that are
+// not materialized are reconstructed from the nearest lower checkpoint on demand.
+type CheckpointPolicy interface {
+	ShouldMaterialize(number int64) bool
+}
+
+// NewCheckpointPolicy returns the checkpoint policy registered under the given name
+func NewCheckpointPolicy(name string, interval int64, ratio float64) (CheckpointPolicy, error) {
+	switch name {
+	case CheckpointPolicyEvery, "":
+		return NewEveryCheckpointPolicy(interval), nil
+	case CheckpointPolicyGeometric:
+		if ratio <= 1 {
+			return nil, fmt.Errorf("geometric checkpoint ratio must be greater than 1 (got %v)", ratio)
+		}
+		return NewGeometricCheckpointPolicy(interval, ratio), nil
+	default:
+		return nil, fmt.Errorf("unknown checkpoint policy: %s", name)
+	}
+}
+
+type everyCheckpointPolicy struct {
+	interval int64
+}
+
+// NewEveryCheckpointPolicy materializes every interval-th factorial (interval 1 stores all of them)
+func NewEveryCheckpointPolicy(interval int64) CheckpointPolicy {
+	if interval <= 0 {
+		interval = 1
+	}
+	return &everyCheckpointPolicy{
+		interval: interval,
+	}
+}
+
+// ShouldMaterialize reports whether number is a multiple of the interval
+func (p *everyCheckpointPolicy) ShouldMaterialize(number int64) bool {
+	return number%p.interval == 0
+}
+
+type geometricCheckpointPolicy struct {
+	start int64
+	ratio float64
+}
+
+// NewGeometricCheckpointPolicy materializes start, start*ratio, start*ratio^2, ...
+func NewGeometricCheckpointPolicy(start int64, ratio float64) CheckpointPolicy {
+	if start <= 0 {
+		start = 1
+	}
+	return &geometricCheckpointPolicy{
+		start: start,
+		ratio: ratio,
+	}
+}
+
+// ShouldMaterialize reports whether number is on the geometric sequence
+func (p *geometricCheckpointPolicy) ShouldMaterialize(number int64) bool {
+	checkpoint := p.start
+	for checkpoint < number {
+		next := int64(float64(checkpoint) * p.ratio)
+		if next <= checkpoint {
+			next = checkpoint + 1
+		}
+		checkpoint = next
+	}
+	return checkpoint == number
+}
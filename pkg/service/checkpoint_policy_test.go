@@ -0,0 +1,74 @@
+package service
+
+import (
+	"testing"
+)
+
+func TestEveryCheckpointPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval int64
+		number   int64
+		want     bool
+	}{
+		{name: "Interval 1 stores everything", interval: 1, number: 7, want: true},
+		{name: "Multiple of interval", interval: 5, number: 10, want: true},
+		{name: "Not a multiple of interval", interval: 5, number: 12, want: false},
+		{name: "Invalid interval falls back to 1", interval: 0, number: 3, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := NewEveryCheckpointPolicy(tt.interval)
+			if got := policy.ShouldMaterialize(tt.number); got != tt.want {
+				t.Errorf("ShouldMaterialize(%d) = %v, want %v", tt.number, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGeometricCheckpointPolicy(t *testing.T) {
+	policy := NewGeometricCheckpointPolicy(4, 2)
+
+	var got []int64
+	for n := int64(0); n <= 100; n++ {
+		if policy.ShouldMaterialize(n) {
+			got = append(got, n)
+		}
+	}
+
+	want := []int64{4, 8, 16, 32, 64}
+	if len(got) != len(want) {
+		t.Fatalf("checkpoints = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("checkpoints = %v, want %v", got, want)
+			break
+		}
+	}
+}
+
+func TestNewCheckpointPolicy(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    string
+		ratio     float64
+		wantError bool
+	}{
+		{name: "Default", policy: ""},
+		{name: "Every", policy: CheckpointPolicyEvery},
+		{name: "Geometric", policy: CheckpointPolicyGeometric, ratio: 1.5},
+		{name: "Geometric with invalid ratio", policy: CheckpointPolicyGeometric, ratio: 1, wantError: true},
+		{name: "Unknown", policy: "random", wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewCheckpointPolicy(tt.policy, 1, tt.ratio)
+			if (err != nil) != tt.wantError {
+				t.Errorf("NewCheckpointPolicy() error = %v, wantError %v", err, tt.wantError)
+			}
+		})
+	}
+}
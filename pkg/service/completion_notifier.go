@@ -0,0 +1,30 @@
+package service
+
+import "context"
+
+// CompletionNotifier broadcasts that a factorial reached domain.StatusDone, so waiters
+// are woken up instead of polling the database
+type CompletionNotifier interface {
+	// NotifyDone announces that number is done
+	NotifyDone(ctx context.Context, number int64) error
+	// Subscribe returns a channel closed once number is announced done, and a function
+	// releasing the subscription. Subscribe before checking the status so no announcement is missed.
+	Subscribe(ctx context.Context, number int64) (<-chan struct{}, func(), error)
+}
+
+type noopCompletionNotifier struct{}
+
+// NewNoopCompletionNotifier creates a notifier that announces nothing; waiters only time out
+func NewNoopCompletionNotifier() CompletionNotifier {
+	return noopCompletionNotifier{}
+}
+
+// NotifyDone does nothing
+func (noopCompletionNotifier) NotifyDone(ctx context.Context, number int64) error {
+	return nil
+}
+
+// Subscribe returns a channel that is never closed
+func (noopCompletionNotifier) Subscribe(ctx context.Context, number int64) (<-chan struct{}, func(), error) {
+	return make(chan struct{}), func() {}, nil
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// CompletionChannel is the Redis pub/sub channel done factorial numbers are published on
+const CompletionChannel = RedisKeyPrefix + "done"
+
+// redisCompletionNotifier shares a single Redis subscription between all local waiters
+type redisCompletionNotifier struct {
+	client *redis.Client
+
+	mu      sync.Mutex
+	pubsub  *redis.PubSub
+	waiters map[int64]map[chan struct{}]struct{}
+}
+
+// NewRedisCompletionNotifier creates a notifier backed by Redis pub/sub
+func NewRedisCompletionNotifier(client *redis.Client) CompletionNotifier {
+	return &redisCompletionNotifier{
+		client:  client,
+		waiters: make(map[int64]map[chan struct{}]struct{}),
+	}
+}
+
+// NotifyDone publishes number on the completion channel
+func (n *redisCompletionNotifier) NotifyDone(ctx context.Context, number int64) error {
+	if err := n.client.Publish(ctx, CompletionChannel, strconv.FormatInt(number, 10)).Err(); err != nil {
+		return fmt.Errorf("redis publish error: %w", err)
+	}
+	return nil
+}
+
+// Subscribe registers a waiter for number, subscribing to the completion channel on first use
+func (n *redisCompletionNotifier) Subscribe(ctx context.Context, number int64) (<-chan struct{}, func(), error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.pubsub == nil {
+		pubsub := n.client.Subscribe(context.Background(), CompletionChannel)
+		// Wait for the confirmation so announcements made after Subscribe returns are received
+		if _, err := pubsub.Receive(ctx); err != nil {
+			pubsub.Close()
+			return nil, nil, fmt.Errorf("redis subscribe error: %w", err)
+		}
+		n.pubsub = pubsub
+		go n.listen(pubsub)
+	}
+
+	ch := make(chan struct{})
+	if n.waiters[number] == nil {
+		n.waiters[number] = make(map[chan struct{}]struct{})
+	}
+	n.waiters[number][ch] = struct{}{}
+
+	release := func() {
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		if waiters, ok := n.waiters[number]; ok {
+			delete(waiters, ch)
+			if len(waiters) == 0 {
+				delete(n.waiters, number)
+			}
+		}
+	}
+	return ch, release, nil
+}
+
+// listen wakes up the waiters of every announced number
+func (n *redisCompletionNotifier) listen(pubsub *redis.PubSub) {
+	for msg := range pubsub.Channel() {
+		number, err := strconv.ParseInt(msg.Payload, 10, 64)
+		if err != nil {
+			log.Printf("invalid completion message %q: %v", msg.Payload, err)
+			continue
+		}
+
+		n.mu.Lock()
+		for ch := range n.waiters[number] {
+			close(ch)
+		}
+		delete(n.waiters, number)
+		n.mu.Unlock()
+	}
+}
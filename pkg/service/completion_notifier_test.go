@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"factorial-cal-services/pkg/repository"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// recordingCompletionNotifier records every announced number
+type recordingCompletionNotifier struct {
+	noopCompletionNotifier
+	mu      sync.Mutex
+	numbers []int64
+}
+
+func (n *recordingCompletionNotifier) NotifyDone(ctx context.Context, number int64) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.numbers = append(n.numbers, number)
+	return nil
+}
+
+func TestRedisCompletionNotifier(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to create miniredis: %v", err)
+	}
+	defer mr.Close()
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	notifier := NewRedisCompletionNotifier(client)
+	ctx := context.Background()
+
+	done10, release10, err := notifier.Subscribe(ctx, 10)
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	defer release10()
+	done11, release11, err := notifier.Subscribe(ctx, 11)
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	defer release11()
+
+	if err := notifier.NotifyDone(ctx, 10); err != nil {
+		t.Fatalf("NotifyDone failed: %v", err)
+	}
+
+	select {
+	case <-done10:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected waiter of 10 to be woken up")
+	}
+	select {
+	case <-done11:
+		t.Fatal("Expected waiter of 11 not to be woken up")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Released waiters are not woken up
+	release11()
+	if err := notifier.NotifyDone(ctx, 11); err != nil {
+		t.Fatalf("NotifyDone failed: %v", err)
+	}
+	select {
+	case <-done11:
+		t.Fatal("Expected released waiter not to be woken up")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestFactorialService_NotifiesDone(t *testing.T) {
+	db := setupTestDB(t)
+	mockStorage := newMockStorageService()
+	setupBaseCases(t, db, mockStorage, context.Background())
+
+	notifier := &recordingCompletionNotifier{}
+	svc := NewFactorialService(
+		repository.NewFactorialRepository(db),
+		repository.NewCurrentCalculatedRepository(db),
+		repository.NewMaxRequestRepository(db),
+		mockStorage,
+		WithCheckpointPolicy(NewEveryCheckpointPolicy(2)),
+		WithCompletionNotifier(notifier),
+	).(*factorialService)
+
+	if err := svc.continuelyCalculateFactorial(context.Background(), 3, 6, nil); err != nil {
+		t.Fatalf("Failed to calculate factorials: %v", err)
+	}
+
+	// 3 was already done, 4 and 6 are materialized and 5 is derived
+	expected := []int64{4, 5, 6}
+	if len(notifier.numbers) != len(expected) {
+		t.Fatalf("Expected notifications %v, got %v", expected, notifier.numbers)
+	}
+	for i, number := range expected {
+		if notifier.numbers[i] != number {
+			t.Errorf("Expected notifications %v, got %v", expected, notifier.numbers)
+			break
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"fmt"
+	"math/big"
+)
+
+const (
+	AlgorithmBinarySplitting = "binary-splitting"
+	AlgorithmPrimeSwing      = "prime-swing"
+
+	// productTreeLeafSize is the range length below which numbers are multiplied sequentially
+	productTreeLeafSize = 16
+)
+
+// FactorialAlgorithm computes n! directly, without relying on (n-1)! from storage
+type FactorialAlgorithm interface {
+	Name() string
+	Factorial(n int64) *big.Int
+}
+
+// NewFactorialAlgorithm returns the algorithm registered under the given name,
+// multiplying on up to parallelism goroutines
+func NewFactorialAlgorithm(name string, parallelism int) (FactorialAlgorithm, error) {
+	switch name {
+	case AlgorithmBinarySplitting:
+		return NewBinarySplittingAlgorithm(parallelism), nil
+	case AlgorithmPrimeSwing, "":
+		return NewPrimeSwingAlgorithm(parallelism), nil
+	default:
+		return nil, fmt.Errorf("unknown factorial algorithm: %s", name)
+	}
+}
+
+type binarySplittingAlgorithm struct {
+	multiplier Multiplier
+}
+
+// NewBinarySplittingAlgorithm creates an algorithm multiplying 2..n with a balanced product tree
+func NewBinarySplittingAlgorithm(parallelism int) FactorialAlgorithm {
+	return &binarySplittingAlgorithm{
+		multiplier: NewParallelMultiplier(parallelism),
+	}
+}
+
+// Name returns the algorithm name
+func (a *binarySplittingAlgorithm) Name() string {
+	return AlgorithmBinarySplitting
+}
+
+// Factorial computes n!
+func (a *binarySplittingAlgorithm) Factorial(n int64) *big.Int {
+	if n < 2 {
+		return big.NewInt(1)
+	}
+	return a.multiplier.MulRange(2, n)
+}
+
+type primeSwingAlgorithm struct {
+	multiplier Multiplier
+}
+
+// NewPrimeSwingAlgorithm creates an algorithm based on n! = ((n/2)!)^2 * swing(n)
+func NewPrimeSwingAlgorithm(parallelism int) FactorialAlgorithm {
+	return &primeSwingAlgorithm{
+		multiplier: NewParallelMultiplier(parallelism),
+	}
+}
+
+// Name returns the algorithm name
+func (a *primeSwingAlgorithm) Name() string {
+	return AlgorithmPrimeSwing
+}
+
+// Factorial computes n!
+func (a *primeSwingAlgorithm) Factorial(n int64) *big.Int {
+	if n < 2 {
+		return big.NewInt(1)
+	}
+	return a.factorial(n, sievePrimes(n))
+}
+
+func (a *primeSwingAlgorithm) factorial(n int64, primes []int64) *big.Int {
+	if n < 2 {
+		return big.NewInt(1)
+	}
+	half := a.factorial(n/2, primes)
+	result := new(big.Int).Mul(half, half)
+	return result.Mul(result, a.swing(n, primes))
+}
+
+// swing computes n! / ((n/2)!)^2. By Legendre's formula the exponent of a prime p
+// is the number of odd quotients n/p^k.
+func (a *primeSwingAlgorithm) swing(n int64, primes []int64) *big.Int {
+	factors := make([]*big.Int, 0, len(primes))
+	exponent := new(big.Int)
+	for _, p := range primes {
+		if p > n {
+			break
+		}
+		exp := int64(0)
+		for q := n / p; q > 0; q /= p {
+			exp += q & 1
+		}
+		if exp == 0 {
+			continue
+		}
+		factor := big.NewInt(p)
+		if exp > 1 {
+			factor.Exp(factor, exponent.SetInt64(exp), nil)
+		}
+		factors = append(factors, factor)
+	}
+	return a.multiplier.MulAll(factors)
+}
+
+// sievePrimes returns all primes <= n in ascending order
+func sievePrimes(n int64) []int64 {
+	if n < 2 {
+		return nil
+	}
+	composite := make([]bool, n+1)
+	primes := make([]int64, 0)
+	for i := int64(2); i <= n; i++ {
+		if composite[i] {
+			continue
+		}
+		primes = append(primes, i)
+		for j := i * i; j <= n; j += i {
+			composite[j] = true
+		}
+	}
+	return primes
+}
+
+// productRange multiplies all integers in [a, b] using a balanced product tree
+func productRange(a, b int64) *big.Int {
+	if a > b {
+		return big.NewInt(1)
+	}
+	if b-a < productTreeLeafSize {
+		result := big.NewInt(a)
+		factor := new(big.Int)
+		for i := a + 1; i <= b; i++ {
+			result.Mul(result, factor.SetInt64(i))
+		}
+		return result
+	}
+	mid := a + (b-a)/2
+	return new(big.Int).Mul(productRange(a, mid), productRange(mid+1, b))
+}
+
+// productOf multiplies the given factors using a balanced product tree
+func productOf(factors []*big.Int) *big.Int {
+	switch len(factors) {
+	case 0:
+		return big.NewInt(1)
+	case 1:
+		return factors[0]
+	}
+	mid := len(factors) / 2
+	return new(big.Int).Mul(productOf(factors[:mid]), productOf(factors[mid:]))
+}
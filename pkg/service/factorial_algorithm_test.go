@@ -0,0 +1,285 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"factorial-cal-services/pkg/domain"
+)
+
+func TestFactorialAlgorithms(t *testing.T) {
+	algorithms := []FactorialAlgorithm{
+		NewBinarySplittingAlgorithm(1),
+		NewPrimeSwingAlgorithm(1),
+		NewBinarySplittingAlgorithm(4),
+		NewPrimeSwingAlgorithm(4),
+	}
+
+	numbers := []int64{0, 1, 2, 3, 4, 5, 10, 15, 16, 17, 31, 32, 33, 100, 257, 1000, 2500, 10000}
+
+	for i, algorithm := range algorithms {
+		for _, n := range numbers {
+			t.Run(fmt.Sprintf("%s_%d_%d", algorithm.Name(), i, n), func(t *testing.T) {
+				want := calculateFactorial(n)
+				got := algorithm.Factorial(n)
+				if got.Cmp(want) != 0 {
+					t.Errorf("Factorial(%d) = %s, want %s", n, got.String(), want.String())
+				}
+			})
+		}
+	}
+}
+
+func TestNewFactorialAlgorithm(t *testing.T) {
+	tests := []struct {
+		name      string
+		algorithm string
+		wantName  string
+		wantError bool
+	}{
+		{name: "Default", algorithm: "", wantName: AlgorithmPrimeSwing},
+		{name: "Prime swing", algorithm: AlgorithmPrimeSwing, wantName: AlgorithmPrimeSwing},
+		{name: "Binary splitting", algorithm: AlgorithmBinarySplitting, wantName: AlgorithmBinarySplitting},
+		{name: "Unknown", algorithm: "naive", wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewFactorialAlgorithm(tt.algorithm, 1)
+			if (err != nil) != tt.wantError {
+				t.Fatalf("NewFactorialAlgorithm() error = %v, wantError %v", err, tt.wantError)
+			}
+			if !tt.wantError && got.Name() != tt.wantName {
+				t.Errorf("NewFactorialAlgorithm() = %s, want %s", got.Name(), tt.wantName)
+			}
+		})
+	}
+}
+
+func TestSievePrimes(t *testing.T) {
+	got := sievePrimes(30)
+	want := []int64{2, 3, 5, 7, 11, 13, 17, 19, 23, 29}
+	if len(got) != len(want) {
+		t.Fatalf("sievePrimes(30) = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("sievePrimes(30)[%d] = %d, want %d", i, got[i], want[i])
+		}
+	}
+}
+
+func TestFactorialService_CalculateFactorialRange_Direct(t *testing.T) {
+	mockRepo := newMockFactorialRepository()
+	mockStorage := newUnitTestMockStorageService()
+	mockStorage.storage[mockStorage.GenerateKey(3)] = "6"
+
+	service := NewFactorialService(
+		mockRepo,
+		newMockCurrentCalculatedRepository(),
+		newMockMaxRequestRepository(),
+		mockStorage,
+		WithDirectThreshold(5),
+	).(*factorialService)
+
+	if err := service.calculateFactorialRange(context.Background(), 4, 20, -1); err != nil {
+		t.Fatalf("calculateFactorialRange() error = %v", err)
+	}
+
+	for n := int64(4); n <= 20; n++ {
+		calc, exists := mockRepo.calculations[n]
+		if !exists {
+			t.Fatalf("factorial %d was not recorded", n)
+		}
+		if calc.Status != domain.StatusDone {
+			t.Errorf("factorial %d status = %s, want done", n, calc.Status)
+		}
+		got, ok := new(big.Int).SetString(mockStorage.storage[calc.S3Key], 10)
+		if !ok || got.Cmp(calculateFactorial(n)) != 0 {
+			t.Errorf("factorial %d = %s, want %s", n, mockStorage.storage[calc.S3Key], calculateFactorial(n).String())
+		}
+	}
+}
+
+func TestFactorialService_CalculateFactorialRange_PriorityFirst(t *testing.T) {
+	mockStorage := newUnitTestMockStorageService()
+	mockStorage.storage[mockStorage.GenerateKey(3)] = "6"
+	notifier := &recordingCompletionNotifier{}
+
+	service := NewFactorialService(
+		newMockFactorialRepository(),
+		newMockCurrentCalculatedRepository(),
+		newMockMaxRequestRepository(),
+		mockStorage,
+		WithDirectThreshold(5),
+		WithCompletionNotifier(notifier),
+	).(*factorialService)
+
+	if err := service.calculateFactorialRange(context.Background(), 4, 20, 8); err != nil {
+		t.Fatalf("calculateFactorialRange() error = %v", err)
+	}
+
+	// The priority max and 20 are computed directly, then the walk skips them
+	expected := []int64{8, 20, 4, 5, 6, 7, 9}
+	if len(notifier.numbers) != 17 {
+		t.Fatalf("Expected 17 notifications, got %v", notifier.numbers)
+	}
+	for i, number := range expected {
+		if notifier.numbers[i] != number {
+			t.Fatalf("Expected notifications to start with %v, got %v", expected, notifier.numbers)
+		}
+	}
+	for n := int64(4); n <= 20; n++ {
+		want := calculateFactorial(n).String()
+		if got := mockStorage.storage[mockStorage.GenerateKey(n)]; got != want {
+			t.Errorf("factorial %d = %s, want %s", n, got, want)
+		}
+	}
+}
+
+func TestFactorialService_CalculateFactorialRange_PriorityBeforeWalk(t *testing.T) {
+	mockStorage := newUnitTestMockStorageService()
+	mockStorage.storage[mockStorage.GenerateKey(3)] = "6"
+	notifier := &recordingCompletionNotifier{}
+
+	service := NewFactorialService(
+		newMockFactorialRepository(),
+		newMockCurrentCalculatedRepository(),
+		newMockMaxRequestRepository(),
+		mockStorage,
+		WithDirectThreshold(100),
+		WithCompletionNotifier(notifier),
+	).(*factorialService)
+
+	// 6 was requested with normal priority, 15 with high priority, 20 is the highest request
+	if err := service.calculateFactorialRange(context.Background(), 4, 20, 15); err != nil {
+		t.Fatalf("calculateFactorialRange() error = %v", err)
+	}
+
+	position := make(map[int64]int)
+	for i, number := range notifier.numbers {
+		position[number] = i
+	}
+	if len(notifier.numbers) != 17 {
+		t.Fatalf("Expected 17 notifications, got %v", notifier.numbers)
+	}
+	if position[15] > position[6] {
+		t.Errorf("Expected 15 done before 6, got notifications %v", notifier.numbers)
+	}
+	if want := calculateFactorial(15).String(); mockStorage.storage[mockStorage.GenerateKey(15)] != want {
+		t.Errorf("factorial 15 = %s, want %s", mockStorage.storage[mockStorage.GenerateKey(15)], want)
+	}
+}
+
+func TestFactorialService_ContinuelyCalculateFactorial_ColdStart(t *testing.T) {
+	mockRepo := newMockFactorialRepository()
+	mockStorage := newUnitTestMockStorageService()
+
+	service := NewFactorialService(
+		mockRepo,
+		newMockCurrentCalculatedRepository(),
+		newMockMaxRequestRepository(),
+		mockStorage,
+	).(*factorialService)
+
+	// Storage is empty, so 9! has to be computed directly before the walk starts
+	if err := service.continuelyCalculateFactorial(context.Background(), 10, 12, nil); err != nil {
+		t.Fatalf("continuelyCalculateFactorial() error = %v", err)
+	}
+
+	for n := int64(10); n <= 12; n++ {
+		want := calculateFactorial(n).String()
+		if got := mockStorage.storage[mockStorage.GenerateKey(n)]; got != want {
+			t.Errorf("factorial %d = %s, want %s", n, got, want)
+		}
+	}
+}
+
+func TestFactorialService_ContinuelyCalculateFactorial_StorageOutage(t *testing.T) {
+	mockRepo := newMockFactorialRepository()
+	mockStorage := newUnitTestMockStorageService()
+	mockStorage.downloadError = errors.New("connection reset")
+
+	service := NewFactorialService(
+		mockRepo,
+		newMockCurrentCalculatedRepository(),
+		newMockMaxRequestRepository(),
+		mockStorage,
+	).(*factorialService)
+
+	// An outage is not a cold start: the tick fails and is retried instead of recomputing 9!
+	err := service.continuelyCalculateFactorial(context.Background(), 10, 12, nil)
+	if err == nil || !strings.Contains(err.Error(), "connection reset") {
+		t.Fatalf("Expected the storage error, got %v", err)
+	}
+	if _, stored := mockStorage.storage[mockStorage.GenerateKey(10)]; stored {
+		t.Error("Expected nothing to be stored during the outage")
+	}
+}
+
+func BenchmarkFactorialAlgorithm_BinarySplitting(b *testing.B) {
+	algorithm := NewBinarySplittingAlgorithm(1)
+	for i := 0; i < b.N; i++ {
+		algorithm.Factorial(10000)
+	}
+}
+
+func BenchmarkFactorialAlgorithm_PrimeSwing(b *testing.B) {
+	algorithm := NewPrimeSwingAlgorithm(1)
+	for i := 0; i < b.N; i++ {
+		algorithm.Factorial(10000)
+	}
+}
+
+func TestParallelMultiplier(t *testing.T) {
+	tests := []struct {
+		name        string
+		parallelism int
+		a, b        int64
+	}{
+		{name: "Empty range", parallelism: 4, a: 5, b: 4},
+		{name: "Single number", parallelism: 4, a: 7, b: 7},
+		{name: "Below parallel threshold", parallelism: 4, a: 2, b: 100},
+		{name: "Sequential", parallelism: 1, a: 2, b: 5000},
+		{name: "Parallel", parallelism: 4, a: 2, b: 5000},
+		{name: "Parallel offset range", parallelism: 3, a: 1000, b: 9001},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := big.NewInt(1)
+			for i := tt.a; i <= tt.b; i++ {
+				want.Mul(want, big.NewInt(i))
+			}
+			got := NewParallelMultiplier(tt.parallelism).MulRange(tt.a, tt.b)
+			if got.Cmp(want) != 0 {
+				t.Errorf("MulRange(%d, %d) mismatch", tt.a, tt.b)
+			}
+		})
+	}
+}
+
+func TestSplitRange(t *testing.T) {
+	segments := splitRange(1, 10, 3)
+	if len(segments) != 3 {
+		t.Fatalf("splitRange() returned %d segments, want 3", len(segments))
+	}
+	next := int64(1)
+	for _, segment := range segments {
+		if segment[0] != next || segment[1] < segment[0] {
+			t.Fatalf("splitRange() segments not contiguous: %v", segments)
+		}
+		next = segment[1] + 1
+	}
+	if next != 11 {
+		t.Errorf("splitRange() does not cover the range: %v", segments)
+	}
+
+	if got := splitRange(1, 2, 8); len(got) != 2 {
+		t.Errorf("splitRange() returned %d segments for 2 numbers, want 2", len(got))
+	}
+}
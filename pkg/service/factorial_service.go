@@ -6,9 +6,11 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"math/big"
 	"strconv"
+	"strings"
 	"time"
 
 	"factorial-cal-services/pkg/domain"
@@ -20,31 +22,103 @@ import (
 // FactorialService handles factorial calculations
 type FactorialService interface {
 	ValidateNumber(number string) (int64, error)
-	StartContinuelyCalculateFactorial()
+	StartContinuelyCalculateFactorial(ctx context.Context) <-chan struct{}
+	ResolveFactorial(ctx context.Context, calc *domain.FactorialCalculation) (string, error)
+	OpenResult(ctx context.Context, calc *domain.FactorialCalculation) (io.ReadSeekCloser, error)
 }
 
+// DefaultDirectThreshold is the gap between the current and the requested number
+// above which the requested factorial is computed directly instead of waiting for the walk
+const DefaultDirectThreshold = 1000
+
+// failedRecalculationBatchSize bounds the factorials that failed verification recalculated per tick
+const failedRecalculationBatchSize = 10
+
 type factorialService struct {
 	maxFactorial                int64
+	directThreshold             int64
+	algorithm                   FactorialAlgorithm
+	checkpointPolicy            CheckpointPolicy
+	notifier                    CompletionNotifier
+	alerter                     IntegrityAlerter
 	repository                  repository.FactorialRepository
 	currentCalculatedRepository repository.CurrentCalculatedRepository
 	maxRequestRepository        repository.MaxRequestRepository
 	storage                     StorageService
 }
 
+// FactorialServiceOption configures optional behaviour of the factorial service
+type FactorialServiceOption func(*factorialService)
+
+// WithAlgorithm sets the algorithm used to compute factorials directly
+func WithAlgorithm(algorithm FactorialAlgorithm) FactorialServiceOption {
+	return func(s *factorialService) {
+		if algorithm != nil {
+			s.algorithm = algorithm
+		}
+	}
+}
+
+// WithDirectThreshold sets the gap above which the requested factorial is computed directly
+func WithDirectThreshold(threshold int64) FactorialServiceOption {
+	return func(s *factorialService) {
+		if threshold > 0 {
+			s.directThreshold = threshold
+		}
+	}
+}
+
+// WithCheckpointPolicy sets the policy deciding which factorials are persisted to storage
+func WithCheckpointPolicy(policy CheckpointPolicy) FactorialServiceOption {
+	return func(s *factorialService) {
+		if policy != nil {
+			s.checkpointPolicy = policy
+		}
+	}
+}
+
+// WithCompletionNotifier sets the notifier announcing factorials as they are done
+func WithCompletionNotifier(notifier CompletionNotifier) FactorialServiceOption {
+	return func(s *factorialService) {
+		if notifier != nil {
+			s.notifier = notifier
+		}
+	}
+}
+
+// WithIntegrityAlerter sets the alerter told about stored factorials failing verification
+func WithIntegrityAlerter(alerter IntegrityAlerter) FactorialServiceOption {
+	return func(s *factorialService) {
+		if alerter != nil {
+			s.alerter = alerter
+		}
+	}
+}
+
 // NewFactorialService creates a new factorial service
 func NewFactorialService(
 	repository repository.FactorialRepository,
 	currentCalculatedRepository repository.CurrentCalculatedRepository,
 	maxRequestRepository repository.MaxRequestRepository,
 	storage StorageService,
+	opts ...FactorialServiceOption,
 ) FactorialService {
-	return &factorialService{
+	s := &factorialService{
 		repository:                  repository,
 		currentCalculatedRepository: currentCalculatedRepository,
 		maxRequestRepository:        maxRequestRepository,
 		storage:                     storage,
 		maxFactorial:                10000,
+		directThreshold:             DefaultDirectThreshold,
+		algorithm:                   NewPrimeSwingAlgorithm(1),
+		checkpointPolicy:            NewEveryCheckpointPolicy(1),
+		notifier:                    NewNoopCompletionNotifier(),
+		alerter:                     NewLogIntegrityAlerter(),
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // ValidateNumber validates and parses the input number string
@@ -67,11 +141,25 @@ func (s *factorialService) ValidateNumber(number string) (int64, error) {
 	return n, nil
 }
 
-// TODO: Add ctx, graceful shutdown
-func (s *factorialService) StartContinuelyCalculateFactorial() {
+// StartContinuelyCalculateFactorial walks the current number up to the max requested number
+// in the background until ctx is done. The number in progress when ctx is cancelled is
+// finished; if the process exits first its record stays calculating and the current number
+// is not moved, so it is calculated again on restart. The returned channel is closed once
+// the walk has stopped.
+func (s *factorialService) StartContinuelyCalculateFactorial(ctx context.Context) <-chan struct{} {
+	stopped := make(chan struct{})
 	go func() {
+		defer close(stopped)
 		for {
-			time.Sleep(1 * time.Second)
+			select {
+			case <-ctx.Done():
+				log.Println("continuous factorial calculation stopped")
+				return
+			case <-time.After(1 * time.Second):
+			}
+			if err := s.recalculateFailed(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("failed to recalculate factorials failing verification: %v", err)
+			}
 			current, err := s.currentCalculatedRepository.GetCurrentNumber()
 			if err != nil {
 				log.Printf("failed to get current number: %v", err)
@@ -82,62 +170,326 @@ func (s *factorialService) StartContinuelyCalculateFactorial() {
 				log.Printf("failed to get max number: %v", err)
 				continue
 			}
+			priorityMax, err := s.maxRequestRepository.GetPriorityMaxNumber()
+			if err != nil {
+				log.Printf("failed to get priority max number: %v", err)
+				continue
+			}
 			if current > s.maxFactorial {
 				log.Printf("current number exceeds maximum allowed value of %d", s.maxFactorial)
 				continue
 			}
-			err = s.continuelyCalculateFactorial(current, max, nil)
-			if err != nil {
+			err = s.calculateFactorialRange(ctx, current, max, priorityMax)
+			if err != nil && ctx.Err() == nil {
 				log.Printf("failed to calculate factorial: %v", err)
 			}
 		}
 	}()
+	return stopped
+}
+
+// calculateFactorialRange calculates every factorial in [current, max]. priorityMax, the highest
+// number requested with high priority, is computed directly first so it does not wait behind the
+// lower numbers of the walk. When the gap is large, max! is computed directly too before the walk.
+func (s *factorialService) calculateFactorialRange(ctx context.Context, current, max, priorityMax int64) error {
+	if priorityMax > current && priorityMax <= max {
+		log.Printf("calculating high priority factorial %d before walking from %d", priorityMax, current)
+		if err := s.calculateFactorialDirectly(ctx, priorityMax); err != nil {
+			return err
+		}
+	}
+	if max-current >= s.directThreshold {
+		if err := s.calculateFactorialDirectly(ctx, max); err != nil {
+			return err
+		}
+	}
+	return s.continuelyCalculateFactorial(ctx, current, max, nil)
 }
 
-func (s *factorialService) continuelyCalculateFactorial(current, max int64, factorialBigInt *big.Int) error {
+// calculateFactorialDirectly computes n! with the configured algorithm without moving the current number
+func (s *factorialService) calculateFactorialDirectly(ctx context.Context, number int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	_, done, err := s.prepareCalculation(number)
+	if err != nil || done {
+		return err
+	}
+
+	log.Printf("calculating factorial %d directly with %s", number, s.algorithm.Name())
+	factorialStr := s.algorithm.Factorial(number).String()
+	// A started calculation is finished even when ctx is cancelled
+	object, err := s.storage.UploadFactorial(context.WithoutCancel(ctx), number, factorialStr)
+	if err != nil {
+		return fmt.Errorf("failed to upload factorial to S3: %w", err)
+	}
+
+	err = s.repository.UpdateResult(number, object, checksum(factorialStr), int64(len(factorialStr)), domain.StatusDone)
+	if err != nil {
+		return fmt.Errorf("failed to update factorial record: %w", err)
+	}
+	s.notifyDone(number)
+	return nil
+}
+
+// prepareCalculation reports whether number is already done, creating its record if missing
+func (s *factorialService) prepareCalculation(number int64) (*domain.FactorialCalculation, bool, error) {
+	factorial, err := s.repository.FindByNumber(number)
+	if factorial != nil && factorial.Status == domain.StatusDone {
+		return factorial, true, nil
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, fmt.Errorf("failed to query factorial %d: %w", number, err)
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		factorial = &domain.FactorialCalculation{
+			Number: number,
+			Status: domain.StatusCalculating,
+		}
+		if err := s.repository.Create(factorial); err != nil {
+			return nil, false, fmt.Errorf("failed to create factorial record: %w", err)
+		}
+	}
+	return factorial, false, nil
+}
+
+// continuelyCalculateFactorial calculates every factorial in [current, max] from the running
+// product, stopping before the next number once ctx is done
+func (s *factorialService) continuelyCalculateFactorial(ctx context.Context, current, max int64, factorialBigInt *big.Int) error {
+	// Nearest materialized factorial below current, -1 while unknown
+	checkpoint := int64(-1)
 	for ; current <= max; current++ {
-		// check status process
-		factorial, err := s.repository.FindByNumber(current)
-		if factorial != nil && factorial.Status == domain.StatusDone {
-			continue // Skip already done factorial, continue to next
+		if err := ctx.Err(); err != nil {
+			return err
 		}
-		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-			return fmt.Errorf("failed to query factorial %d: %w", current, err)
+
+		// check status process
+		factorial, done, err := s.prepareCalculation(current)
+		if err != nil {
+			return err
 		}
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			err := s.repository.Create(&domain.FactorialCalculation{
-				Number: current,
-				Status: domain.StatusCalculating,
-			})
-			if err != nil {
-				return fmt.Errorf("failed to create factorial record: %w", err)
+		if done {
+			// Skip already done factorial, but keep the running product in step
+			if factorialBigInt != nil {
+				factorialBigInt = new(big.Int).Mul(factorialBigInt, big.NewInt(current))
 			}
+			checkpoint = factorial.NearestCheckpoint()
+			continue
 		}
 
 		if factorialBigInt == nil {
 			factorialBigInt, err = s.getPreviousFactorial(current - 1)
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && !errors.Is(err, ErrObjectNotFound) {
+				// Storage or database outage, the next tick retries
+				return err
+			}
 			if err != nil {
-				return fmt.Errorf("failed to get previous factorial: %w", err)
+				// Cold start: the previous object is missing, compute it directly
+				log.Printf("failed to get previous factorial %d, computing directly: %v", current-1, err)
+				factorialBigInt = s.algorithm.Factorial(current - 1)
+				checkpoint = -1
+			} else {
+				checkpoint = s.nearestCheckpoint(current - 1)
 			}
 		}
 
 		// Calculate and save
 		factorialBigInt = new(big.Int).Mul(factorialBigInt, big.NewInt(current))
 		factorialStr := factorialBigInt.String()
-		s3Key, err := s.storage.UploadFactorial(context.Background(), current, factorialStr)
+
+		// Without a known checkpoint nothing could rebuild this row, so it has to be stored
+		if checkpoint >= 0 && !s.checkpointPolicy.ShouldMaterialize(current) {
+			err = s.repository.UpdateDerivedWithCurrentNumber(current, checkpoint, checksum(factorialStr), int64(len(factorialStr)), domain.StatusDone)
+			if err != nil {
+				return fmt.Errorf("failed to update factorial record: %w", err)
+			}
+			s.notifyDone(current)
+			continue
+		}
+
+		object, err := s.storage.UploadFactorial(context.WithoutCancel(ctx), current, factorialStr)
 		if err != nil {
 			return fmt.Errorf("failed to upload factorial to S3: %w", err)
 		}
 
-		// Size should be the string length (bytes), not bit length
-		err = s.repository.UpdateWithCurrentNumber(current, s3Key, checksum(factorialStr), int64(len(factorialStr)), domain.StatusDone, s.storage.GetBucket())
+		// Size is the length of the decimal text, the stored size that of the encoded object
+		err = s.repository.UpdateWithCurrentNumber(current, object, checksum(factorialStr), int64(len(factorialStr)), domain.StatusDone)
 		if err != nil {
 			return fmt.Errorf("failed to update factorial record: %w", err)
 		}
+		s.notifyDone(current)
+		checkpoint = current
 	}
 	return nil
 }
 
+// notifyDone announces a done factorial; waiters fall back to their timeout when it is lost
+func (s *factorialService) notifyDone(number int64) {
+	if err := s.notifier.NotifyDone(context.Background(), number); err != nil {
+		log.Printf("failed to notify factorial %d done: %v", number, err)
+	}
+}
+
+// nearestCheckpoint returns the materialized factorial number is read from
+func (s *factorialService) nearestCheckpoint(number int64) int64 {
+	calc, err := s.repository.FindByNumber(number)
+	if err != nil || calc == nil {
+		return number
+	}
+	return calc.NearestCheckpoint()
+}
+
+// ResolveFactorial returns the decimal value of a done factorial, downloading it directly
+// when materialized or rebuilding it from its checkpoint times the partial product otherwise.
+// The value is verified against its checksum; a mismatch marks the corrupted row failed.
+func (s *factorialService) ResolveFactorial(ctx context.Context, calc *domain.FactorialCalculation) (string, error) {
+	if !calc.Derived {
+		return s.downloadVerified(ctx, calc)
+	}
+
+	checkpoint, err := s.repository.FindByNumber(calc.CheckpointNumber)
+	if err != nil {
+		return "", fmt.Errorf("failed to find checkpoint %d: %w", calc.CheckpointNumber, err)
+	}
+	if checkpoint.Derived {
+		return "", fmt.Errorf("checkpoint %d of factorial %d is not materialized", checkpoint.Number, calc.Number)
+	}
+	if checkpoint.Status == domain.StatusFailed {
+		return "", fmt.Errorf("%w: checkpoint %d of factorial %d is being recalculated", ErrChecksumMismatch, checkpoint.Number, calc.Number)
+	}
+	result, err := s.downloadVerified(ctx, checkpoint)
+	if err != nil {
+		return "", fmt.Errorf("failed to download checkpoint %d: %w", checkpoint.Number, err)
+	}
+	factorial, ok := new(big.Int).SetString(result, 10)
+	if !ok {
+		return "", fmt.Errorf("failed to parse checkpoint %d: invalid format", checkpoint.Number)
+	}
+	factorial.Mul(factorial, productRange(checkpoint.Number+1, calc.Number))
+	result = factorial.String()
+	if err := s.verify(calc, result); err != nil {
+		return "", err
+	}
+	return result, nil
+}
+
+// OpenResult opens a done factorial for streaming. Materialized factorials are verified as they
+// are read; derived factorials have no object of their own and are rebuilt in memory.
+func (s *factorialService) OpenResult(ctx context.Context, calc *domain.FactorialCalculation) (io.ReadSeekCloser, error) {
+	if calc.Derived {
+		result, err := s.ResolveFactorial(ctx, calc)
+		if err != nil {
+			return nil, err
+		}
+		return nopReadSeekCloser{strings.NewReader(result)}, nil
+	}
+
+	reader, err := s.storage.OpenReader(ctx, calc.S3Key)
+	if err != nil || calc.Checksum == "" {
+		return reader, err
+	}
+	return newVerifyingReader(reader, calc.Size, calc.Checksum, func() { s.reportCorrupted(calc) })
+}
+
+// downloadVerified downloads a materialized factorial and verifies it against its checksum
+func (s *factorialService) downloadVerified(ctx context.Context, calc *domain.FactorialCalculation) (string, error) {
+	result, err := s.storage.DownloadFactorial(ctx, calc.S3Key)
+	if err != nil {
+		return "", err
+	}
+	if err := s.verify(calc, result); err != nil {
+		return "", err
+	}
+	return result, nil
+}
+
+// verify checks a factorial value against the checksum of its row; rows without a checksum are trusted
+func (s *factorialService) verify(calc *domain.FactorialCalculation, result string) error {
+	if calc.Checksum == "" || checksum(result) == calc.Checksum {
+		return nil
+	}
+	s.reportCorrupted(calc)
+	return fmt.Errorf("%w: factorial %d", ErrChecksumMismatch, calc.Number)
+}
+
+// reportCorrupted alerts about a factorial failing verification and marks it failed,
+// so it is recalculated by the calculator instead of being served
+func (s *factorialService) reportCorrupted(calc *domain.FactorialCalculation) {
+	s.alerter.ChecksumMismatch(calc.Number, calc.S3Key)
+	if err := s.repository.UpdateStatus(strconv.FormatInt(calc.Number, 10), domain.StatusFailed); err != nil {
+		log.Printf("failed to mark factorial %d failed: %v", calc.Number, err)
+	}
+}
+
+// recalculateFailed recalculates factorials that failed verification, lowest first
+func (s *factorialService) recalculateFailed(ctx context.Context) error {
+	failed, err := s.repository.FindByStatus(domain.StatusFailed, failedRecalculationBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to find failed factorials: %w", err)
+	}
+	for i := range failed {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if _, err := s.recoverFactorial(ctx, &failed[i]); err != nil {
+			return err
+		}
+		s.notifyDone(failed[i].Number)
+	}
+	return nil
+}
+
+// recoverFactorial recalculates a factorial that failed verification from its last verified
+// ancestor, the highest materialized factorial below it that matches its checksum. Every
+// corrupted factorial met on the way is stored again.
+func (s *factorialService) recoverFactorial(ctx context.Context, calc *domain.FactorialCalculation) (*big.Int, error) {
+	corrupted := []*domain.FactorialCalculation{calc}
+	ancestorNumber, ancestor := int64(0), big.NewInt(1)
+	for before := calc.Number; ; {
+		candidate, err := s.repository.FindLastMaterializedBefore(before)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to find an ancestor of factorial %d: %w", calc.Number, err)
+		}
+		result, err := s.downloadVerified(ctx, candidate)
+		if errors.Is(err, ErrChecksumMismatch) {
+			corrupted = append(corrupted, candidate)
+			before = candidate.Number
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to download ancestor %d of factorial %d: %w", candidate.Number, calc.Number, err)
+		}
+		value, ok := new(big.Int).SetString(result, 10)
+		if !ok {
+			return nil, fmt.Errorf("failed to parse ancestor %d: invalid format", candidate.Number)
+		}
+		ancestorNumber, ancestor = candidate.Number, value
+		break
+	}
+
+	log.Printf("recalculating factorial %d from verified ancestor %d", calc.Number, ancestorNumber)
+	factorial := ancestor
+	for i := len(corrupted) - 1; i >= 0; i-- {
+		number := corrupted[i].Number
+		factorial = new(big.Int).Mul(factorial, productRange(ancestorNumber+1, number))
+		ancestorNumber = number
+
+		factorialStr := factorial.String()
+		object, err := s.storage.UploadFactorial(context.WithoutCancel(ctx), number, factorialStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to upload factorial to S3: %w", err)
+		}
+		err = s.repository.UpdateResult(number, object, checksum(factorialStr), int64(len(factorialStr)), domain.StatusDone)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update factorial record: %w", err)
+		}
+	}
+	return factorial, nil
+}
+
 func (s *factorialService) getPreviousFactorial(number int64) (*big.Int, error) {
 	// number is already (current - 1), so we use it directly
 	// For example: if current=4, we call getPreviousFactorial(3), so we want factorial(3)
@@ -145,8 +497,25 @@ func (s *factorialService) getPreviousFactorial(number int64) (*big.Int, error)
 		// Factorial of 0 is 1, no previous needed
 		return big.NewInt(1), nil
 	}
-	key := s.storage.GenerateKey(number)
-	result, err := s.storage.DownloadFactorial(context.Background(), key)
+	ctx := context.Background()
+	var result string
+	calc, err := s.repository.FindByNumber(number)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to find factorial %d: %w", number, err)
+	}
+	switch {
+	case err == nil && calc.Derived:
+		result, err = s.ResolveFactorial(ctx, calc)
+	case err == nil && calc.S3Key != "":
+		// The recorded key may be of another encoding than the current one
+		result, err = s.downloadVerified(ctx, calc)
+	default:
+		result, err = s.storage.DownloadFactorial(ctx, s.storage.GenerateKey(number))
+	}
+	if errors.Is(err, ErrChecksumMismatch) {
+		// A corrupted checkpoint must not poison the factorials calculated from it
+		return s.recoverFactorial(ctx, calc)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to download factorial from S3: %w", err)
 	}
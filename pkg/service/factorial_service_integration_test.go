@@ -3,7 +3,10 @@ package service
 import (
 	"context"
 	"fmt"
+	"io"
 	"math/big"
+	"runtime"
+	"strings"
 	"testing"
 	"time"
 
@@ -27,16 +30,16 @@ func newMockStorageService() *mockStorageService {
 	}
 }
 
-func (m *mockStorageService) UploadFactorial(ctx context.Context, number int64, result string) (string, error) {
+func (m *mockStorageService) UploadFactorial(ctx context.Context, number int64, result string) (*domain.StoredObject, error) {
 	key := m.GenerateKey(number)
 	m.storage[key] = result
-	return key, nil
+	return &domain.StoredObject{Key: key, Bucket: m.GetBucket(), Encoding: domain.EncodingText, Size: int64(len(result))}, nil
 }
 
 func (m *mockStorageService) DownloadFactorial(ctx context.Context, s3Key string) (string, error) {
 	value, ok := m.storage[s3Key]
 	if !ok {
-		return "", fmt.Errorf("key not found: %s", s3Key)
+		return "", fmt.Errorf("%w: %s", ErrObjectNotFound, s3Key)
 	}
 	return value, nil
 }
@@ -45,10 +48,27 @@ func (m *mockStorageService) GenerateKey(number int64) string {
 	return fmt.Sprintf("%d.txt", number)
 }
 
+func (m *mockStorageService) OpenReader(ctx context.Context, key string) (io.ReadSeekCloser, error) {
+	value, ok := m.storage[key]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrObjectNotFound, key)
+	}
+	return readSeekNopCloser{strings.NewReader(value)}, nil
+}
+
 func (m *mockStorageService) GetBucket() string {
 	return "test-bucket"
 }
 
+// readSeekNopCloser adds a no-op Close to an in-memory reader
+type readSeekNopCloser struct {
+	io.ReadSeeker
+}
+
+func (readSeekNopCloser) Close() error {
+	return nil
+}
+
 // setupTestDB creates an in-memory SQLite database with schema
 func setupTestDB(t *testing.T) *gorm.DB {
 	// Use in-memory SQLite database
@@ -174,7 +194,7 @@ func TestFactorialService_Integration_UpTo10(t *testing.T) {
 	}
 
 	// Calculate factorials from 4 to 10
-	err = factorialService.continuelyCalculateFactorial(current, max, nil)
+	err = factorialService.continuelyCalculateFactorial(context.Background(), current, max, nil)
 	if err != nil {
 		t.Fatalf("Failed to calculate factorials: %v", err)
 	}
@@ -299,7 +319,7 @@ func TestFactorialService_Integration_SequentialCalculation(t *testing.T) {
 	// Calculate factorials sequentially: 4, 5, 6, 7, 8, 9, 10
 	current, _ := currentCalculatedRepo.GetCurrentNumber()
 	max, _ := maxRequestRepo.GetMaxNumber()
-	err = factorialService.continuelyCalculateFactorial(current, max, nil)
+	err = factorialService.continuelyCalculateFactorial(context.Background(), current, max, nil)
 	if err != nil {
 		t.Fatalf("Failed to calculate factorials: %v", err)
 	}
@@ -397,7 +417,7 @@ func TestFactorialService_Integration_WithRedis(t *testing.T) {
 	// Calculate factorials
 	current, _ := currentCalculatedRepo.GetCurrentNumber()
 	max, _ := maxRequestRepo.GetMaxNumber()
-	err = factorialService.continuelyCalculateFactorial(current, max, nil)
+	err = factorialService.continuelyCalculateFactorial(context.Background(), current, max, nil)
 	if err != nil {
 		t.Fatalf("Failed to calculate factorials: %v", err)
 	}
@@ -468,7 +488,7 @@ func TestFactorialService_Integration_EdgeCases(t *testing.T) {
 	}
 
 	// This should not calculate anything
-	err = factorialService.continuelyCalculateFactorial(current, 2, nil)
+	err = factorialService.continuelyCalculateFactorial(context.Background(), current, 2, nil)
 	if err != nil {
 		t.Fatalf("Should not error when current > max: %v", err)
 	}
@@ -481,12 +501,85 @@ func TestFactorialService_Integration_EdgeCases(t *testing.T) {
 	}
 
 	// Calculate 4 again (already done from previous test)
-	err = factorialService.continuelyCalculateFactorial(4, 4, nil)
+	err = factorialService.continuelyCalculateFactorial(context.Background(), 4, 4, nil)
 	if err != nil {
 		t.Fatalf("Should skip already done factorial: %v", err)
 	}
 }
 
+func TestFactorialService_Integration_SparseCheckpoints(t *testing.T) {
+	// Setup test database
+	db := setupTestDB(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		if sqlDB != nil {
+			sqlDB.Close()
+		}
+	}()
+
+	storage := newMockStorageService()
+	ctx := context.Background()
+
+	// Setup base cases
+	setupBaseCases(t, db, storage, ctx)
+
+	// Initialize repositories
+	factorialRepo := repository.NewFactorialRepository(db)
+	currentCalculatedRepo := repository.NewCurrentCalculatedRepository(db)
+	maxRequestRepo := repository.NewMaxRequestRepository(db)
+
+	// Only every 5th factorial is persisted
+	factorialService := NewFactorialService(
+		factorialRepo,
+		currentCalculatedRepo,
+		maxRequestRepo,
+		storage,
+		WithCheckpointPolicy(NewEveryCheckpointPolicy(5)),
+	).(*factorialService)
+
+	// Calculate in two rounds so the second walk has to seed from a derived row
+	if err := factorialService.continuelyCalculateFactorial(context.Background(), 4, 12, nil); err != nil {
+		t.Fatalf("Failed to calculate factorials: %v", err)
+	}
+	if err := factorialService.continuelyCalculateFactorial(context.Background(), 13, 22, nil); err != nil {
+		t.Fatalf("Failed to calculate factorials: %v", err)
+	}
+
+	for number := int64(4); number <= 22; number++ {
+		t.Run(fmt.Sprintf("Verify_checkpoint_factorial_%d", number), func(t *testing.T) {
+			calc, err := factorialRepo.FindByNumber(number)
+			if err != nil {
+				t.Fatalf("Failed to find factorial %d: %v", number, err)
+			}
+
+			materialized := number%5 == 0
+			if calc.Derived == materialized {
+				t.Errorf("Factorial %d: derived = %v, want %v", number, calc.Derived, !materialized)
+			}
+			_, stored := storage.storage[fmt.Sprintf("%d.txt", number)]
+			if stored != materialized {
+				t.Errorf("Factorial %d: stored = %v, want %v", number, stored, materialized)
+			}
+			// 4! derives from the 3! base case, later rows from the previous multiple of 5
+			wantCheckpoint := max(number-number%5, 3)
+			if calc.Derived && calc.CheckpointNumber != wantCheckpoint {
+				t.Errorf("Factorial %d: checkpoint = %d, want %d", number, calc.CheckpointNumber, wantCheckpoint)
+			}
+
+			result, err := factorialService.ResolveFactorial(ctx, calc)
+			if err != nil {
+				t.Fatalf("Failed to resolve factorial %d: %v", number, err)
+			}
+			if result != calculateFactorial(number).String() {
+				t.Errorf("Factorial %d: expected %s, got %s", number, calculateFactorial(number).String(), result)
+			}
+			if calc.Checksum != checksum(result) {
+				t.Errorf("Factorial %d: checksum mismatch", number)
+			}
+		})
+	}
+}
+
 // Benchmark factorial calculation
 func BenchmarkFactorialService_CalculateUpTo10(b *testing.B) {
 	db := setupTestDB(&testing.T{})
@@ -522,6 +615,23 @@ func BenchmarkFactorialService_CalculateUpTo10(b *testing.B) {
 		maxRequestRepo.UpdateMaxNumber(10)
 		current, _ := currentCalculatedRepo.GetCurrentNumber()
 		max, _ := maxRequestRepo.GetMaxNumber()
-		factorialService.continuelyCalculateFactorial(current, max, nil)
+		factorialService.continuelyCalculateFactorial(context.Background(), current, max, nil)
+	}
+}
+
+// Benchmark direct calculation of a large factorial, single goroutine vs parallel product tree
+func BenchmarkFactorialService_DirectSequential(b *testing.B) {
+	multiplier := NewParallelMultiplier(1)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		multiplier.MulRange(2, 200000)
+	}
+}
+
+func BenchmarkFactorialService_DirectParallel(b *testing.B) {
+	multiplier := NewParallelMultiplier(runtime.NumCPU())
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		multiplier.MulRange(2, 200000)
 	}
 }
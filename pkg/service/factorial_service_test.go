@@ -4,8 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"math/big"
+	"sort"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"factorial-cal-services/pkg/domain"
 
@@ -45,24 +50,133 @@ func (m *mockFactorialRepository) FindByNumber(number int64) (*domain.FactorialC
 	return calc, nil
 }
 
+func (m *mockFactorialRepository) FindLastMaterializedBefore(number int64) (*domain.FactorialCalculation, error) {
+	if m.findError != nil {
+		return nil, m.findError
+	}
+	var last *domain.FactorialCalculation
+	for n, calc := range m.calculations {
+		if n < number && calc.Status == domain.StatusDone && !calc.Derived && calc.S3Key != "" && (last == nil || n > last.Number) {
+			last = calc
+		}
+	}
+	if last == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return last, nil
+}
+
+func (m *mockFactorialRepository) FindByStatus(status string, limit int) ([]domain.FactorialCalculation, error) {
+	if m.findError != nil {
+		return nil, m.findError
+	}
+	var calcs []domain.FactorialCalculation
+	for _, calc := range m.calculations {
+		if calc.Status == status {
+			calcs = append(calcs, *calc)
+		}
+	}
+	sort.Slice(calcs, func(i, j int) bool { return calcs[i].Number < calcs[j].Number })
+	if len(calcs) > limit {
+		calcs = calcs[:limit]
+	}
+	return calcs, nil
+}
+
+func (m *mockFactorialRepository) FindMaterializedAfter(number int64, limit int) ([]domain.FactorialCalculation, error) {
+	if m.findError != nil {
+		return nil, m.findError
+	}
+	var calcs []domain.FactorialCalculation
+	for n, calc := range m.calculations {
+		if n > number && calc.Status == domain.StatusDone && !calc.Derived && calc.S3Key != "" {
+			calcs = append(calcs, *calc)
+		}
+	}
+	sort.Slice(calcs, func(i, j int) bool { return calcs[i].Number < calcs[j].Number })
+	if len(calcs) > limit {
+		calcs = calcs[:limit]
+	}
+	return calcs, nil
+}
+
 func (m *mockFactorialRepository) UpdateStatus(number string, status string) error {
-	return m.updateError
+	if m.updateError != nil {
+		return m.updateError
+	}
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil {
+		return err
+	}
+	if calc, exists := m.calculations[n]; exists {
+		calc.Status = status
+	}
+	return nil
 }
 
-func (m *mockFactorialRepository) UpdateWithCurrentNumber(number int64, s3Key string, checksum string, size int64, status string, bucket string) error {
+func (m *mockFactorialRepository) UpdateWithCurrentNumber(number int64, object *domain.StoredObject, checksum string, size int64, status string) error {
 	if m.updateError != nil {
 		return m.updateError
 	}
 	if calc, exists := m.calculations[number]; exists {
-		calc.S3Key = s3Key
+		calc.S3Key = object.Key
 		calc.Checksum = checksum
 		calc.Size = size
+		calc.Encoding = object.Encoding
+		calc.StoredSize = object.Size
 		calc.Status = status
-		calc.Bucket = bucket
+		calc.Bucket = object.Bucket
+		calc.Derived = false
+		calc.CheckpointNumber = number
 	}
 	return nil
 }
 
+func (m *mockFactorialRepository) UpdateDerivedWithCurrentNumber(number int64, checkpointNumber int64, checksum string, size int64, status string) error {
+	if m.updateError != nil {
+		return m.updateError
+	}
+	if calc, exists := m.calculations[number]; exists {
+		calc.S3Key = ""
+		calc.Checksum = checksum
+		calc.Size = size
+		calc.Status = status
+		calc.Derived = true
+		calc.CheckpointNumber = checkpointNumber
+	}
+	return nil
+}
+
+func (m *mockFactorialRepository) UpdateResult(number int64, object *domain.StoredObject, checksum string, size int64, status string) error {
+	return m.UpdateWithCurrentNumber(number, object, checksum, size, status)
+}
+
+func (m *mockFactorialRepository) CountByStatusInRange(from int64, to int64, status string) (int64, error) {
+	if m.findError != nil {
+		return 0, m.findError
+	}
+	var count int64
+	for number, calc := range m.calculations {
+		if number >= from && number <= to && calc.Status == status {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *mockFactorialRepository) CountByStatusUpdatedSince(status string, since time.Time) (int64, error) {
+	if m.findError != nil {
+		return 0, m.findError
+	}
+	var count int64
+	for _, calc := range m.calculations {
+		if calc.Status == status && !calc.UpdatedAt.Before(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
 // mockCurrentCalculatedRepository is a mock implementation of CurrentCalculatedRepository
 type mockCurrentCalculatedRepository struct {
 	currentNumber int64
@@ -93,10 +207,11 @@ func (m *mockCurrentCalculatedRepository) UpdateCurrentNumber(curNumber int64) e
 
 // mockMaxRequestRepository is a mock implementation of MaxRequestRepository
 type mockMaxRequestRepository struct {
-	maxNumber   int64
-	getError    error
-	updateError error
-	setError    error
+	maxNumber         int64
+	priorityMaxNumber int64
+	getError          error
+	updateError       error
+	setError          error
 }
 
 func newMockMaxRequestRepository() *mockMaxRequestRepository {
@@ -109,7 +224,7 @@ func (m *mockMaxRequestRepository) GetMaxNumber() (int64, error) {
 	if m.getError != nil {
 		return 0, m.getError
 	}
-	return m.maxNumber, nil
+	return max(m.maxNumber, m.priorityMaxNumber), nil
 }
 
 func (m *mockMaxRequestRepository) UpdateMaxNumber(maxNumber int64) error {
@@ -131,6 +246,24 @@ func (m *mockMaxRequestRepository) SetMaxNumberIfGreater(maxNumber int64) (int64
 	return 0, nil
 }
 
+func (m *mockMaxRequestRepository) GetPriorityMaxNumber() (int64, error) {
+	if m.getError != nil {
+		return 0, m.getError
+	}
+	return m.priorityMaxNumber, nil
+}
+
+func (m *mockMaxRequestRepository) SetPriorityMaxNumberIfGreater(maxNumber int64) (int64, error) {
+	if m.setError != nil {
+		return 0, m.setError
+	}
+	if maxNumber > m.priorityMaxNumber {
+		m.priorityMaxNumber = maxNumber
+		return 1, nil
+	}
+	return 0, nil
+}
+
 // unitTestMockStorageService is a mock implementation of StorageService for unit tests
 type unitTestMockStorageService struct {
 	storage       map[string]string
@@ -144,13 +277,13 @@ func newUnitTestMockStorageService() *unitTestMockStorageService {
 	}
 }
 
-func (m *unitTestMockStorageService) UploadFactorial(ctx context.Context, number int64, result string) (string, error) {
+func (m *unitTestMockStorageService) UploadFactorial(ctx context.Context, number int64, result string) (*domain.StoredObject, error) {
 	if m.uploadError != nil {
-		return "", m.uploadError
+		return nil, m.uploadError
 	}
 	key := m.GenerateKey(number)
 	m.storage[key] = result
-	return key, nil
+	return &domain.StoredObject{Key: key, Bucket: m.GetBucket(), Encoding: domain.EncodingText, Size: int64(len(result))}, nil
 }
 
 func (m *unitTestMockStorageService) DownloadFactorial(ctx context.Context, s3Key string) (string, error) {
@@ -159,7 +292,7 @@ func (m *unitTestMockStorageService) DownloadFactorial(ctx context.Context, s3Ke
 	}
 	result, exists := m.storage[s3Key]
 	if !exists {
-		return "", errors.New("key not found")
+		return "", fmt.Errorf("%w: %s", ErrObjectNotFound, s3Key)
 	}
 	return result, nil
 }
@@ -168,6 +301,17 @@ func (m *unitTestMockStorageService) GenerateKey(number int64) string {
 	return fmt.Sprintf("%d.txt", number)
 }
 
+func (m *unitTestMockStorageService) OpenReader(ctx context.Context, key string) (io.ReadSeekCloser, error) {
+	if m.downloadError != nil {
+		return nil, m.downloadError
+	}
+	value, ok := m.storage[key]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrObjectNotFound, key)
+	}
+	return readSeekNopCloser{strings.NewReader(value)}, nil
+}
+
 func (m *unitTestMockStorageService) GetBucket() string {
 	return "test-bucket"
 }
@@ -551,7 +695,7 @@ func TestFactorialService_ContinuelyCalculateFactorial_EdgeCases(t *testing.T) {
 				mockStorage,
 			).(*factorialService)
 
-			err := service.continuelyCalculateFactorial(tt.current, tt.max, nil)
+			err := service.continuelyCalculateFactorial(context.Background(), tt.current, tt.max, nil)
 			if (err != nil) != tt.wantError {
 				t.Errorf("continuelyCalculateFactorial() error = %v, wantError %v", err, tt.wantError)
 				return
@@ -564,3 +708,33 @@ func TestFactorialService_ContinuelyCalculateFactorial_EdgeCases(t *testing.T) {
 		})
 	}
 }
+
+func TestFactorialService_StopsWhenContextCancelled(t *testing.T) {
+	mockRepo := newMockFactorialRepository()
+	mockStorage := newUnitTestMockStorageService()
+	mockStorage.storage[mockStorage.GenerateKey(4)] = "24"
+
+	service := NewFactorialService(
+		mockRepo,
+		newMockCurrentCalculatedRepository(),
+		newMockMaxRequestRepository(),
+		mockStorage,
+	).(*factorialService)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := service.continuelyCalculateFactorial(ctx, 5, 10, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	if len(mockRepo.calculations) != 0 {
+		t.Errorf("Expected no factorial to be started after cancellation, got %d", len(mockRepo.calculations))
+	}
+
+	select {
+	case <-service.StartContinuelyCalculateFactorial(ctx):
+	case <-time.After(time.Second):
+		t.Fatal("Expected the calculator to stop once its context is cancelled")
+	}
+}
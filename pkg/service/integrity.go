@@ -0,0 +1,108 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"expvar"
+	"hash"
+	"io"
+	"log"
+)
+
+// ErrChecksumMismatch is returned when a stored factorial does not match its recorded checksum
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// checksumMismatches counts stored factorials that failed verification, published with expvar
+var checksumMismatches = expvar.NewInt("factorial_checksum_mismatches")
+
+// IntegrityAlerter is told about stored factorials that fail checksum verification
+type IntegrityAlerter interface {
+	ChecksumMismatch(number int64, key string)
+}
+
+type logIntegrityAlerter struct{}
+
+// NewLogIntegrityAlerter logs an alert for every mismatch and counts it in the
+// factorial_checksum_mismatches expvar
+func NewLogIntegrityAlerter() IntegrityAlerter {
+	return logIntegrityAlerter{}
+}
+
+// ChecksumMismatch logs the alert and increments the mismatch counter
+func (logIntegrityAlerter) ChecksumMismatch(number int64, key string) {
+	checksumMismatches.Add(1)
+	log.Printf("ALERT: stored factorial %d (%s) does not match its checksum, it is recalculated", number, key)
+}
+
+// verifyingReader hashes an object read from start to end and withholds its last byte when
+// the hash does not match, so a corrupted object never reaches a client as a complete response.
+// Reads that do not start at offset 0, such as byte ranges, are not verified.
+type verifyingReader struct {
+	io.ReadSeekCloser
+	size       int64
+	expected   string
+	onMismatch func()
+	hash       hash.Hash
+	offset     int64
+	verify     bool
+}
+
+// newVerifyingReader verifies reader against the expected checksum. An object whose size
+// differs from size, when known, fails verification at once.
+func newVerifyingReader(reader io.ReadSeekCloser, size int64, expected string, onMismatch func()) (io.ReadSeekCloser, error) {
+	actual, err := reader.Seek(0, io.SeekEnd)
+	if err == nil {
+		_, err = reader.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		reader.Close()
+		return nil, err
+	}
+	if size > 0 && actual != size {
+		reader.Close()
+		onMismatch()
+		return nil, ErrChecksumMismatch
+	}
+	return &verifyingReader{
+		ReadSeekCloser: reader,
+		size:           actual,
+		expected:       expected,
+		onMismatch:     onMismatch,
+		hash:           sha256.New(),
+		verify:         true,
+	}, nil
+}
+
+// Read reads from the object, checking the checksum once the last byte is read
+func (r *verifyingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadSeekCloser.Read(p)
+	if !r.verify || n == 0 {
+		r.offset += int64(n)
+		return n, err
+	}
+
+	r.hash.Write(p[:n])
+	r.offset += int64(n)
+	if r.offset < r.size {
+		return n, err
+	}
+	r.verify = false
+	if hex.EncodeToString(r.hash.Sum(nil)) != r.expected {
+		r.onMismatch()
+		return n - 1, ErrChecksumMismatch
+	}
+	return n, err
+}
+
+// Seek moves the offset; the object is verified again when it is read from offset 0
+func (r *verifyingReader) Seek(offset int64, whence int) (int64, error) {
+	position, err := r.ReadSeekCloser.Seek(offset, whence)
+	if err != nil {
+		return position, err
+	}
+	r.offset = position
+	r.verify = position == 0
+	r.hash.Reset()
+	return position, nil
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"factorial-cal-services/pkg/domain"
+)
+
+// recordingIntegrityAlerter records the factorials reported as corrupted
+type recordingIntegrityAlerter struct {
+	numbers []int64
+}
+
+func (a *recordingIntegrityAlerter) ChecksumMismatch(number int64, key string) {
+	a.numbers = append(a.numbers, number)
+}
+
+// materialize records a done factorial with its object and checksum
+func materialize(repo *mockFactorialRepository, storage *unitTestMockStorageService, number int64) {
+	result := calculateFactorial(number).String()
+	key := storage.GenerateKey(number)
+	storage.storage[key] = result
+	repo.calculations[number] = &domain.FactorialCalculation{
+		Number:   number,
+		Status:   domain.StatusDone,
+		S3Key:    key,
+		Checksum: checksum(result),
+		Size:     int64(len(result)),
+	}
+}
+
+func TestVerifyingReader(t *testing.T) {
+	expected := checksum("3628800")
+
+	tests := []struct {
+		name         string
+		data         string
+		size         int64
+		seek         int64
+		wantErr      error
+		wantMismatch bool
+	}{
+		{name: "intact", data: "3628800", size: 7},
+		{name: "corrupted", data: "3628900", size: 7, wantErr: ErrChecksumMismatch, wantMismatch: true},
+		{name: "corrupted range is not verified", data: "3628900", size: 7, seek: 2},
+		{name: "truncated", data: "36288", size: 7, wantErr: ErrChecksumMismatch, wantMismatch: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mismatch := false
+			reader, err := newVerifyingReader(nopReadSeekCloser{strings.NewReader(tt.data)}, tt.size, expected, func() { mismatch = true })
+			if err == nil {
+				defer reader.Close()
+				if _, err = reader.Seek(tt.seek, io.SeekStart); err != nil {
+					t.Fatalf("Seek failed: %v", err)
+				}
+				var data []byte
+				data, err = io.ReadAll(reader)
+				if err == nil && string(data) != tt.data[tt.seek:] {
+					t.Errorf("Expected %q, got %q", tt.data[tt.seek:], data)
+				}
+			}
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if mismatch != tt.wantMismatch {
+				t.Errorf("Expected mismatch reported %v, got %v", tt.wantMismatch, mismatch)
+			}
+		})
+	}
+}
+
+func TestFactorialService_ResolveFactorial_Corrupted(t *testing.T) {
+	repo := newMockFactorialRepository()
+	storage := newUnitTestMockStorageService()
+	alerter := &recordingIntegrityAlerter{}
+	svc := NewFactorialService(repo, newMockCurrentCalculatedRepository(), newMockMaxRequestRepository(), storage,
+		WithIntegrityAlerter(alerter)).(*factorialService)
+
+	materialize(repo, storage, 3)
+	materialize(repo, storage, 5)
+	storage.storage[storage.GenerateKey(5)] = "121"
+
+	if _, err := svc.ResolveFactorial(context.Background(), repo.calculations[5]); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("Expected ErrChecksumMismatch, got %v", err)
+	}
+	if status := repo.calculations[5].Status; status != domain.StatusFailed {
+		t.Errorf("Expected status failed, got %s", status)
+	}
+	if len(alerter.numbers) != 1 || alerter.numbers[0] != 5 {
+		t.Errorf("Expected an alert for 5, got %v", alerter.numbers)
+	}
+
+	if err := svc.recalculateFailed(context.Background()); err != nil {
+		t.Fatalf("recalculateFailed failed: %v", err)
+	}
+	result, err := svc.ResolveFactorial(context.Background(), repo.calculations[5])
+	if err != nil || result != "120" {
+		t.Errorf("Expected 120 after recalculation, got %q (%v)", result, err)
+	}
+}
+
+func TestFactorialService_GetPreviousFactorial_RecoversFromVerifiedAncestor(t *testing.T) {
+	repo := newMockFactorialRepository()
+	storage := newUnitTestMockStorageService()
+	alerter := &recordingIntegrityAlerter{}
+	svc := NewFactorialService(repo, newMockCurrentCalculatedRepository(), newMockMaxRequestRepository(), storage,
+		WithIntegrityAlerter(alerter)).(*factorialService)
+
+	for _, n := range []int64{3, 5, 7} {
+		materialize(repo, storage, n)
+	}
+	storage.storage[storage.GenerateKey(5)] = "1"
+	storage.storage[storage.GenerateKey(7)] = "1"
+
+	factorial, err := svc.getPreviousFactorial(7)
+	if err != nil {
+		t.Fatalf("getPreviousFactorial failed: %v", err)
+	}
+	if factorial.Cmp(calculateFactorial(7)) != 0 {
+		t.Errorf("Expected %s, got %s", calculateFactorial(7), factorial)
+	}
+	for _, n := range []int64{5, 7} {
+		calc := repo.calculations[n]
+		if calc.Status != domain.StatusDone || storage.storage[calc.S3Key] != calculateFactorial(n).String() {
+			t.Errorf("Expected factorial %d to be stored again, got %s %q", n, calc.Status, storage.storage[calc.S3Key])
+		}
+	}
+	if len(alerter.numbers) != 2 {
+		t.Errorf("Expected alerts for 7 and 5, got %v", alerter.numbers)
+	}
+}
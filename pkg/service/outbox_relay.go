@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"factorial-cal-services/pkg/domain"
+	"factorial-cal-services/pkg/producer"
+	"factorial-cal-services/pkg/repository"
+)
+
+const (
+	DefaultOutboxRelayInterval = 200 * time.Millisecond
+	DefaultOutboxMaxAttempts   = 10
+
+	// outboxRelayBatchSize is the number of outbox messages locked and published per transaction
+	outboxRelayBatchSize = 100
+	// outboxPublishTimeout bounds a single publish so a hung broker does not hold the row locks
+	outboxPublishTimeout = 10 * time.Second
+	// outboxRetryBackoff is the delay before the second attempt of a failed message, doubled
+	// for every further attempt up to outboxMaxRetryBackoff
+	outboxRetryBackoff    = time.Second
+	outboxMaxRetryBackoff = time.Minute
+)
+
+// OutboxRelay publishes the messages of the transactional outbox to the queue
+type OutboxRelay interface {
+	StartRelaying(ctx context.Context) <-chan struct{}
+}
+
+type outboxRelay struct {
+	interval         time.Duration
+	maxAttempts      int
+	outboxRepository repository.OutboxRepository
+	producer         producer.Producer
+}
+
+// NewOutboxRelay creates a new outbox relay polling for unsent messages every interval.
+// A message failing maxAttempts publishes is parked as failed, so it stops being retried.
+func NewOutboxRelay(
+	outboxRepository repository.OutboxRepository,
+	producer producer.Producer,
+	interval time.Duration,
+	maxAttempts int,
+) OutboxRelay {
+	if interval <= 0 {
+		interval = DefaultOutboxRelayInterval
+	}
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultOutboxMaxAttempts
+	}
+	return &outboxRelay{
+		interval:         interval,
+		maxAttempts:      maxAttempts,
+		outboxRepository: outboxRepository,
+		producer:         producer,
+	}
+}
+
+// StartRelaying publishes unsent outbox messages in the background until ctx is done.
+// Messages left unsent are published by the next relay; the returned channel is closed once relaying stopped.
+func (r *outboxRelay) StartRelaying(ctx context.Context) <-chan struct{} {
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-ctx.Done():
+				log.Println("outbox relaying stopped")
+				return
+			case <-time.After(r.interval):
+			}
+			if err := r.relayPending(context.WithoutCancel(ctx)); err != nil {
+				log.Printf("failed to relay outbox: %v", err)
+			}
+		}
+	}()
+	return stopped
+}
+
+// relayPending publishes due messages batch by batch until the outbox is drained or a publish fails
+func (r *outboxRelay) relayPending(ctx context.Context) error {
+	for {
+		sent, err := r.outboxRepository.RelayPending(outboxRelayBatchSize, r.maxAttempts, outboxRetryDelay, func(message *domain.OutboxMessage) error {
+			publishCtx, cancel := context.WithTimeout(ctx, outboxPublishTimeout)
+			defer cancel()
+			return r.producer.Publish(publishCtx, message.Queue, message.Payload)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to relay outbox messages: %w", err)
+		}
+		if sent < outboxRelayBatchSize {
+			return nil
+		}
+	}
+}
+
+// outboxRetryDelay returns the delay before retrying a message that failed attempts times
+func outboxRetryDelay(attempts int) time.Duration {
+	delay := outboxRetryBackoff
+	for i := 1; i < attempts && delay < outboxMaxRetryBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, outboxMaxRetryBackoff)
+}
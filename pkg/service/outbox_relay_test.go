@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"factorial-cal-services/pkg/domain"
+	"factorial-cal-services/pkg/repository"
+)
+
+// recordingProducer records published payloads and fails while err is set, or for failQueue
+type recordingProducer struct {
+	err       error
+	failQueue string
+	payloads  []string
+}
+
+func (p *recordingProducer) Publish(ctx context.Context, queueName string, payload []byte) error {
+	if p.err != nil {
+		return p.err
+	}
+	if queueName == p.failQueue {
+		return fmt.Errorf("message is unroutable: %s", queueName)
+	}
+	p.payloads = append(p.payloads, queueName+":"+string(payload))
+	return nil
+}
+
+func (p *recordingProducer) Close() error {
+	return nil
+}
+
+func TestOutboxRelay_RelayPending(t *testing.T) {
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&domain.OutboxMessage{}, &domain.FactorialSubmission{}, &domain.FactorialBatch{}); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+	outboxRepo := repository.NewOutboxRepository(db)
+
+	batch := &domain.FactorialBatch{BatchID: "batch-1", FromNumber: 1, ToNumber: 3}
+	if err := outboxRepo.Enqueue(&domain.FactorialSubmission{SubmissionID: "s-1"}, &domain.OutboxMessage{Queue: "q", Payload: []byte("first")}, batch); err != nil {
+		t.Fatalf("Enqueue failed: %v", err)
+	}
+	if err := outboxRepo.Enqueue(&domain.FactorialSubmission{SubmissionID: "s-2"}, &domain.OutboxMessage{Queue: "q", Payload: []byte("second")}); err != nil {
+		t.Fatalf("Enqueue failed: %v", err)
+	}
+	// A record that fails to insert rolls back its submission and message
+	duplicate := &domain.FactorialBatch{BatchID: "batch-1", FromNumber: 1, ToNumber: 3}
+	if err := outboxRepo.Enqueue(&domain.FactorialSubmission{SubmissionID: "s-3"}, &domain.OutboxMessage{Queue: "q", Payload: []byte("rolled back")}, duplicate); err == nil {
+		t.Fatal("Expected duplicate batch to fail")
+	}
+	submissionRepo := repository.NewSubmissionRepository(db)
+	if _, err := submissionRepo.FindBySubmissionID("s-3"); err == nil {
+		t.Error("Expected the submission of a rolled back message not to be committed")
+	}
+
+	producer := &recordingProducer{err: errors.New("broker down")}
+	relay := NewOutboxRelay(outboxRepo, producer, 0, 0).(*outboxRelay)
+
+	if err := relay.relayPending(context.Background()); err == nil {
+		t.Fatal("Expected relay to fail while the broker is down")
+	}
+	var failed domain.OutboxMessage
+	db.Order("id").First(&failed)
+	if failed.SentAt != nil || failed.FailedAt != nil || failed.Attempts != 1 || failed.LastError == "" || failed.SubmissionID != "s-1" {
+		t.Errorf("Expected unsent message with a recorded failure, got %+v", failed)
+	}
+	if !failed.NextAttemptAt.After(time.Now()) {
+		t.Errorf("Expected the failed message to be retried later, got %v", failed.NextAttemptAt)
+	}
+	// The client sees the publish failure on the submission
+	submission, err := submissionRepo.FindBySubmissionID("s-1")
+	if err != nil || submission.Status != domain.SubmissionStatusPending || submission.LastError != "broker down" {
+		t.Errorf("Expected pending submission with the publish error, got %+v (%v)", submission, err)
+	}
+
+	// The failed message waits for its retry without holding up the one behind it
+	producer.err = nil
+	if err := relay.relayPending(context.Background()); err != nil {
+		t.Fatalf("relayPending failed: %v", err)
+	}
+	if len(producer.payloads) != 1 || producer.payloads[0] != "q:second" {
+		t.Fatalf("Expected only the second message published, got %v", producer.payloads)
+	}
+	db.Model(&domain.OutboxMessage{}).Where("id = ?", failed.ID).Update("next_attempt_at", time.Now())
+	if err := relay.relayPending(context.Background()); err != nil {
+		t.Fatalf("relayPending failed: %v", err)
+	}
+	expected := []string{"q:second", "q:first"}
+	if len(producer.payloads) != len(expected) || producer.payloads[0] != expected[0] || producer.payloads[1] != expected[1] {
+		t.Fatalf("Expected published payloads %v, got %v", expected, producer.payloads)
+	}
+
+	var pending int64
+	db.Model(&domain.OutboxMessage{}).Where("sent_at IS NULL").Count(&pending)
+	if pending != 0 {
+		t.Errorf("Expected every message to be marked sent, %d pending", pending)
+	}
+	for _, id := range []string{"s-1", "s-2"} {
+		submission, err := submissionRepo.FindBySubmissionID(id)
+		if err != nil || submission.Status != domain.SubmissionStatusPublished || submission.LastError != "" || submission.PublishedAt == nil {
+			t.Errorf("Expected submission %s to be published, got %+v (%v)", id, submission, err)
+		}
+	}
+
+	// Sent messages are not published again
+	if err := relay.relayPending(context.Background()); err != nil {
+		t.Fatalf("relayPending failed: %v", err)
+	}
+	if len(producer.payloads) != len(expected) {
+		t.Errorf("Expected no republish, got %v", producer.payloads)
+	}
+}
+
+func TestOutboxRelay_ParksMessageAfterMaxAttempts(t *testing.T) {
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&domain.OutboxMessage{}, &domain.FactorialSubmission{}); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+	outboxRepo := repository.NewOutboxRepository(db)
+	submissionRepo := repository.NewSubmissionRepository(db)
+	for i, queue := range []string{"unroutable", "q"} {
+		submission := &domain.FactorialSubmission{SubmissionID: fmt.Sprintf("s-%d", i)}
+		if err := outboxRepo.Enqueue(submission, &domain.OutboxMessage{Queue: queue, Payload: []byte(queue)}); err != nil {
+			t.Fatalf("Enqueue failed: %v", err)
+		}
+	}
+
+	producer := &recordingProducer{failQueue: "unroutable"}
+	relay := NewOutboxRelay(outboxRepo, producer, 0, 2).(*outboxRelay)
+	for attempt := 1; attempt <= 2; attempt++ {
+		if err := relay.relayPending(context.Background()); err == nil {
+			t.Fatalf("Expected attempt %d of the unroutable message to fail", attempt)
+		}
+		db.Model(&domain.OutboxMessage{}).Where("queue = ?", "unroutable").Update("next_attempt_at", time.Now())
+	}
+
+	var parked domain.OutboxMessage
+	db.Where("queue = ?", "unroutable").First(&parked)
+	if parked.FailedAt == nil || parked.SentAt != nil || parked.Attempts != 2 {
+		t.Errorf("Expected the message parked after 2 attempts, got %+v", parked)
+	}
+	submission, err := submissionRepo.FindBySubmissionID("s-0")
+	if err != nil || submission.Status != domain.SubmissionStatusFailed || submission.LastError == "" {
+		t.Errorf("Expected a failed submission with the publish error, got %+v (%v)", submission, err)
+	}
+
+	// The parked message is not retried and the rest of the outbox is relayed
+	if err := relay.relayPending(context.Background()); err != nil {
+		t.Fatalf("relayPending failed: %v", err)
+	}
+	if len(producer.payloads) != 1 || producer.payloads[0] != "q:q" {
+		t.Errorf("Expected only the routable message published, got %v", producer.payloads)
+	}
+
+	// Sent messages are purged once old enough, parked ones are kept
+	deleted, err := outboxRepo.DeleteSentBefore(time.Now().Add(time.Second))
+	if err != nil || deleted != 1 {
+		t.Fatalf("Expected one sent message deleted, got %d (%v)", deleted, err)
+	}
+	var remaining int64
+	db.Model(&domain.OutboxMessage{}).Count(&remaining)
+	if remaining != 1 {
+		t.Errorf("Expected the parked message to be kept, %d messages left", remaining)
+	}
+}
+
+func TestOutboxRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{4, 8 * time.Second},
+		{10, time.Minute},
+	}
+	for _, tt := range tests {
+		if got := outboxRetryDelay(tt.attempts); got != tt.want {
+			t.Errorf("outboxRetryDelay(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
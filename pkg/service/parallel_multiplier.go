@@ -0,0 +1,123 @@
+package service
+
+import (
+	"math/big"
+	"sync"
+
+	"factorial-cal-services/pkg/utils/patterns"
+)
+
+const (
+	// minParallelRange is the range length below which splitting costs more than it saves
+	minParallelRange = 4096
+	// minParallelFactors is the factor count below which splitting costs more than it saves
+	minParallelFactors = 512
+	// segmentsPerWorker oversplits the range so workers finishing early can pick up more work
+	segmentsPerWorker = 4
+)
+
+// Multiplier computes large products
+type Multiplier interface {
+	// MulRange multiplies all integers in [a, b]
+	MulRange(a, b int64) *big.Int
+	// MulAll multiplies all the given factors
+	MulAll(factors []*big.Int) *big.Int
+}
+
+type parallelMultiplier struct {
+	parallelism int
+}
+
+// NewParallelMultiplier creates a multiplier that splits a range into segments,
+// multiplies them concurrently on a worker pool and merges the partial products
+func NewParallelMultiplier(parallelism int) Multiplier {
+	if parallelism <= 0 {
+		parallelism = 1
+	}
+	return &parallelMultiplier{
+		parallelism: parallelism,
+	}
+}
+
+// MulRange multiplies all integers in [a, b]
+func (m *parallelMultiplier) MulRange(a, b int64) *big.Int {
+	if m.parallelism == 1 || b-a+1 < minParallelRange {
+		return productRange(a, b)
+	}
+
+	pool := patterns.NewWorkerPool(m.parallelism)
+	pool.Run()
+	defer pool.Close()
+
+	segments := splitRange(a, b, m.parallelism*segmentsPerWorker)
+	products := make([]*big.Int, len(segments))
+	runAll(pool, len(segments), func(i int) {
+		products[i] = productRange(segments[i][0], segments[i][1])
+	})
+	return mergeProducts(pool, products)
+}
+
+// MulAll multiplies all the given factors
+func (m *parallelMultiplier) MulAll(factors []*big.Int) *big.Int {
+	if m.parallelism == 1 || len(factors) < minParallelFactors {
+		return productOf(factors)
+	}
+
+	pool := patterns.NewWorkerPool(m.parallelism)
+	pool.Run()
+	defer pool.Close()
+
+	segments := splitRange(0, int64(len(factors)-1), m.parallelism*segmentsPerWorker)
+	products := make([]*big.Int, len(segments))
+	runAll(pool, len(segments), func(i int) {
+		products[i] = productOf(factors[segments[i][0] : segments[i][1]+1])
+	})
+	return mergeProducts(pool, products)
+}
+
+// mergeProducts multiplies the partial products on the pool and returns the total
+func mergeProducts(pool *patterns.WorkerPool, products []*big.Int) *big.Int {
+	// Merge neighbouring products pairwise so every round keeps the workers busy
+	for len(products) > 1 {
+		merged := make([]*big.Int, (len(products)+1)/2)
+		runAll(pool, len(merged), func(i int) {
+			if 2*i+1 == len(products) {
+				merged[i] = products[2*i]
+				return
+			}
+			merged[i] = new(big.Int).Mul(products[2*i], products[2*i+1])
+		})
+		products = merged
+	}
+	return products[0]
+}
+
+// runAll submits n tasks to the pool and waits until all of them finish
+func runAll(pool *patterns.WorkerPool, n int, task func(i int)) {
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		pool.Submit(func() error {
+			defer wg.Done()
+			task(i)
+			return nil
+		})
+	}
+	wg.Wait()
+}
+
+// splitRange splits [a, b] into at most n contiguous segments of similar length
+func splitRange(a, b int64, n int) [][2]int64 {
+	length := b - a + 1
+	if int64(n) > length {
+		n = int(length)
+	}
+	segments := make([][2]int64, 0, n)
+	start := a
+	for i := 0; i < n; i++ {
+		end := start + (length-(start-a))/int64(n-i) - 1
+		segments = append(segments, [2]int64{start, end})
+		start = end + 1
+	}
+	return segments
+}
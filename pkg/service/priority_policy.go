@@ -0,0 +1,29 @@
+package service
+
+import (
+	"factorial-cal-services/pkg/domain"
+)
+
+// PriorityPolicy decides the priority a requested number is queued and calculated with
+type PriorityPolicy interface {
+	Priority(number int64) int
+}
+
+type priorityPolicy struct {
+	numberThreshold int64
+}
+
+// NewPriorityPolicy gives high priority to numbers up to numberThreshold, which are cheap to
+// calculate; a negative threshold disables it. The client ID is set by the caller and not
+// authenticated, so it does not affect the priority.
+func NewPriorityPolicy(numberThreshold int64) PriorityPolicy {
+	return &priorityPolicy{numberThreshold: numberThreshold}
+}
+
+// Priority returns domain.PriorityHigh for small numbers, domain.PriorityNormal otherwise
+func (p *priorityPolicy) Priority(number int64) int {
+	if number <= p.numberThreshold {
+		return domain.PriorityHigh
+	}
+	return domain.PriorityNormal
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"testing"
+
+	"factorial-cal-services/pkg/domain"
+)
+
+func TestPriorityPolicy(t *testing.T) {
+	policy := NewPriorityPolicy(100)
+
+	tests := []struct {
+		number int64
+		want   int
+	}{
+		{number: 0, want: domain.PriorityHigh},
+		{number: 100, want: domain.PriorityHigh},
+		{number: 101, want: domain.PriorityNormal},
+		{number: 9999, want: domain.PriorityNormal},
+	}
+	for _, tt := range tests {
+		if got := policy.Priority(tt.number); got != tt.want {
+			t.Errorf("Priority(%d) = %d, want %d", tt.number, got, tt.want)
+		}
+	}
+
+	if got := NewPriorityPolicy(-1).Priority(0); got != domain.PriorityNormal {
+		t.Errorf("Expected a negative threshold to disable the number rule, got %d", got)
+	}
+}
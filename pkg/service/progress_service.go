@@ -0,0 +1,96 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"factorial-cal-services/pkg/domain"
+	"factorial-cal-services/pkg/repository"
+)
+
+// DefaultProgressWindow is the sliding window calculator throughput is measured over
+const DefaultProgressWindow = 5 * time.Minute
+
+// CalculationProgress describes where the calculator stands relative to a requested number
+type CalculationProgress struct {
+	Number int64
+	// Current is the next number the calculator walks to
+	Current int64
+	// Target is the highest requested number; numbers above it are not queued
+	Target int64
+	// Remaining is the number of factorials the calculator computes before reaching Number
+	Remaining int64
+	// Throughput is the number of factorials completed per second over Window
+	Throughput float64
+	Window     time.Duration
+	// EstimatedCompletionAt is nil when Number is not queued or nothing completed within Window
+	EstimatedCompletionAt *time.Time
+}
+
+// Queued reports whether the calculator walk will reach the number
+func (p *CalculationProgress) Queued() bool {
+	return p.Number <= p.Target
+}
+
+// ProgressService estimates when a factorial that is not done yet will be
+type ProgressService interface {
+	GetProgress(number int64) (*CalculationProgress, error)
+}
+
+type progressService struct {
+	window                      time.Duration
+	repository                  repository.FactorialRepository
+	currentCalculatedRepository repository.CurrentCalculatedRepository
+	maxRequestRepository        repository.MaxRequestRepository
+}
+
+// NewProgressService creates a new progress service measuring throughput over window
+func NewProgressService(
+	repository repository.FactorialRepository,
+	currentCalculatedRepository repository.CurrentCalculatedRepository,
+	maxRequestRepository repository.MaxRequestRepository,
+	window time.Duration,
+) ProgressService {
+	if window <= 0 {
+		window = DefaultProgressWindow
+	}
+	return &progressService{
+		window:                      window,
+		repository:                  repository,
+		currentCalculatedRepository: currentCalculatedRepository,
+		maxRequestRepository:        maxRequestRepository,
+	}
+}
+
+// GetProgress returns the calculator position, target and throughput, and estimates
+// the completion time of number from them
+func (s *progressService) GetProgress(number int64) (*CalculationProgress, error) {
+	current, err := s.currentCalculatedRepository.GetCurrentNumber()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current number: %w", err)
+	}
+	target, err := s.maxRequestRepository.GetMaxNumber()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get max number: %w", err)
+	}
+
+	now := time.Now()
+	completed, err := s.repository.CountByStatusUpdatedSince(domain.StatusDone, now.Add(-s.window))
+	if err != nil {
+		return nil, fmt.Errorf("failed to count completed factorials: %w", err)
+	}
+
+	progress := &CalculationProgress{
+		Number:     number,
+		Current:    current,
+		Target:     target,
+		Remaining:  max(number-current+1, 0),
+		Throughput: float64(completed) / s.window.Seconds(),
+		Window:     s.window,
+	}
+	if progress.Queued() && progress.Throughput > 0 {
+		eta := now.Add(time.Duration(float64(progress.Remaining) / progress.Throughput * float64(time.Second)))
+		progress.EstimatedCompletionAt = &eta
+	}
+	return progress, nil
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"factorial-cal-services/pkg/domain"
+)
+
+func TestProgressService_GetProgress(t *testing.T) {
+	factRepo := newMockFactorialRepository()
+	now := time.Now()
+	// 60 factorials completed within the window, one long before it
+	for number := int64(0); number < 60; number++ {
+		factRepo.calculations[number] = &domain.FactorialCalculation{Number: number, Status: domain.StatusDone, UpdatedAt: now.Add(-time.Duration(number) * time.Second)}
+	}
+	factRepo.calculations[60] = &domain.FactorialCalculation{Number: 60, Status: domain.StatusDone, UpdatedAt: now.Add(-time.Hour)}
+	factRepo.calculations[61] = &domain.FactorialCalculation{Number: 61, Status: domain.StatusCalculating, UpdatedAt: now}
+
+	curRepo := newMockCurrentCalculatedRepository()
+	curRepo.currentNumber = 61
+	maxRepo := newMockMaxRequestRepository()
+	maxRepo.maxNumber = 100
+
+	svc := NewProgressService(factRepo, curRepo, maxRepo, time.Minute)
+
+	tests := []struct {
+		name      string
+		number    int64
+		remaining int64
+		queued    bool
+		hasETA    bool
+	}{
+		{name: "queued number", number: 90, remaining: 30, queued: true, hasETA: true},
+		{name: "number being calculated", number: 61, remaining: 1, queued: true, hasETA: true},
+		{name: "number behind the calculator", number: 10, remaining: 0, queued: true, hasETA: true},
+		{name: "number not submitted", number: 150, remaining: 90, queued: false, hasETA: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			progress, err := svc.GetProgress(tt.number)
+			if err != nil {
+				t.Fatalf("GetProgress failed: %v", err)
+			}
+			if progress.Current != 61 || progress.Target != 100 {
+				t.Errorf("Expected current 61 and target 100, got %d and %d", progress.Current, progress.Target)
+			}
+			if progress.Remaining != tt.remaining {
+				t.Errorf("Expected remaining %d, got %d", tt.remaining, progress.Remaining)
+			}
+			if progress.Queued() != tt.queued {
+				t.Errorf("Expected queued %v, got %v", tt.queued, progress.Queued())
+			}
+			if progress.Throughput != 1 {
+				t.Errorf("Expected throughput of 1/s, got %v", progress.Throughput)
+			}
+			if (progress.EstimatedCompletionAt != nil) != tt.hasETA {
+				t.Fatalf("Expected ETA presence %v, got %v", tt.hasETA, progress.EstimatedCompletionAt)
+			}
+			if tt.hasETA {
+				eta := time.Until(*progress.EstimatedCompletionAt)
+				expected := time.Duration(tt.remaining) * time.Second
+				if eta < expected-time.Second || eta > expected {
+					t.Errorf("Expected ETA in about %v, got %v", expected, eta)
+				}
+			}
+		})
+	}
+}
+
+func TestProgressService_GetProgress_NoThroughput(t *testing.T) {
+	curRepo := newMockCurrentCalculatedRepository()
+	curRepo.currentNumber = 4
+	maxRepo := newMockMaxRequestRepository()
+	maxRepo.maxNumber = 10
+
+	progress, err := NewProgressService(newMockFactorialRepository(), curRepo, maxRepo, time.Minute).GetProgress(10)
+	if err != nil {
+		t.Fatalf("GetProgress failed: %v", err)
+	}
+	if progress.Throughput != 0 || progress.EstimatedCompletionAt != nil {
+		t.Errorf("Expected no throughput and no ETA, got %v and %v", progress.Throughput, progress.EstimatedCompletionAt)
+	}
+}
+
+func TestProgressService_GetProgress_Error(t *testing.T) {
+	curRepo := newMockCurrentCalculatedRepository()
+	curRepo.getError = errors.New("database error")
+
+	_, err := NewProgressService(newMockFactorialRepository(), curRepo, newMockMaxRequestRepository(), time.Minute).GetProgress(10)
+	if err == nil {
+		t.Error("Expected error when the current number cannot be read")
+	}
+}
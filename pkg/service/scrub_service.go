@@ -0,0 +1,273 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"math/rand/v2"
+	"strconv"
+	"time"
+
+	"factorial-cal-services/pkg/domain"
+	"factorial-cal-services/pkg/repository"
+)
+
+const (
+	DefaultScrubInterval       = time.Hour
+	DefaultScrubBytesPerSecond = 4 << 20
+	DefaultScrubSampleRate     = 0.1
+
+	// scrubBatchSize is the number of factorials read from the database at a time
+	scrubBatchSize = 100
+)
+
+// ScrubService walks the stored factorials and verifies them in the background
+type ScrubService interface {
+	Scrub(ctx context.Context) (*domain.ScrubReport, error)
+	StartScrubbing(ctx context.Context) <-chan struct{}
+}
+
+type scrubService struct {
+	factorialRepository repository.FactorialRepository
+	scrubRepository     repository.ScrubRepository
+	storage             StorageService
+	alerter             IntegrityAlerter
+	interval            time.Duration
+	bytesPerSecond      int64
+	sampleRate          float64
+}
+
+// ScrubServiceOption configures optional ScrubService settings
+type ScrubServiceOption func(*scrubService)
+
+// WithScrubInterval sets the pause between two scrub passes
+func WithScrubInterval(interval time.Duration) ScrubServiceOption {
+	return func(s *scrubService) {
+		if interval > 0 {
+			s.interval = interval
+		}
+	}
+}
+
+// WithScrubBytesPerSecond limits the rate objects are read at, so scrubbing does not starve the calculator
+func WithScrubBytesPerSecond(bytesPerSecond int64) ScrubServiceOption {
+	return func(s *scrubService) {
+		if bytesPerSecond > 0 {
+			s.bytesPerSecond = bytesPerSecond
+		}
+	}
+}
+
+// WithScrubSampleRate sets the share of consecutive factorial pairs checked for mathematical consistency
+func WithScrubSampleRate(rate float64) ScrubServiceOption {
+	return func(s *scrubService) {
+		if rate >= 0 && rate <= 1 {
+			s.sampleRate = rate
+		}
+	}
+}
+
+// WithScrubAlerter sets the alerter told about stored factorials failing verification
+func WithScrubAlerter(alerter IntegrityAlerter) ScrubServiceOption {
+	return func(s *scrubService) {
+		if alerter != nil {
+			s.alerter = alerter
+		}
+	}
+}
+
+// NewScrubService creates a new scrub service
+func NewScrubService(
+	factorialRepository repository.FactorialRepository,
+	scrubRepository repository.ScrubRepository,
+	storage StorageService,
+	opts ...ScrubServiceOption,
+) ScrubService {
+	s := &scrubService{
+		factorialRepository: factorialRepository,
+		scrubRepository:     scrubRepository,
+		storage:             storage,
+		alerter:             NewLogIntegrityAlerter(),
+		interval:            DefaultScrubInterval,
+		bytesPerSecond:      DefaultScrubBytesPerSecond,
+		sampleRate:          DefaultScrubSampleRate,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// StartScrubbing runs a scrub pass, then another one every interval, until ctx is done.
+// The returned channel is closed once scrubbing stopped.
+func (s *scrubService) StartScrubbing(ctx context.Context) <-chan struct{} {
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			if _, err := s.Scrub(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("failed to scrub stored factorials: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				log.Println("scrubbing stopped")
+				return
+			case <-time.After(s.interval):
+			}
+		}
+	}()
+	return stopped
+}
+
+// Scrub verifies every materialized factorial once, lowest first, and records the pass in a
+// scrub report. Objects failing their checksum or size are marked failed, so the calculator
+// recalculates them; objects that cannot be read and inconsistent pairs are only reported,
+// as a storage outage or a wrong ancestor cannot be told apart from them.
+func (s *scrubService) Scrub(ctx context.Context) (*domain.ScrubReport, error) {
+	report := &domain.ScrubReport{
+		Status:    domain.ScrubStatusRunning,
+		StartedAt: time.Now(),
+	}
+	if err := s.scrubRepository.CreateReport(report); err != nil {
+		return nil, fmt.Errorf("failed to create scrub report: %w", err)
+	}
+
+	err := s.scrubAll(ctx, report)
+	finishedAt := time.Now()
+	report.FinishedAt = &finishedAt
+	report.Status = domain.ScrubStatusCompleted
+	if err != nil {
+		report.Status = domain.ScrubStatusAborted
+		report.Error = err.Error()
+	}
+	if updateErr := s.scrubRepository.UpdateReport(report); updateErr != nil && err == nil {
+		err = fmt.Errorf("failed to update scrub report: %w", updateErr)
+	}
+	log.Printf("scrub %d %s: %d factorials checked, %d pairs checked, %d findings",
+		report.ID, report.Status, report.Checked, report.PairsChecked, report.Findings)
+	return report, err
+}
+
+// scrubbedFactorial is the last verified factorial of a pass, kept for the consistency check
+type scrubbedFactorial struct {
+	number int64
+	result string
+}
+
+func (s *scrubService) scrubAll(ctx context.Context, report *domain.ScrubReport) error {
+	var previous *scrubbedFactorial
+	for {
+		calcs, err := s.factorialRepository.FindMaterializedAfter(report.LastNumber, scrubBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to find stored factorials: %w", err)
+		}
+		if len(calcs) == 0 {
+			return nil
+		}
+
+		for i := range calcs {
+			calc := &calcs[i]
+			result, verified, err := s.scrubFactorial(ctx, report, calc)
+			if err != nil {
+				return err
+			}
+			if verified && previous != nil && rand.Float64() < s.sampleRate {
+				if err := s.checkConsistency(report, previous, calc.Number, result); err != nil {
+					return err
+				}
+			}
+			previous = nil
+			if verified {
+				previous = &scrubbedFactorial{number: calc.Number, result: result}
+			}
+			report.Checked++
+			report.BytesRead += int64(len(result))
+			report.LastNumber = calc.Number
+
+			if err := s.throttle(ctx, len(result)); err != nil {
+				return err
+			}
+		}
+
+		if err := s.scrubRepository.UpdateReport(report); err != nil {
+			return fmt.Errorf("failed to update scrub report: %w", err)
+		}
+	}
+}
+
+// scrubFactorial downloads a factorial and verifies its size and checksum. It returns the
+// result and whether it was verified; an error means the pass cannot go on.
+func (s *scrubService) scrubFactorial(ctx context.Context, report *domain.ScrubReport, calc *domain.FactorialCalculation) (string, bool, error) {
+	result, err := s.storage.DownloadFactorial(ctx, calc.S3Key)
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", false, ctx.Err()
+		}
+		log.Printf("ALERT: stored factorial %d (%s) cannot be read: %v", calc.Number, calc.S3Key, err)
+		return "", false, s.addFinding(report, calc.Number, domain.ScrubFindingMissing, err.Error())
+	}
+
+	kind, detail := "", ""
+	switch {
+	case calc.Size > 0 && int64(len(result)) != calc.Size:
+		kind, detail = domain.ScrubFindingSizeMismatch, fmt.Sprintf("expected %d digits, got %d", calc.Size, len(result))
+	case calc.Checksum != "" && checksum(result) != calc.Checksum:
+		kind, detail = domain.ScrubFindingChecksumMismatch, fmt.Sprintf("expected checksum %s", calc.Checksum)
+	default:
+		return result, true, nil
+	}
+
+	s.alerter.ChecksumMismatch(calc.Number, calc.S3Key)
+	if err := s.factorialRepository.UpdateStatus(strconv.FormatInt(calc.Number, 10), domain.StatusFailed); err != nil {
+		return "", false, fmt.Errorf("failed to mark factorial %d failed: %w", calc.Number, err)
+	}
+	return result, false, s.addFinding(report, calc.Number, kind, detail)
+}
+
+// checkConsistency checks that n! / m! is the product of m+1..n for two verified factorials
+func (s *scrubService) checkConsistency(report *domain.ScrubReport, previous *scrubbedFactorial, number int64, result string) error {
+	report.PairsChecked++
+	dividend, ok := new(big.Int).SetString(result, 10)
+	divisor, ok2 := new(big.Int).SetString(previous.result, 10)
+	if !ok || !ok2 || divisor.Sign() == 0 {
+		return s.addFinding(report, number, domain.ScrubFindingInconsistent,
+			fmt.Sprintf("%d! or %d! is not a decimal number", number, previous.number))
+	}
+
+	quotient, remainder := new(big.Int).QuoRem(dividend, divisor, new(big.Int))
+	if remainder.Sign() == 0 && quotient.Cmp(productRange(previous.number+1, number)) == 0 {
+		return nil
+	}
+	log.Printf("ALERT: stored factorials %d and %d are not consistent", previous.number, number)
+	return s.addFinding(report, number, domain.ScrubFindingInconsistent,
+		fmt.Sprintf("%d! / %d! is not the product of %d..%d", number, previous.number, previous.number+1, number))
+}
+
+func (s *scrubService) addFinding(report *domain.ScrubReport, number int64, kind, detail string) error {
+	report.Findings++
+	finding := &domain.ScrubFinding{
+		ReportID: report.ID,
+		Number:   number,
+		Kind:     kind,
+		Detail:   detail,
+	}
+	if err := s.scrubRepository.AddFinding(finding); err != nil {
+		return fmt.Errorf("failed to record scrub finding for %d: %w", number, err)
+	}
+	return nil
+}
+
+// throttle pauses for as long as reading size bytes takes at the configured rate
+func (s *scrubService) throttle(ctx context.Context, size int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	pause := time.Duration(float64(size) / float64(s.bytesPerSecond) * float64(time.Second))
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(pause):
+		return nil
+	}
+}
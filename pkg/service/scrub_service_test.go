@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"factorial-cal-services/pkg/domain"
+	"factorial-cal-services/pkg/repository"
+)
+
+func TestScrubService_Scrub(t *testing.T) {
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&domain.ScrubReport{}, &domain.ScrubFinding{}); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+	factorialRepo := repository.NewFactorialRepository(db)
+	storage := newUnitTestMockStorageService()
+
+	stored := map[int64]string{
+		3: "6",
+		5: "121", // Matches its checksum but is not 5 * 4 * 3!
+		7: calculateFactorial(7).String(),
+		9: calculateFactorial(9).String(),
+	}
+	for number, result := range stored {
+		calc := &domain.FactorialCalculation{
+			Number:   number,
+			Status:   domain.StatusDone,
+			S3Key:    storage.GenerateKey(number),
+			Checksum: checksum(result),
+			Size:     int64(len(result)),
+		}
+		if err := factorialRepo.Create(calc); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		storage.storage[calc.S3Key] = result
+	}
+	storage.storage[storage.GenerateKey(7)] = "5041"
+	delete(storage.storage, storage.GenerateKey(9))
+
+	alerter := &recordingIntegrityAlerter{}
+	scrubber := NewScrubService(factorialRepo, repository.NewScrubRepository(db), storage,
+		WithScrubSampleRate(1), WithScrubBytesPerSecond(1<<40), WithScrubAlerter(alerter))
+
+	report, err := scrubber.Scrub(context.Background())
+	if err != nil {
+		t.Fatalf("Scrub failed: %v", err)
+	}
+	if report.Status != domain.ScrubStatusCompleted || report.Checked != 4 || report.PairsChecked != 1 || report.Findings != 3 {
+		t.Errorf("Unexpected report %+v", report)
+	}
+
+	var findings []domain.ScrubFinding
+	db.Order("number").Find(&findings)
+	wantKinds := map[int64]string{
+		5: domain.ScrubFindingInconsistent,
+		7: domain.ScrubFindingChecksumMismatch,
+		9: domain.ScrubFindingMissing,
+	}
+	if len(findings) != len(wantKinds) {
+		t.Fatalf("Expected %d findings, got %+v", len(wantKinds), findings)
+	}
+	for _, finding := range findings {
+		if finding.ReportID != report.ID || finding.Kind != wantKinds[finding.Number] {
+			t.Errorf("Unexpected finding %+v", finding)
+		}
+	}
+
+	if len(alerter.numbers) != 1 || alerter.numbers[0] != 7 {
+		t.Errorf("Expected an alert for 7, got %v", alerter.numbers)
+	}
+	for number, want := range map[int64]string{5: domain.StatusDone, 7: domain.StatusFailed, 9: domain.StatusDone} {
+		calc, _ := factorialRepo.FindByNumber(number)
+		if calc.Status != want {
+			t.Errorf("Expected factorial %d to be %s, got %s", number, want, calc.Status)
+		}
+	}
+}
+
+func TestScrubService_Scrub_Cancelled(t *testing.T) {
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&domain.ScrubReport{}, &domain.ScrubFinding{}); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+	factorialRepo := repository.NewFactorialRepository(db)
+	storage := newUnitTestMockStorageService()
+	object, _ := storage.UploadFactorial(context.Background(), 3, "6")
+	factorialRepo.Create(&domain.FactorialCalculation{Number: 3, Status: domain.StatusDone, S3Key: object.Key})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	report, err := NewScrubService(factorialRepo, repository.NewScrubRepository(db), storage).Scrub(ctx)
+	if err == nil {
+		t.Fatal("Expected a cancelled scrub to fail")
+	}
+
+	var saved domain.ScrubReport
+	db.First(&saved, report.ID)
+	if saved.Status != domain.ScrubStatusAborted || saved.FinishedAt == nil || saved.Error == "" {
+		t.Errorf("Expected an aborted report, got %+v", saved)
+	}
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"factorial-cal-services/pkg/domain"
+)
+
+// encodedStorageService implements StorageService on an ObjectStorage backend. New results
+// are written with one encoding; existing objects are decoded with the encoding of their key.
+type encodedStorageService struct {
+	backend  ObjectStorage
+	encoding StorageEncoding
+}
+
+// NewEncodedStorageService creates a storage service writing results to backend with encoding
+func NewEncodedStorageService(backend ObjectStorage, encoding StorageEncoding) StorageService {
+	return &encodedStorageService{
+		backend:  backend,
+		encoding: encoding,
+	}
+}
+
+// GenerateKey returns the key a factorial is stored under with the current encoding
+func (s *encodedStorageService) GenerateKey(number int64) string {
+	return s.encoding.Key(s.backend.GenerateKey(number))
+}
+
+// UploadFactorial encodes and stores a factorial result. The encoded object is uploaded while
+// it is encoded, so it is never held in memory next to the result as a whole.
+func (s *encodedStorageService) UploadFactorial(ctx context.Context, number int64, result string) (*domain.StoredObject, error) {
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(s.encoding.EncodeTo(writer, result))
+	}()
+	body := &countingReader{reader: reader}
+
+	key := s.GenerateKey(number)
+	err := s.backend.PutObject(ctx, key, body, s.encoding.ContentType())
+	// Unblocks the encoder when the backend stopped reading early
+	reader.CloseWithError(err)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.StoredObject{
+		Key:      key,
+		Bucket:   s.backend.GetBucket(),
+		Encoding: s.encoding.Name(),
+		Size:     body.count,
+	}, nil
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.count += int64(n)
+	return n, err
+}
+
+// DownloadFactorial returns the decimal text of a stored factorial, whatever its encoding
+func (s *encodedStorageService) DownloadFactorial(ctx context.Context, s3Key string) (string, error) {
+	data, err := s.backend.GetObject(ctx, s3Key)
+	if err != nil {
+		return "", err
+	}
+	result, err := storageEncodingOfKey(s3Key).Decode(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode %s: %w", s3Key, err)
+	}
+	return result, nil
+}
+
+// OpenReader streams the decimal text of a stored factorial. Text objects are streamed from
+// the backend; other encodings are decoded in memory first.
+func (s *encodedStorageService) OpenReader(ctx context.Context, key string) (io.ReadSeekCloser, error) {
+	if storageEncodingOfKey(key).Name() == domain.EncodingText {
+		return s.backend.OpenObject(ctx, key)
+	}
+	result, err := s.DownloadFactorial(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return nopReadSeekCloser{strings.NewReader(result)}, nil
+}
+
+// GetBucket returns the bucket of the backend
+func (s *encodedStorageService) GetBucket() string {
+	return s.backend.GetBucket()
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"sync"
+
+	"factorial-cal-services/pkg/domain"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// StorageEncoding encodes factorial results into stored objects. Every encoding stores its
+// objects under a key suffix of its own, so the encoding of an object is known from its key.
+type StorageEncoding interface {
+	Name() string
+	// Key returns the object key of a factorial given the key of its decimal text object
+	Key(textKey string) string
+	ContentType() string
+	// EncodeTo writes the encoded result to w, so it can be uploaded while it is encoded
+	EncodeTo(w io.Writer, result string) error
+	Decode(data []byte) (string, error)
+}
+
+// NewStorageEncoding returns the storage encoding registered under the given name
+func NewStorageEncoding(name string) (StorageEncoding, error) {
+	switch name {
+	case domain.EncodingText, "":
+		return textEncoding{}, nil
+	case domain.EncodingGzip:
+		return gzipEncoding{}, nil
+	case domain.EncodingZstd:
+		return zstdEncoding{}, nil
+	case domain.EncodingBinary:
+		return binaryEncoding{}, nil
+	default:
+		return nil, fmt.Errorf("unknown storage encoding: %s", name)
+	}
+}
+
+// storageEncodingOfKey returns the encoding an object was stored with, from its key suffix
+func storageEncodingOfKey(key string) StorageEncoding {
+	switch {
+	case strings.HasSuffix(key, gzipKeySuffix):
+		return gzipEncoding{}
+	case strings.HasSuffix(key, zstdKeySuffix):
+		return zstdEncoding{}
+	case strings.HasSuffix(key, binaryKeySuffix):
+		return binaryEncoding{}
+	default:
+		return textEncoding{}
+	}
+}
+
+const (
+	textKeySuffix   = ".txt"
+	gzipKeySuffix   = ".gz"
+	zstdKeySuffix   = ".zst"
+	binaryKeySuffix = ".bin"
+)
+
+// textEncoding stores the decimal text as is
+type textEncoding struct{}
+
+func (textEncoding) Name() string              { return domain.EncodingText }
+func (textEncoding) Key(textKey string) string { return textKey }
+func (textEncoding) ContentType() string       { return "text/plain" }
+
+func (textEncoding) EncodeTo(w io.Writer, result string) error {
+	_, err := io.WriteString(w, result)
+	return err
+}
+
+func (textEncoding) Decode(data []byte) (string, error) {
+	return string(data), nil
+}
+
+// gzipEncoding stores the gzip-compressed decimal text
+type gzipEncoding struct{}
+
+func (gzipEncoding) Name() string              { return domain.EncodingGzip }
+func (gzipEncoding) Key(textKey string) string { return textKey + gzipKeySuffix }
+func (gzipEncoding) ContentType() string       { return "application/gzip" }
+
+func (gzipEncoding) EncodeTo(w io.Writer, result string) error {
+	writer := gzip.NewWriter(w)
+	if _, err := io.WriteString(writer, result); err != nil {
+		return fmt.Errorf("failed to compress factorial: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to compress factorial: %w", err)
+	}
+	return nil
+}
+
+func (gzipEncoding) Decode(data []byte) (string, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress factorial: %w", err)
+	}
+	defer reader.Close()
+	result, err := io.ReadAll(reader)
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress factorial: %w", err)
+	}
+	return string(result), nil
+}
+
+var (
+	zstdDecoder     *zstd.Decoder
+	zstdDecoderOnce sync.Once
+	errZstdNotReady = errors.New("zstd codec is not available")
+)
+
+// sharedZstdDecoder returns the shared zstd decoder, which is safe for concurrent use
+func sharedZstdDecoder() *zstd.Decoder {
+	zstdDecoderOnce.Do(func() {
+		zstdDecoder, _ = zstd.NewReader(nil)
+	})
+	return zstdDecoder
+}
+
+// zstdEncoding stores the zstd-compressed decimal text
+type zstdEncoding struct{}
+
+func (zstdEncoding) Name() string              { return domain.EncodingZstd }
+func (zstdEncoding) Key(textKey string) string { return textKey + zstdKeySuffix }
+func (zstdEncoding) ContentType() string       { return "application/zstd" }
+
+// EncodeTo uses an encoder of its own per call, since an encoder streams to one writer at a time
+func (zstdEncoding) EncodeTo(w io.Writer, result string) error {
+	writer, err := zstd.NewWriter(w)
+	if err != nil {
+		return fmt.Errorf("failed to compress factorial: %w", err)
+	}
+	if _, err := io.WriteString(writer, result); err != nil {
+		writer.Close()
+		return fmt.Errorf("failed to compress factorial: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to compress factorial: %w", err)
+	}
+	return nil
+}
+
+func (zstdEncoding) Decode(data []byte) (string, error) {
+	decoder := sharedZstdDecoder()
+	if decoder == nil {
+		return "", errZstdNotReady
+	}
+	result, err := decoder.DecodeAll(data, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress factorial: %w", err)
+	}
+	return string(result), nil
+}
+
+// binaryEncoding stores the big-endian bytes of the factorial (big.Int.Bytes)
+type binaryEncoding struct{}
+
+func (binaryEncoding) Name() string { return domain.EncodingBinary }
+func (binaryEncoding) Key(textKey string) string {
+	return strings.TrimSuffix(textKey, textKeySuffix) + binaryKeySuffix
+}
+func (binaryEncoding) ContentType() string { return "application/octet-stream" }
+
+func (binaryEncoding) EncodeTo(w io.Writer, result string) error {
+	value, ok := new(big.Int).SetString(result, 10)
+	if !ok || value.Sign() < 0 {
+		return errors.New("failed to encode factorial: invalid format")
+	}
+	_, err := w.Write(value.Bytes())
+	return err
+}
+
+func (binaryEncoding) Decode(data []byte) (string, error) {
+	return new(big.Int).SetBytes(data).String(), nil
+}
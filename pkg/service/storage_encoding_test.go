@@ -0,0 +1,122 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math/big"
+	"strings"
+	"testing"
+
+	"factorial-cal-services/pkg/domain"
+)
+
+func TestStorageEncodings_RoundTrip(t *testing.T) {
+	result := productRange(1, 3000).String()
+
+	for _, name := range []string{domain.EncodingText, domain.EncodingGzip, domain.EncodingZstd, domain.EncodingBinary} {
+		t.Run(name, func(t *testing.T) {
+			encoding, err := NewStorageEncoding(name)
+			if err != nil {
+				t.Fatalf("NewStorageEncoding failed: %v", err)
+			}
+			var buf bytes.Buffer
+			if err := encoding.EncodeTo(&buf, result); err != nil {
+				t.Fatalf("EncodeTo failed: %v", err)
+			}
+			data := buf.Bytes()
+			if name != domain.EncodingText && len(data) >= len(result) {
+				t.Errorf("Expected %s to be smaller than the text, got %d bytes for %d digits", name, len(data), len(result))
+			}
+
+			key := encoding.Key("3000.txt")
+			if got := storageEncodingOfKey(key).Name(); got != name {
+				t.Errorf("Expected key %s to be decoded as %s, got %s", key, name, got)
+			}
+			decoded, err := storageEncodingOfKey(key).Decode(data)
+			if err != nil {
+				t.Fatalf("Decode failed: %v", err)
+			}
+			if decoded != result {
+				t.Error("Expected the decoded result to match the original")
+			}
+		})
+	}
+
+	if _, err := NewStorageEncoding("lz4"); err == nil {
+		t.Error("Expected an error for an unknown encoding")
+	}
+}
+
+func TestEncodedStorageService(t *testing.T) {
+	backend := NewMemoryStorageService()
+	ctx := context.Background()
+
+	// An object written as text before compression was enabled
+	if err := backend.PutObject(ctx, backend.GenerateKey(5), strings.NewReader("120"), "text/plain"); err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+
+	encoding, _ := NewStorageEncoding(domain.EncodingZstd)
+	storage := NewEncodedStorageService(backend, encoding)
+
+	result := productRange(1, 500).String()
+	object, err := storage.UploadFactorial(ctx, 500, result)
+	if err != nil {
+		t.Fatalf("UploadFactorial failed: %v", err)
+	}
+	if object.Key != "factorials/500.txt.zst" || object.Encoding != domain.EncodingZstd || object.Bucket != "memory" {
+		t.Errorf("Unexpected stored object: %+v", object)
+	}
+	stored, _ := backend.GetObject(ctx, object.Key)
+	if object.Size != int64(len(stored)) || object.Size >= int64(len(result)) {
+		t.Errorf("Expected compressed size %d, got %d", len(stored), object.Size)
+	}
+
+	for key, expected := range map[string]string{object.Key: result, backend.GenerateKey(5): "120"} {
+		downloaded, err := storage.DownloadFactorial(ctx, key)
+		if err != nil || downloaded != expected {
+			t.Errorf("DownloadFactorial(%s): unexpected result (%v)", key, err)
+		}
+
+		reader, err := storage.OpenReader(ctx, key)
+		if err != nil {
+			t.Fatalf("OpenReader(%s) failed: %v", key, err)
+		}
+		if _, err := reader.Seek(1, io.SeekStart); err != nil {
+			t.Fatalf("Seek failed: %v", err)
+		}
+		data, _ := io.ReadAll(reader)
+		reader.Close()
+		if string(data) != expected[1:] {
+			t.Errorf("OpenReader(%s): expected the decoded text from offset 1", key)
+		}
+	}
+}
+
+func TestEncodedStorageService_EncodeFailureStoresNothing(t *testing.T) {
+	backend := NewMemoryStorageService()
+	encoding, _ := NewStorageEncoding(domain.EncodingBinary)
+	storage := NewEncodedStorageService(backend, encoding)
+	ctx := context.Background()
+
+	if _, err := storage.UploadFactorial(ctx, 5, "12a"); err == nil {
+		t.Fatal("Expected UploadFactorial to fail")
+	}
+	if _, err := backend.GetObject(ctx, storage.GenerateKey(5)); !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("Expected no object after a failed encoding, got %v", err)
+	}
+}
+
+func TestBinaryEncoding_RejectsInvalidResult(t *testing.T) {
+	for _, result := range []string{"", "12a", "-5", strings.Repeat("9", 10) + " "} {
+		if err := (binaryEncoding{}).EncodeTo(io.Discard, result); err == nil {
+			t.Errorf("Expected an error encoding %q", result)
+		}
+	}
+	decoded, err := (binaryEncoding{}).Decode(big.NewInt(720).Bytes())
+	if err != nil || decoded != "720" {
+		t.Errorf("Expected 720, got %q (%v)", decoded, err)
+	}
+}
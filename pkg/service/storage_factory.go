@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"factorial-cal-services/pkg/config"
+)
+
+// Storage backends selectable with STORAGE_TYPE
+const (
+	StorageTypeLocal        = "local"
+	StorageTypeS3           = "s3"
+	StorageTypeS3Compatible = "s3-compatible"
+	StorageTypeMemory       = "memory"
+)
+
+// processMemoryStorage is shared by every memory backend of the process, so storage services
+// created separately in one test or by the standalone services read each other's results
+var (
+	processMemoryStorage     ObjectStorage
+	processMemoryStorageOnce sync.Once
+)
+
+// NewStorageService creates the storage backend selected by cfg.STORAGE_TYPE, writing results
+// with cfg.STORAGE_ENCODING. The memory backend is shared by everything in the process, so
+// it serves tests and cmd/standalone; the local backend reaches other processes only through
+// a shared LOCAL_STORAGE_PATH.
+func NewStorageService(ctx context.Context, cfg *config.Config) (StorageService, error) {
+	encoding, err := NewStorageEncoding(cfg.STORAGE_ENCODING)
+	if err != nil {
+		return nil, err
+	}
+	backend, err := newObjectStorage(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return NewEncodedStorageService(backend, encoding), nil
+}
+
+func newObjectStorage(ctx context.Context, cfg *config.Config) (ObjectStorage, error) {
+	switch cfg.STORAGE_TYPE {
+	case StorageTypeLocal, "":
+		return NewLocalStorageService(cfg.LOCAL_STORAGE_PATH), nil
+	case StorageTypeS3:
+		return NewS3Service(ctx, cfg.AWS_REGION, cfg.S3_BUCKET_NAME, "", s3Options(cfg)...)
+	case StorageTypeS3Compatible:
+		if cfg.S3_ENDPOINT == "" {
+			return nil, fmt.Errorf("storage type %s requires S3_ENDPOINT", StorageTypeS3Compatible)
+		}
+		return NewS3Service(ctx, cfg.AWS_REGION, cfg.S3_BUCKET_NAME, cfg.S3_ENDPOINT, s3Options(cfg)...)
+	case StorageTypeMemory:
+		processMemoryStorageOnce.Do(func() {
+			processMemoryStorage = NewMemoryStorageService()
+		})
+		return processMemoryStorage, nil
+	default:
+		return nil, fmt.Errorf("unknown storage type: %s", cfg.STORAGE_TYPE)
+	}
+}
+
+func s3Options(cfg *config.Config) []S3Option {
+	return []S3Option{
+		WithS3PartSize(int64(cfg.S3_PART_SIZE_MB) << 20),
+		WithS3Concurrency(cfg.S3_CONCURRENCY),
+	}
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"factorial-cal-services/pkg/config"
+)
+
+func TestNewStorageService(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.Config
+		wantErr bool
+	}{
+		{name: "local", cfg: config.Config{STORAGE_TYPE: StorageTypeLocal, LOCAL_STORAGE_PATH: t.TempDir()}},
+		{name: "memory", cfg: config.Config{STORAGE_TYPE: StorageTypeMemory}},
+		{name: "s3", cfg: config.Config{STORAGE_TYPE: StorageTypeS3, AWS_REGION: "us-east-1", S3_BUCKET_NAME: "bucket"}},
+		{name: "s3 without bucket", cfg: config.Config{STORAGE_TYPE: StorageTypeS3, AWS_REGION: "us-east-1"}, wantErr: true},
+		{name: "s3-compatible", cfg: config.Config{STORAGE_TYPE: StorageTypeS3Compatible, AWS_REGION: "us-east-1", S3_BUCKET_NAME: "bucket", S3_ENDPOINT: "http://localhost:9000"}},
+		{name: "s3-compatible without endpoint", cfg: config.Config{STORAGE_TYPE: StorageTypeS3Compatible, AWS_REGION: "us-east-1", S3_BUCKET_NAME: "bucket"}, wantErr: true},
+		{name: "unknown", cfg: config.Config{STORAGE_TYPE: "ftp"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage, err := NewStorageService(context.Background(), &tt.cfg)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewStorageService failed: %v", err)
+			}
+			if storage == nil {
+				t.Fatal("Expected a storage service")
+			}
+		})
+	}
+}
+
+func TestNewStorageService_SharesMemoryStorage(t *testing.T) {
+	cfg := &config.Config{STORAGE_TYPE: StorageTypeMemory}
+	writer, _ := NewStorageService(context.Background(), cfg)
+	reader, _ := NewStorageService(context.Background(), cfg)
+
+	object, err := writer.UploadFactorial(context.Background(), 5, "120")
+	if err != nil {
+		t.Fatalf("UploadFactorial failed: %v", err)
+	}
+	if result, err := reader.DownloadFactorial(context.Background(), object.Key); err != nil || result != "120" {
+		t.Errorf("Expected 120, got %q (%v)", result, err)
+	}
+}
+
+func TestMemoryStorageService(t *testing.T) {
+	storage := NewMemoryStorageService()
+	ctx := context.Background()
+
+	if _, err := storage.GetObject(ctx, storage.GenerateKey(7)); err == nil {
+		t.Error("Expected an error for a missing object")
+	}
+
+	key := storage.GenerateKey(7)
+	if err := storage.PutObject(ctx, key, strings.NewReader("5040"), "text/plain"); err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+	reader, err := storage.OpenObject(ctx, key)
+	if err != nil {
+		t.Fatalf("OpenObject failed: %v", err)
+	}
+	defer reader.Close()
+
+	if _, err := reader.Seek(2, io.SeekStart); err != nil {
+		t.Fatalf("Seek failed: %v", err)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil || string(data) != "40" {
+		t.Errorf("Expected 40, got %q (%v)", data, err)
+	}
+}
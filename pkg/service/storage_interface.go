@@ -2,12 +2,33 @@ package service
 
 import (
 	"context"
+	"errors"
+	"io"
+
+	"factorial-cal-services/pkg/domain"
 )
 
-// StorageService handles storage operations (S3, local filesystem, etc.)
+// ErrObjectNotFound is returned by every storage backend for a key that has no object
+var ErrObjectNotFound = errors.New("object not found")
+
+// StorageService handles storage operations (S3, local filesystem, etc.).
+// Results are encoded on upload and decoded transparently on download.
 type StorageService interface {
-	UploadFactorial(ctx context.Context, number int64, result string) (string, error)
+	UploadFactorial(ctx context.Context, number int64, result string) (*domain.StoredObject, error)
 	DownloadFactorial(ctx context.Context, s3Key string) (string, error)
+	// OpenReader streams a stored object; the reader is seekable so callers can serve byte ranges
+	OpenReader(ctx context.Context, key string) (io.ReadSeekCloser, error)
+	GenerateKey(number int64) string
+	GetBucket() string
+}
+
+// ObjectStorage is a storage backend holding opaque objects, beneath StorageService
+type ObjectStorage interface {
+	// PutObject stores everything read from body under key, replacing any object there
+	PutObject(ctx context.Context, key string, body io.Reader, contentType string) error
+	GetObject(ctx context.Context, key string) ([]byte, error)
+	OpenObject(ctx context.Context, key string) (io.ReadSeekCloser, error)
+	// GenerateKey returns the key of the decimal text object of a factorial
 	GenerateKey(number int64) string
 	GetBucket() string
 }
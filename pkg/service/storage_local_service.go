@@ -2,7 +2,10 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 )
@@ -11,13 +14,13 @@ const (
 	LocalStoragePrefix = "factorials/"
 )
 
-// LocalStorageService handles local filesystem storage operations
+// LocalStorageService stores objects on the local filesystem
 type LocalStorageService struct {
 	basePath string
 }
 
-// NewLocalStorageService creates a new local storage service
-func NewLocalStorageService(basePath string) StorageService {
+// NewLocalStorageService creates a new local storage backend
+func NewLocalStorageService(basePath string) ObjectStorage {
 	if basePath == "" {
 		basePath = "/tmp/factorial-storage"
 	}
@@ -33,38 +36,65 @@ func (s *LocalStorageService) GenerateKey(number int64) string {
 	return fmt.Sprintf("%v%v.txt", LocalStoragePrefix, number)
 }
 
-// Upload saves a factorial result to local filesystem
-func (s *LocalStorageService) UploadFactorial(ctx context.Context, number int64, result string) (string, error) {
-	key := s.GenerateKey(number)
+// PutObject saves an object to local filesystem. It is written to a temporary file renamed
+// into place once complete, so a failed write leaves no partial object behind.
+func (s *LocalStorageService) PutObject(ctx context.Context, key string, body io.Reader, contentType string) error {
 	filePath := filepath.Join(s.basePath, key)
 
 	// Ensure directory exists
 	dir := filepath.Dir(filePath)
 	if err := os.MkdirAll(dir, 0o755); err != nil {
-		return "", fmt.Errorf("failed to create directory: %w", err)
+		return fmt.Errorf("failed to create directory: %w", err)
 	}
 
 	// Write file
-	if err := os.WriteFile(filePath, []byte(result), 0o644); err != nil {
-		return "", fmt.Errorf("failed to write file: %w", err)
+	file, err := os.CreateTemp(dir, ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer os.Remove(file.Name())
+	if _, err := io.Copy(file, body); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := os.Chmod(file.Name(), 0o644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := os.Rename(file.Name(), filePath); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
 	}
 
-	return key, nil
+	return nil
 }
 
-// Download reads a factorial result from local filesystem
-func (s *LocalStorageService) DownloadFactorial(ctx context.Context, s3Key string) (string, error) {
-	filePath := filepath.Join(s.basePath, s3Key)
-
-	data, err := os.ReadFile(filePath)
+// GetObject reads an object from local filesystem
+func (s *LocalStorageService) GetObject(ctx context.Context, key string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(s.basePath, key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrObjectNotFound, key)
+	}
 	if err != nil {
-		return "", fmt.Errorf("failed to read file: %w", err)
+		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
+	return data, nil
+}
 
-	return string(data), nil
+// OpenObject opens an object for streaming
+func (s *LocalStorageService) OpenObject(ctx context.Context, key string) (io.ReadSeekCloser, error) {
+	file, err := os.Open(filepath.Join(s.basePath, key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrObjectNotFound, key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	return file, nil
 }
 
-// GetBucket implements StorageService interface (alias for basePath)
+// GetBucket implements ObjectStorage interface (alias for basePath)
 func (s *LocalStorageService) GetBucket() string {
 	return s.basePath
 }
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// MemoryStorageService keeps objects in memory. Objects are lost when the process exits,
+// so it is only meant for development and tests.
+type MemoryStorageService struct {
+	mu      sync.RWMutex
+	objects map[string][]byte
+}
+
+// NewMemoryStorageService creates a new, empty memory storage backend
+func NewMemoryStorageService() ObjectStorage {
+	return &MemoryStorageService{
+		objects: make(map[string][]byte),
+	}
+}
+
+// GenerateKey generates a storage key for a given number (consistent with local storage)
+func (s *MemoryStorageService) GenerateKey(number int64) string {
+	return fmt.Sprintf("%v%v.txt", LocalStoragePrefix, number)
+}
+
+// PutObject stores a copy of an object
+func (s *MemoryStorageService) PutObject(ctx context.Context, key string, body io.Reader, contentType string) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return fmt.Errorf("failed to read object: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[key] = data
+	return nil
+}
+
+// GetObject returns a copy of a stored object
+func (s *MemoryStorageService) GetObject(ctx context.Context, key string) ([]byte, error) {
+	data, err := s.get(key)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.Clone(data), nil
+}
+
+// OpenObject opens a stored object for streaming
+func (s *MemoryStorageService) OpenObject(ctx context.Context, key string) (io.ReadSeekCloser, error) {
+	data, err := s.get(key)
+	if err != nil {
+		return nil, err
+	}
+	return nopReadSeekCloser{bytes.NewReader(data)}, nil
+}
+
+// GetBucket implements ObjectStorage interface
+func (s *MemoryStorageService) GetBucket() string {
+	return "memory"
+}
+
+func (s *MemoryStorageService) get(key string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	data, ok := s.objects[key]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrObjectNotFound, key)
+	}
+	return data, nil
+}
+
+// nopReadSeekCloser adds a no-op Close to an io.ReadSeeker
+type nopReadSeekCloser struct {
+	io.ReadSeeker
+}
+
+func (nopReadSeekCloser) Close() error {
+	return nil
+}
@@ -3,49 +3,100 @@ package service
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
-	"log"
-
-	"factorial-cal-services/pkg/config"
+	"slices"
+	"sync"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awsConfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 )
 
 const (
 	S3KeyPrefix = "factorials/"
+
+	// DefaultS3PartSize is the part size of multipart uploads and ranged downloads
+	DefaultS3PartSize = 8 << 20
+	// DefaultS3Concurrency is the number of parts transferred at a time
+	DefaultS3Concurrency = 4
+
+	// s3MaxParts is the maximum number of parts of a multipart upload
+	s3MaxParts = 10000
 )
 
-// S3Service handles S3 storage operations
-type S3Service interface {
-	UploadFactorial(ctx context.Context, number int64, result string) (string, error)
-	DownloadFactorial(ctx context.Context, s3Key string) (string, error)
-	GenerateS3Key(number int64) string
-	// StorageService interface methods
-	Upload(ctx context.Context, number int64, result string) (string, error)
-	Download(ctx context.Context, key string) (string, error)
+// s3API is the part of the S3 client the S3 backend uses, implemented by *s3.Client
+type s3API interface {
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error)
+	CreateMultipartUpload(ctx context.Context, params *s3.CreateMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error)
+	UploadPart(ctx context.Context, params *s3.UploadPartInput, optFns ...func(*s3.Options)) (*s3.UploadPartOutput, error)
+	CompleteMultipartUpload(ctx context.Context, params *s3.CompleteMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error)
+	AbortMultipartUpload(ctx context.Context, params *s3.AbortMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error)
 }
 
 type s3Service struct {
-	client     *s3.Client
-	bucketName string
+	client      s3API
+	bucketName  string
+	partSize    int64
+	concurrency int
 }
 
-// NewS3Service creates a new S3 service
-func NewS3Service(ctx context.Context, cfg *config.Config) StorageService {
-	if cfg.AWS_REGION == "" || cfg.S3_BUCKET_NAME == "" {
-		log.Fatalf("AWS_REGION and S3_BUCKET_NAME must be set")
+// S3Option configures optional S3 backend settings
+type S3Option func(*s3Service)
+
+// WithS3PartSize sets the part size of multipart uploads and ranged downloads.
+// Objects up to one part are transferred with a single request.
+func WithS3PartSize(partSize int64) S3Option {
+	return func(s *s3Service) {
+		if partSize > 0 {
+			s.partSize = partSize
+		}
 	}
-	awsCfg, err := awsConfig.LoadDefaultConfig(ctx, awsConfig.WithRegion(cfg.AWS_REGION))
+}
+
+// WithS3Concurrency sets the number of parts uploaded or downloaded at a time
+func WithS3Concurrency(concurrency int) S3Option {
+	return func(s *s3Service) {
+		if concurrency > 0 {
+			s.concurrency = concurrency
+		}
+	}
+}
+
+// NewS3Service creates a new S3 storage backend. A non-empty endpoint points the client at an
+// S3-compatible server (MinIO and the like), which is addressed path-style.
+func NewS3Service(ctx context.Context, region, bucketName, endpoint string, opts ...S3Option) (ObjectStorage, error) {
+	if region == "" || bucketName == "" {
+		return nil, errors.New("AWS_REGION and S3_BUCKET_NAME must be set")
+	}
+	awsCfg, err := awsConfig.LoadDefaultConfig(ctx, awsConfig.WithRegion(region))
 	if err != nil {
-		log.Fatalf("Failed to load AWS config: %v", err)
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+			o.UsePathStyle = true
+		}
+	})
+	return newS3Service(client, bucketName, opts...), nil
+}
+
+func newS3Service(client s3API, bucketName string, opts ...S3Option) *s3Service {
+	s := &s3Service{
+		client:      client,
+		bucketName:  bucketName,
+		partSize:    DefaultS3PartSize,
+		concurrency: DefaultS3Concurrency,
 	}
-	return &s3Service{
-		client:     s3.NewFromConfig(awsCfg),
-		bucketName: cfg.S3_BUCKET_NAME,
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
 // GenerateKey generates an S3 key for a given number
@@ -53,59 +104,271 @@ func (s *s3Service) GenerateKey(number int64) string {
 	return fmt.Sprintf("%v.txt", number)
 }
 
-// UploadFactorial uploads a factorial result to S3
-func (s *s3Service) UploadFactorial(ctx context.Context, number int64, result string) (string, error) {
-	key := s.GenerateKey(number)
-
-	// Convert result to byte buffer
-	body := bytes.NewReader([]byte(result))
+// PutObject uploads an object to S3, with a multipart upload when it is larger than a part
+func (s *s3Service) PutObject(ctx context.Context, key string, body io.Reader, contentType string) error {
+	// One byte past a part tells whether the object fits in a single request
+	head, err := io.ReadAll(io.LimitReader(body, s.partSize+1))
+	if err != nil {
+		return fmt.Errorf("failed to read object: %w", err)
+	}
+	if int64(len(head)) > s.partSize {
+		return s.putMultipart(ctx, key, io.MultiReader(bytes.NewReader(head), body), contentType)
+	}
+	_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucketName),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(head),
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload to S3: %w", err)
+	}
+	return nil
+}
 
-	// Upload to S3
-	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+// putMultipart uploads body to S3 as a multipart upload, at most concurrency parts at a time.
+// Parts are read from body as the upload goes, so it holds at most concurrency parts in memory.
+// A failed upload is aborted, so no incomplete parts are left behind in the bucket.
+func (s *s3Service) putMultipart(ctx context.Context, key string, body io.Reader, contentType string) error {
+	created, err := s.client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
 		Bucket:      aws.String(s.bucketName),
 		Key:         aws.String(key),
-		Body:        body,
-		ContentType: aws.String("text/plain"),
+		ContentType: aws.String(contentType),
 	})
 	if err != nil {
-		return "", fmt.Errorf("failed to upload to S3: %w", err)
+		return fmt.Errorf("failed to start multipart upload to S3: %w", err)
 	}
 
-	return key, nil
+	err = s.uploadParts(ctx, key, created.UploadId, body)
+	if err == nil {
+		return nil
+	}
+	// The upload is aborted even when ctx is done, or its parts are billed until a lifecycle rule removes them
+	_, abortErr := s.client.AbortMultipartUpload(context.WithoutCancel(ctx), &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(s.bucketName),
+		Key:      aws.String(key),
+		UploadId: created.UploadId,
+	})
+	if abortErr != nil {
+		return errors.Join(err, fmt.Errorf("failed to abort multipart upload to S3: %w", abortErr))
+	}
+	return err
 }
 
-// DownloadFactorial downloads a factorial result from S3
-func (s *s3Service) DownloadFactorial(ctx context.Context, s3Key string) (string, error) {
-	// Get object from S3
-	output, err := s.client.GetObject(ctx, &s3.GetObjectInput{
-		Bucket: aws.String(s.bucketName),
-		Key:    aws.String(s3Key),
+func (s *s3Service) uploadParts(ctx context.Context, key string, uploadID *string, body io.Reader) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		parts    []types.CompletedPart
+		firstErr error
+	)
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+
+	// A part buffer is reused once its part is uploaded; buffers are allocated on first use,
+	// so small uploads do not allocate concurrency parts up front
+	buffers := make(chan []byte, s.concurrency)
+	for i := 0; i < s.concurrency; i++ {
+		buffers <- nil
+	}
+
+	for partNumber := int32(1); ctx.Err() == nil; partNumber++ {
+		var buffer []byte
+		select {
+		case buffer = <-buffers:
+		case <-ctx.Done():
+			continue
+		}
+		if buffer == nil {
+			buffer = make([]byte, s.partSize)
+		}
+		n, err := io.ReadFull(body, buffer)
+		if err == io.EOF {
+			break
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			fail(fmt.Errorf("failed to read part %d: %w", partNumber, err))
+			break
+		}
+		if partNumber > s3MaxParts {
+			fail(fmt.Errorf("object exceeds %d parts of %d bytes", s3MaxParts, s.partSize))
+			break
+		}
+
+		wg.Add(1)
+		go func(partNumber int32, buffer []byte, part []byte) {
+			defer wg.Done()
+			defer func() { buffers <- buffer }()
+			output, err := s.client.UploadPart(ctx, &s3.UploadPartInput{
+				Bucket:        aws.String(s.bucketName),
+				Key:           aws.String(key),
+				UploadId:      uploadID,
+				PartNumber:    aws.Int32(partNumber),
+				Body:          bytes.NewReader(part),
+				ContentLength: aws.Int64(int64(len(part))),
+			})
+			if err != nil {
+				fail(fmt.Errorf("failed to upload part %d to S3: %w", partNumber, err))
+				return
+			}
+			mu.Lock()
+			parts = append(parts, types.CompletedPart{ETag: output.ETag, PartNumber: aws.Int32(partNumber)})
+			mu.Unlock()
+		}(partNumber, buffer, buffer[:n])
+
+		// A short part is the last one
+		if err == io.ErrUnexpectedEOF {
+			break
+		}
+	}
+	wg.Wait()
+	if firstErr == nil && ctx.Err() != nil {
+		firstErr = ctx.Err()
+	}
+	if firstErr != nil {
+		return firstErr
+	}
+
+	slices.SortFunc(parts, func(a, b types.CompletedPart) int {
+		return int(aws.ToInt32(a.PartNumber) - aws.ToInt32(b.PartNumber))
+	})
+	_, err := s.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(s.bucketName),
+		Key:             aws.String(key),
+		UploadId:        uploadID,
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
 	})
 	if err != nil {
-		return "", fmt.Errorf("failed to download from S3: %w", err)
+		return fmt.Errorf("failed to complete multipart upload to S3: %w", err)
 	}
-	defer output.Body.Close()
+	return nil
+}
 
-	// Read body
-	body, err := io.ReadAll(output.Body)
+// GetObject downloads an object from S3. Objects larger than a part are downloaded as
+// ranged requests in parallel, each pinned to the ETag of the object so a concurrent
+// overwrite fails the download instead of mixing two versions.
+func (s *s3Service) GetObject(ctx context.Context, key string) ([]byte, error) {
+	head, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(key),
+	})
 	if err != nil {
-		return "", fmt.Errorf("failed to read S3 object body: %w", err)
+		return nil, s3Error("failed to stat S3 object", key, err)
 	}
+	size := aws.ToInt64(head.ContentLength)
+	if size <= s.partSize {
+		output, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+			Bucket: aws.String(s.bucketName),
+			Key:    aws.String(key),
+		})
+		if err != nil {
+			return nil, s3Error("failed to download from S3", key, err)
+		}
+		defer output.Body.Close()
 
-	return string(body), nil
+		body, err := io.ReadAll(output.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read S3 object body: %w", err)
+		}
+		return body, nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	data := make([]byte, size)
+	slots := make(chan struct{}, s.concurrency)
+	for offset := int64(0); offset < size && ctx.Err() == nil; offset += s.partSize {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			continue
+		}
+		wg.Add(1)
+		go func(offset int64, part []byte) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			if err := s.getRange(ctx, key, head.ETag, offset, part); err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}(offset, data[offset:min(offset+s.partSize, size)])
+	}
+	wg.Wait()
+	if firstErr == nil && ctx.Err() != nil {
+		firstErr = ctx.Err()
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return data, nil
 }
 
-// Upload implements StorageService interface (alias for UploadFactorial)
-func (s *s3Service) Upload(ctx context.Context, number int64, result string) (string, error) {
-	return s.UploadFactorial(ctx, number, result)
+// getRange downloads the bytes of an object from offset into part
+func (s *s3Service) getRange(ctx context.Context, key string, etag *string, offset int64, part []byte) error {
+	output, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket:  aws.String(s.bucketName),
+		Key:     aws.String(key),
+		Range:   aws.String(fmt.Sprintf("bytes=%d-%d", offset, offset+int64(len(part))-1)),
+		IfMatch: etag,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to download range at %d from S3: %w", offset, err)
+	}
+	defer output.Body.Close()
+
+	if _, err := io.ReadFull(output.Body, part); err != nil {
+		return fmt.Errorf("failed to read range at %d of S3 object body: %w", offset, err)
+	}
+	return nil
 }
 
-// Download implements StorageService interface (alias for DownloadFactorial)
-func (s *s3Service) Download(ctx context.Context, key string) (string, error) {
-	return s.DownloadFactorial(ctx, key)
+// OpenObject streams an object from S3, fetching byte ranges lazily as the reader is read
+func (s *s3Service) OpenObject(ctx context.Context, key string) (io.ReadSeekCloser, error) {
+	head, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, s3Error("failed to stat S3 object", key, err)
+	}
+	return &s3ObjectReader{
+		ctx:    ctx,
+		client: s.client,
+		bucket: s.bucketName,
+		key:    key,
+		etag:   head.ETag,
+		size:   aws.ToInt64(head.ContentLength),
+	}, nil
 }
 
-// GetBucket implements StorageService interface (alias for bucketName)
+// GetBucket implements ObjectStorage interface (alias for bucketName)
 func (s *s3Service) GetBucket() string {
 	return s.bucketName
 }
+
+// s3Error wraps an S3 error, reporting a missing object as ErrObjectNotFound
+func s3Error(message, key string, err error) error {
+	var noSuchKey *types.NoSuchKey
+	var notFound *types.NotFound
+	if errors.As(err, &noSuchKey) || errors.As(err, &notFound) {
+		return fmt.Errorf("%s: %w: %s", message, ErrObjectNotFound, key)
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
@@ -0,0 +1,315 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// fakeS3Object is an object stored by fakeS3
+type fakeS3Object struct {
+	data []byte
+	etag string
+}
+
+// fakeS3 is an in-process S3 keeping objects and multipart uploads in memory
+type fakeS3 struct {
+	mu        sync.Mutex
+	objects   map[string]fakeS3Object
+	uploads   map[string]map[int32][]byte
+	nextID    int
+	failPart  int32
+	partGate  chan struct{} // holds every UploadPart until closed, when set
+	puts      int
+	completed int
+	aborted   int
+	ranges    int
+}
+
+func newFakeS3() *fakeS3 {
+	return &fakeS3{
+		objects: make(map[string]fakeS3Object),
+		uploads: make(map[string]map[int32][]byte),
+	}
+}
+
+func fakeETag(data []byte) string {
+	sum := md5.Sum(data)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+func (f *fakeS3) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	data, err := io.ReadAll(params.Body)
+	if err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.puts++
+	f.objects[aws.ToString(params.Key)] = fakeS3Object{data: data, etag: fakeETag(data)}
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (f *fakeS3) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	object, ok := f.objects[aws.ToString(params.Key)]
+	if !ok {
+		return nil, &types.NoSuchKey{}
+	}
+	if params.IfMatch != nil && aws.ToString(params.IfMatch) != object.etag {
+		return nil, errors.New("precondition failed")
+	}
+	data := object.data
+	if params.Range != nil {
+		f.ranges++
+		var start, end int
+		if _, err := fmt.Sscanf(aws.ToString(params.Range), "bytes=%d-%d", &start, &end); err != nil {
+			if _, err := fmt.Sscanf(aws.ToString(params.Range), "bytes=%d-", &start); err != nil {
+				return nil, err
+			}
+			end = len(data) - 1
+		}
+		data = data[start:min(end+1, len(data))]
+	}
+	return &s3.GetObjectOutput{
+		Body:          io.NopCloser(bytes.NewReader(data)),
+		ContentLength: aws.Int64(int64(len(data))),
+		ETag:          aws.String(object.etag),
+	}, nil
+}
+
+func (f *fakeS3) HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	object, ok := f.objects[aws.ToString(params.Key)]
+	if !ok {
+		return nil, &types.NotFound{}
+	}
+	return &s3.HeadObjectOutput{
+		ContentLength: aws.Int64(int64(len(object.data))),
+		ETag:          aws.String(object.etag),
+	}, nil
+}
+
+func (f *fakeS3) CreateMultipartUpload(ctx context.Context, params *s3.CreateMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextID++
+	uploadID := fmt.Sprintf("upload-%d", f.nextID)
+	f.uploads[uploadID] = make(map[int32][]byte)
+	return &s3.CreateMultipartUploadOutput{UploadId: aws.String(uploadID)}, nil
+}
+
+func (f *fakeS3) UploadPart(ctx context.Context, params *s3.UploadPartInput, optFns ...func(*s3.Options)) (*s3.UploadPartOutput, error) {
+	if f.partGate != nil {
+		<-f.partGate
+	}
+	data, err := io.ReadAll(params.Body)
+	if err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	partNumber := aws.ToInt32(params.PartNumber)
+	if partNumber == f.failPart {
+		return nil, fmt.Errorf("part %d rejected", partNumber)
+	}
+	parts, ok := f.uploads[aws.ToString(params.UploadId)]
+	if !ok {
+		return nil, &types.NoSuchUpload{}
+	}
+	parts[partNumber] = data
+	return &s3.UploadPartOutput{ETag: aws.String(fakeETag(data))}, nil
+}
+
+func (f *fakeS3) CompleteMultipartUpload(ctx context.Context, params *s3.CompleteMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	uploadID := aws.ToString(params.UploadId)
+	parts, ok := f.uploads[uploadID]
+	if !ok {
+		return nil, &types.NoSuchUpload{}
+	}
+	var data []byte
+	for i, part := range params.MultipartUpload.Parts {
+		partNumber := aws.ToInt32(part.PartNumber)
+		if partNumber != int32(i+1) || aws.ToString(part.ETag) != fakeETag(parts[partNumber]) {
+			return nil, fmt.Errorf("invalid part %d", partNumber)
+		}
+		data = append(data, parts[partNumber]...)
+	}
+	delete(f.uploads, uploadID)
+	f.completed++
+	f.objects[aws.ToString(params.Key)] = fakeS3Object{data: data, etag: fakeETag(data)}
+	return &s3.CompleteMultipartUploadOutput{}, nil
+}
+
+func (f *fakeS3) AbortMultipartUpload(ctx context.Context, params *s3.AbortMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.uploads, aws.ToString(params.UploadId))
+	f.aborted++
+	return &s3.AbortMultipartUploadOutput{}, nil
+}
+
+// atomicCountingReader counts the bytes read through it while the test reads the count
+type atomicCountingReader struct {
+	reader io.Reader
+	count  atomic.Int64
+}
+
+func (r *atomicCountingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.count.Add(int64(n))
+	return n, err
+}
+
+func TestS3Service_SmallObject(t *testing.T) {
+	fake := newFakeS3()
+	storage := newS3Service(fake, "bucket", WithS3PartSize(16))
+	ctx := context.Background()
+
+	if err := storage.PutObject(ctx, "120.txt", strings.NewReader("120"), "text/plain"); err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+	data, err := storage.GetObject(ctx, "120.txt")
+	if err != nil || string(data) != "120" {
+		t.Errorf("Expected 120, got %q (%v)", data, err)
+	}
+	if fake.puts != 1 || fake.completed != 0 || fake.ranges != 0 {
+		t.Errorf("Expected a single put and get, got %d puts, %d multipart uploads, %d ranges", fake.puts, fake.completed, fake.ranges)
+	}
+}
+
+func TestS3Service_MultipartObject(t *testing.T) {
+	fake := newFakeS3()
+	storage := newS3Service(fake, "bucket", WithS3PartSize(16), WithS3Concurrency(3))
+	ctx := context.Background()
+	result := calculateFactorial(70).String() // 101 digits, 7 parts
+
+	if err := storage.PutObject(ctx, "70.txt", strings.NewReader(result), "text/plain"); err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+	if fake.puts != 0 || fake.completed != 1 || len(fake.uploads) != 0 {
+		t.Errorf("Expected one completed multipart upload, got %d puts, %d completed, %d open", fake.puts, fake.completed, len(fake.uploads))
+	}
+
+	data, err := storage.GetObject(ctx, "70.txt")
+	if err != nil || string(data) != result {
+		t.Fatalf("Expected %s, got %q (%v)", result, data, err)
+	}
+	if fake.ranges != 7 {
+		t.Errorf("Expected 7 ranged requests, got %d", fake.ranges)
+	}
+
+	reader, err := storage.OpenObject(ctx, "70.txt")
+	if err != nil {
+		t.Fatalf("OpenObject failed: %v", err)
+	}
+	defer reader.Close()
+	if _, err := reader.Seek(-5, io.SeekEnd); err != nil {
+		t.Fatalf("Seek failed: %v", err)
+	}
+	if tail, err := io.ReadAll(reader); err != nil || string(tail) != result[len(result)-5:] {
+		t.Errorf("Expected %s, got %q (%v)", result[len(result)-5:], tail, err)
+	}
+}
+
+func TestS3Service_MultipartUploadReadsAtMostConcurrencyParts(t *testing.T) {
+	fake := newFakeS3()
+	fake.partGate = make(chan struct{})
+	storage := newS3Service(fake, "bucket", WithS3PartSize(16), WithS3Concurrency(2))
+	body := &atomicCountingReader{reader: strings.NewReader(strings.Repeat("5", 160))}
+
+	done := make(chan error, 1)
+	go func() { done <- storage.PutObject(context.Background(), "key", body, "text/plain") }()
+
+	// With both parts held, the upload stops reading once its two part buffers are full
+	time.Sleep(50 * time.Millisecond)
+	if read := body.count.Load(); read > 2*16+1 {
+		t.Errorf("Expected at most two parts read ahead, got %d bytes", read)
+	}
+	close(fake.partGate)
+	if err := <-done; err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+	data, err := storage.GetObject(context.Background(), "key")
+	if err != nil || string(data) != strings.Repeat("5", 160) {
+		t.Errorf("Expected the whole object, got %d bytes (%v)", len(data), err)
+	}
+}
+
+func TestS3Service_MultipartUploadFailureIsAborted(t *testing.T) {
+	fake := newFakeS3()
+	fake.failPart = 3
+	storage := newS3Service(fake, "bucket", WithS3PartSize(16), WithS3Concurrency(2))
+
+	err := storage.PutObject(context.Background(), "70.txt", strings.NewReader(strings.Repeat("7", 100)), "text/plain")
+	if err == nil {
+		t.Fatal("Expected PutObject to fail")
+	}
+	if fake.aborted != 1 || len(fake.uploads) != 0 {
+		t.Errorf("Expected the upload to be aborted, got %d aborted, %d open", fake.aborted, len(fake.uploads))
+	}
+	if _, err := storage.GetObject(context.Background(), "70.txt"); !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("Expected no object after a failed upload, got %v", err)
+	}
+}
+
+func TestS3Service_GetObjectOverwrittenDuringDownload(t *testing.T) {
+	fake := newFakeS3()
+	storage := newS3Service(fake, "bucket", WithS3PartSize(16))
+	if err := storage.PutObject(context.Background(), "key", strings.NewReader(strings.Repeat("1", 40)), "text/plain"); err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+
+	// A ranged request pinned to another ETag must not be served
+	var part [16]byte
+	if err := storage.getRange(context.Background(), "key", aws.String(fakeETag([]byte("other"))), 0, part[:]); err == nil {
+		t.Error("Expected a range of another version to fail")
+	}
+}
+
+func TestS3Service_OpenObjectOverwrittenDuringRead(t *testing.T) {
+	fake := newFakeS3()
+	storage := newS3Service(fake, "bucket", WithS3PartSize(16))
+	ctx := context.Background()
+	if err := storage.PutObject(ctx, "key", strings.NewReader(strings.Repeat("1", 40)), "text/plain"); err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+
+	reader, err := storage.OpenObject(ctx, "key")
+	if err != nil {
+		t.Fatalf("OpenObject failed: %v", err)
+	}
+	defer reader.Close()
+	head := make([]byte, 10)
+	if _, err := io.ReadFull(reader, head); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	// The next range is requested after the overwrite and must not return the new version
+	if err := storage.PutObject(ctx, "key", strings.NewReader(strings.Repeat("2", 40)), "text/plain"); err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+	if _, err := reader.Seek(20, io.SeekStart); err != nil {
+		t.Fatalf("Seek failed: %v", err)
+	}
+	if tail, err := io.ReadAll(reader); err == nil {
+		t.Errorf("Expected reading another version to fail, got %q", tail)
+	}
+}
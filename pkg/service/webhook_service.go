@@ -0,0 +1,289 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"factorial-cal-services/pkg/domain"
+	"factorial-cal-services/pkg/dto"
+	"factorial-cal-services/pkg/repository"
+	"factorial-cal-services/pkg/utils/patterns"
+)
+
+const (
+	WebhookEventFactorialDone = "factorial.done"
+
+	// WebhookIDHeader carries the callback ID so receivers can deduplicate retries
+	WebhookIDHeader = "X-Webhook-ID"
+	// WebhookTimestampHeader carries the Unix time the request was signed at
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	// WebhookSignatureHeader carries "sha256=" + hex HMAC-SHA256 of "<timestamp>.<body>"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+
+	DefaultWebhookMaxAttempts = 5
+	DefaultWebhookBackoff     = 2 * time.Second
+	DefaultWebhookMaxBackoff  = 5 * time.Minute
+	DefaultWebhookTimeout     = 10 * time.Second
+
+	// webhookDispatchBatchSize is the number of due callbacks picked up per tick
+	webhookDispatchBatchSize = 50
+	// webhookConcurrency is the number of callbacks delivered at the same time
+	webhookConcurrency = 8
+	// webhookMaxResponseBody is the amount of response body read before the connection is reused
+	webhookMaxResponseBody = 64 << 10
+)
+
+var errWebhookAddressNotAllowed = errors.New("webhook address is not allowed")
+
+// WebhookService delivers webhook callbacks once their factorial is done
+type WebhookService interface {
+	StartDispatching(ctx context.Context) <-chan struct{}
+}
+
+type webhookService struct {
+	secret             []byte
+	maxAttempts        int
+	backoff            time.Duration
+	maxBackoff         time.Duration
+	client             *http.Client
+	callbackRepository repository.CallbackRepository
+	repository         repository.FactorialRepository
+}
+
+// WebhookServiceOption configures optional behaviour of the webhook service
+type WebhookServiceOption func(*webhookService)
+
+// WithWebhookMaxAttempts sets the number of attempts after which a callback is marked failed
+func WithWebhookMaxAttempts(maxAttempts int) WebhookServiceOption {
+	return func(s *webhookService) {
+		if maxAttempts > 0 {
+			s.maxAttempts = maxAttempts
+		}
+	}
+}
+
+// WithWebhookBackoff sets the delay before the first retry and the cap it doubles up to
+func WithWebhookBackoff(backoff, maxBackoff time.Duration) WebhookServiceOption {
+	return func(s *webhookService) {
+		if backoff > 0 {
+			s.backoff = backoff
+		}
+		if maxBackoff > 0 {
+			s.maxBackoff = maxBackoff
+		}
+		s.maxBackoff = max(s.maxBackoff, s.backoff)
+	}
+}
+
+// WithWebhookHTTPClient sets the client used to deliver callbacks
+func WithWebhookHTTPClient(client *http.Client) WebhookServiceOption {
+	return func(s *webhookService) {
+		if client != nil {
+			s.client = client
+		}
+	}
+}
+
+// NewWebhookService creates a new webhook service signing every request with secret
+func NewWebhookService(
+	callbackRepository repository.CallbackRepository,
+	repository repository.FactorialRepository,
+	secret string,
+	opts ...WebhookServiceOption,
+) WebhookService {
+	s := &webhookService{
+		secret:             []byte(secret),
+		maxAttempts:        DefaultWebhookMaxAttempts,
+		backoff:            DefaultWebhookBackoff,
+		maxBackoff:         DefaultWebhookMaxBackoff,
+		client:             NewWebhookHTTPClient(DefaultWebhookTimeout, false),
+		callbackRepository: callbackRepository,
+		repository:         repository,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// NewWebhookHTTPClient creates a client that does not follow redirects and, unless
+// allowPrivateNetworks is set, refuses to connect to loopback, private and link-local addresses
+func NewWebhookHTTPClient(timeout time.Duration, allowPrivateNetworks bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivateNetworks {
+		// Checked on the resolved address so DNS cannot be used to reach internal hosts
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || !isPublicIP(ip) {
+				return fmt.Errorf("%w: %s", errWebhookAddressNotAllowed, host)
+			}
+			return nil
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func isPublicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsUnspecified() &&
+		!ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() && !ip.IsMulticast()
+}
+
+// SignWebhook returns the signature header value of a webhook body sent at timestamp
+func SignWebhook(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// StartDispatching delivers due callbacks in the background every second until ctx is done.
+// Deliveries in flight are finished; the returned channel is closed once dispatching stopped.
+func (s *webhookService) StartDispatching(ctx context.Context) <-chan struct{} {
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-ctx.Done():
+				log.Println("webhook dispatching stopped")
+				return
+			case <-time.After(1 * time.Second):
+			}
+			if err := s.dispatchDue(context.WithoutCancel(ctx)); err != nil {
+				log.Printf("failed to dispatch webhooks: %v", err)
+			}
+		}
+	}()
+	return stopped
+}
+
+// dispatchDue delivers every pending callback whose factorial is done and whose retry is due
+func (s *webhookService) dispatchDue(ctx context.Context) error {
+	callbacks, err := s.callbackRepository.FindDue(time.Now(), webhookDispatchBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to find due callbacks: %w", err)
+	}
+
+	semaphore := patterns.NewSemaphore(webhookConcurrency)
+	for i := range callbacks {
+		callback := &callbacks[i]
+		semaphore.Submit(func() error {
+			return s.deliver(ctx, callback)
+		})
+	}
+	semaphore.Wait()
+	return nil
+}
+
+// deliver makes one delivery attempt and records its outcome
+func (s *webhookService) deliver(ctx context.Context, callback *domain.FactorialCallback) error {
+	calc, err := s.repository.FindByNumber(callback.Number)
+	if err != nil {
+		return fmt.Errorf("failed to find factorial %d for callback %s: %w", callback.Number, callback.CallbackID, err)
+	}
+
+	body, err := json.Marshal(dto.WebhookPayload{
+		Event:      WebhookEventFactorialDone,
+		CallbackID: callback.CallbackID,
+		Data:       dto.NewMetadataResponseData(calc),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	start := time.Now()
+	statusCode, postErr := s.post(ctx, callback, body)
+	delivery := &domain.FactorialCallbackDelivery{
+		CallbackID: callback.CallbackID,
+		Attempt:    callback.Attempts + 1,
+		StatusCode: statusCode,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+
+	callback.Attempts++
+	switch {
+	case postErr == nil:
+		callback.Status = domain.CallbackStatusDelivered
+		callback.LastError = ""
+	case callback.Attempts >= s.maxAttempts:
+		delivery.Error = postErr.Error()
+		callback.Status = domain.CallbackStatusFailed
+		callback.LastError = postErr.Error()
+	default:
+		delivery.Error = postErr.Error()
+		callback.LastError = postErr.Error()
+		callback.NextAttemptAt = time.Now().Add(s.retryDelay(callback.Attempts))
+	}
+
+	if err := s.callbackRepository.RecordDelivery(callback, delivery); err != nil {
+		return fmt.Errorf("failed to record delivery of callback %s: %w", callback.CallbackID, err)
+	}
+	if postErr != nil {
+		log.Printf("webhook %s attempt %d failed: %v", callback.CallbackID, delivery.Attempt, postErr)
+	}
+	return nil
+}
+
+// post sends the signed body and returns the response status code
+func (s *webhookService) post(ctx context.Context, callback *domain.FactorialCallback, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, callback.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookIDHeader, callback.CallbackID)
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, SignWebhook(s.secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, webhookMaxResponseBody))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// retryDelay returns the exponential backoff after the given number of failed attempts
+func (s *webhookService) retryDelay(attempts int) time.Duration {
+	delay := s.backoff
+	for i := 1; i < attempts && delay < s.maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, s.maxBackoff)
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"factorial-cal-services/pkg/domain"
+	"factorial-cal-services/pkg/dto"
+	"factorial-cal-services/pkg/repository"
+
+	"gorm.io/gorm"
+)
+
+// setupWebhookTestDB creates a database with one done and one calculating factorial
+func setupWebhookTestDB(t *testing.T) *gorm.DB {
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&domain.FactorialCallback{}, &domain.FactorialCallbackDelivery{}); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+	calcs := []domain.FactorialCalculation{
+		{Number: 5, Status: domain.StatusDone, S3Key: "5.txt", Checksum: checksum("120"), Size: 3},
+		{Number: 6, Status: domain.StatusCalculating},
+	}
+	if err := db.Create(&calcs).Error; err != nil {
+		t.Fatalf("Failed to create calculations: %v", err)
+	}
+	return db
+}
+
+func createTestCallback(t *testing.T, repo repository.CallbackRepository, callbackID string, number int64, url string) {
+	err := repo.Create(&domain.FactorialCallback{
+		CallbackID:    callbackID,
+		Number:        number,
+		URL:           url,
+		Status:        domain.CallbackStatusPending,
+		NextAttemptAt: time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create callback: %v", err)
+	}
+}
+
+func TestSignWebhook(t *testing.T) {
+	body := []byte(`{"event":"factorial.done"}`)
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("1700000000." + string(body)))
+	expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	if got := SignWebhook([]byte("secret"), "1700000000", body); got != expected {
+		t.Errorf("Expected signature %s, got %s", expected, got)
+	}
+	if got := SignWebhook([]byte("other"), "1700000000", body); got == expected {
+		t.Error("Expected signature to depend on the secret")
+	}
+	if got := SignWebhook([]byte("secret"), "1700000001", body); got == expected {
+		t.Error("Expected signature to depend on the timestamp")
+	}
+}
+
+func TestWebhookService_DispatchDue_RetriesUntilDelivered(t *testing.T) {
+	db := setupWebhookTestDB(t)
+	callbackRepo := repository.NewCallbackRepository(db)
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		expected := SignWebhook([]byte("secret"), r.Header.Get(WebhookTimestampHeader), body)
+		if r.Header.Get(WebhookSignatureHeader) != expected {
+			t.Errorf("Expected signature %s, got %s", expected, r.Header.Get(WebhookSignatureHeader))
+		}
+
+		var payload dto.WebhookPayload
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Errorf("Failed to decode payload: %v", err)
+		}
+		if payload.Event != WebhookEventFactorialDone || payload.CallbackID != "cb-5" || payload.Data.Number != 5 || payload.Data.Checksum != checksum("120") {
+			t.Errorf("Unexpected payload: %+v", payload)
+		}
+
+		// Fail the first attempt so the callback is retried
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	createTestCallback(t, callbackRepo, "cb-5", 5, server.URL)
+	// Not dispatched until 6 is done
+	createTestCallback(t, callbackRepo, "cb-6", 6, server.URL)
+
+	svc := NewWebhookService(callbackRepo, repository.NewFactorialRepository(db), "secret",
+		WithWebhookHTTPClient(NewWebhookHTTPClient(time.Second, true)),
+		WithWebhookBackoff(time.Hour, time.Hour),
+	).(*webhookService)
+
+	ctx := context.Background()
+	if err := svc.dispatchDue(ctx); err != nil {
+		t.Fatalf("dispatchDue failed: %v", err)
+	}
+	callback, err := callbackRepo.FindByCallbackID("cb-5")
+	if err != nil {
+		t.Fatalf("Failed to find callback: %v", err)
+	}
+	if callback.Status != domain.CallbackStatusPending || callback.Attempts != 1 || callback.LastError == "" {
+		t.Errorf("Expected pending callback after a failed attempt, got %+v", callback)
+	}
+	if time.Until(callback.NextAttemptAt) < 59*time.Minute {
+		t.Errorf("Expected next attempt to be backed off by an hour, got %v", callback.NextAttemptAt)
+	}
+
+	// Backed off, so nothing is due
+	if err := svc.dispatchDue(ctx); err != nil {
+		t.Fatalf("dispatchDue failed: %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("Expected 1 call while backed off, got %d", calls.Load())
+	}
+
+	db.Model(&domain.FactorialCallback{}).Where("callback_id = ?", "cb-5").Update("next_attempt_at", time.Now().Add(-time.Second))
+	if err := svc.dispatchDue(ctx); err != nil {
+		t.Fatalf("dispatchDue failed: %v", err)
+	}
+	callback, _ = callbackRepo.FindByCallbackID("cb-5")
+	if callback.Status != domain.CallbackStatusDelivered || callback.Attempts != 2 || callback.LastError != "" {
+		t.Errorf("Expected delivered callback, got %+v", callback)
+	}
+
+	deliveries, err := callbackRepo.ListDeliveries("cb-5")
+	if err != nil {
+		t.Fatalf("Failed to list deliveries: %v", err)
+	}
+	if len(deliveries) != 2 {
+		t.Fatalf("Expected 2 deliveries, got %d", len(deliveries))
+	}
+	if deliveries[0].StatusCode != http.StatusServiceUnavailable || deliveries[0].Error == "" {
+		t.Errorf("Unexpected first delivery: %+v", deliveries[0])
+	}
+	if deliveries[1].StatusCode != http.StatusNoContent || deliveries[1].Error != "" {
+		t.Errorf("Unexpected second delivery: %+v", deliveries[1])
+	}
+
+	pending, _ := callbackRepo.FindByCallbackID("cb-6")
+	if pending.Attempts != 0 {
+		t.Errorf("Expected callback of a calculating factorial not to be attempted, got %d attempts", pending.Attempts)
+	}
+}
+
+func TestWebhookService_DispatchDue_FailsAfterMaxAttempts(t *testing.T) {
+	db := setupWebhookTestDB(t)
+	callbackRepo := repository.NewCallbackRepository(db)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	createTestCallback(t, callbackRepo, "cb-5", 5, server.URL)
+
+	svc := NewWebhookService(callbackRepo, repository.NewFactorialRepository(db), "secret",
+		WithWebhookHTTPClient(NewWebhookHTTPClient(time.Second, true)),
+		WithWebhookMaxAttempts(2),
+		WithWebhookBackoff(time.Millisecond, time.Millisecond),
+	).(*webhookService)
+
+	for i := 0; i < 3; i++ {
+		time.Sleep(5 * time.Millisecond)
+		if err := svc.dispatchDue(context.Background()); err != nil {
+			t.Fatalf("dispatchDue failed: %v", err)
+		}
+	}
+
+	callback, _ := callbackRepo.FindByCallbackID("cb-5")
+	if callback.Status != domain.CallbackStatusFailed || callback.Attempts != 2 {
+		t.Errorf("Expected failed callback after 2 attempts, got %+v", callback)
+	}
+}
+
+func TestWebhookService_RetryDelay(t *testing.T) {
+	svc := NewWebhookService(nil, nil, "secret", WithWebhookBackoff(time.Second, 10*time.Second)).(*webhookService)
+
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	for i, want := range expected {
+		if got := svc.retryDelay(i + 1); got != want {
+			t.Errorf("retryDelay(%d): expected %v, got %v", i+1, want, got)
+		}
+	}
+}
+
+func TestNewWebhookHTTPClient_RejectsPrivateNetworks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	_, err := NewWebhookHTTPClient(time.Second, false).Get(server.URL)
+	if !errors.Is(err, errWebhookAddressNotAllowed) {
+		t.Errorf("Expected loopback address to be rejected, got %v", err)
+	}
+
+	resp, err := NewWebhookHTTPClient(time.Second, true).Get(server.URL)
+	if err != nil {
+		t.Fatalf("Expected loopback address to be allowed, got %v", err)
+	}
+	resp.Body.Close()
+}
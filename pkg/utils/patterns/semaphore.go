@@ -1,6 +1,7 @@
 package patterns
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"sync"
@@ -27,21 +28,36 @@ func NewSemaphore(maxConcurrent int) *Semaphore {
 func (s *Semaphore) Submit(fn func() error) {
 	s.limit <- struct{}{} // Acquire
 	s.wg.Add(1)
+	go s.run(fn)
+}
 
-	go func() {
-		defer func() {
-			<-s.limit // Release
-			s.wg.Done()
-		}()
-		defer func() {
-			if r := recover(); r != nil {
-				log.Printf("PANIC recovered while processing task: %v", r)
-			}
-		}()
-		if err := fn(); err != nil {
-			log.Printf("task failed: %v", err)
+// SubmitContext runs the given task like Submit, but stops waiting for a free slot
+// when ctx is done and returns its error without running the task.
+func (s *Semaphore) SubmitContext(ctx context.Context, fn func() error) error {
+	select {
+	case s.limit <- struct{}{}: // Acquire
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	s.wg.Add(1)
+	go s.run(fn)
+	return nil
+}
+
+// run executes a task holding a slot and releases it when done.
+func (s *Semaphore) run(fn func() error) {
+	defer func() {
+		<-s.limit // Release
+		s.wg.Done()
+	}()
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("PANIC recovered while processing task: %v", r)
 		}
 	}()
+	if err := fn(); err != nil {
+		log.Printf("task failed: %v", err)
+	}
 }
 
 // Wait waits for all submitted tasks to finish.